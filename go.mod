@@ -4,7 +4,6 @@ go 1.16
 
 require (
 	github.com/ant0ine/go-json-rest v3.3.2+incompatible
-	github.com/cbergoon/merkletree v0.2.0
 	github.com/davidlazar/go-crypto v0.0.0-20200604182044-b73af7476f6c
 	github.com/filecoin-project/go-address v0.0.5
 	github.com/filecoin-project/go-crypto v0.0.0-20191218222705-effae4ea9f03
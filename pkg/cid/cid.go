@@ -2,6 +2,10 @@
 Package cid - provides methods for ContentID struct.
 
 ContentID is 32 bytes is a unique identifier of a file stored in a Filecoin blockchain network.
+
+A ContentID is either a multiformat CID, such as the piece CIDs of Filecoin clients, or a legacy 32 bytes
+identifier given in hex. The 32 bytes of a CID are its DHT key, the sha256 hash of the CIDv1 form of the CID,
+which places the CID on the 256-bit ring of the DHT.
 */
 package cid
 
@@ -21,12 +25,13 @@ package cid
  */
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math/big"
 
-	"github.com/cbergoon/merkletree"
+	gocid "github.com/ipfs/go-cid"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
 )
@@ -35,7 +40,10 @@ const WordSize = 32 // the ContentID length is 32 bytes.
 
 // ContentID represents a CID.
 type ContentID struct {
+	// id is the DHT key of the CID, or the legacy identifier.
 	id []byte
+	// cid is the multiformat CID, it is undefined for legacy identifiers.
+	cid gocid.Cid
 }
 
 // NewContentID creates a ContentID object.
@@ -72,6 +80,31 @@ func NewContentIDFromHexString(id string) (*ContentID, error) {
 	return NewContentIDFromBytes(b)
 }
 
+// NewContentIDFromCID creates a ContentID object from a multiformat CID.
+func NewContentIDFromCID(c gocid.Cid) (*ContentID, error) {
+	if !c.Defined() {
+		return nil, fmt.Errorf("ContentID: Undefined CID")
+	}
+	// CIDv0 and CIDv1 of the same content share the same DHT key.
+	key := sha256.Sum256(gocid.NewCidV1(c.Type(), c.Hash()).Bytes())
+	return &ContentID{id: key[:], cid: c}, nil
+}
+
+// NewContentIDFromString creates a ContentID object from a multiformat CID string, such as a CIDv0 "Qm..." or
+// a CIDv1 "baga6ea4sea..." piece CID, or from a legacy hex string.
+func NewContentIDFromString(id string) (*ContentID, error) {
+	if len(id) <= 2*WordSize {
+		if n, err := NewContentIDFromHexString(id); err == nil {
+			return n, nil
+		}
+	}
+	c, err := gocid.Decode(id)
+	if err != nil {
+		return nil, fmt.Errorf("ContentID: Invalid CID %s: %s", id, err.Error())
+	}
+	return NewContentIDFromCID(c)
+}
+
 // NewRandomContentID creates a random ContentID object.
 func NewRandomContentID() *ContentID {
 	var n = ContentID{}
@@ -80,8 +113,17 @@ func NewRandomContentID() *ContentID {
 	return &n
 }
 
-// ToString returns a string for the ContentID.
+// ToString returns a string for the ContentID: the multiformat CID string if the ContentID is a CID,
+// the hex string of the legacy identifier otherwise.
 func (n *ContentID) ToString() string {
+	if n.cid.Defined() {
+		return n.cid.String()
+	}
+	return n.ToDHTKey()
+}
+
+// ToDHTKey returns the hex string of the position of the ContentID on the DHT ring.
+func (n *ContentID) ToDHTKey() string {
 	str := hex.EncodeToString(n.id)
 	if str == "" {
 		str = "00"
@@ -89,18 +131,48 @@ func (n *ContentID) ToString() string {
 	return str
 }
 
-// ToBytes returns the byte array representation of the ContentID.
+// ToBytes returns the byte array representation of the ContentID, which is its DHT key.
 func (n *ContentID) ToBytes() []byte {
 	return n.id
 }
 
+// IsCID returns true if the ContentID is a multiformat CID, false if it is a legacy identifier.
+func (n *ContentID) IsCID() bool {
+	return n.cid.Defined()
+}
+
+// ToCID returns the multiformat CID of the ContentID, it returns an error for legacy identifiers.
+func (n *ContentID) ToCID() (gocid.Cid, error) {
+	if !n.cid.Defined() {
+		return gocid.Undef, fmt.Errorf("ContentID: %s is not a CID", n.ToString())
+	}
+	return n.cid, nil
+}
+
 // MarshalJSON is used to marshal CID into bytes.
+// A CID is marshalled as its string, a legacy identifier as its bytes.
 func (n ContentID) MarshalJSON() ([]byte, error) {
+	if n.cid.Defined() {
+		return json.Marshal(n.cid.String())
+	}
 	return json.Marshal(n.id)
 }
 
 // UnmarshalJSON is used to unmarshal bytes into ContentID.
 func (n *ContentID) UnmarshalJSON(p []byte) error {
+	var str string
+	if err := json.Unmarshal(p, &str); err == nil {
+		// A base64 encoded legacy identifier ends with a padding character, which no CID string contains.
+		if c, err := gocid.Decode(str); err == nil {
+			contentID, err := NewContentIDFromCID(c)
+			if err != nil {
+				return err
+			}
+			*n = *contentID
+			return nil
+		}
+	}
+
 	var id []byte
 	err := json.Unmarshal(p, &id)
 	if err != nil {
@@ -112,6 +184,7 @@ func (n *ContentID) UnmarshalJSON(p []byte) error {
 	}
 	n.id = make([]byte, WordSize)
 	copy(n.id, id)
+	n.cid = gocid.Undef
 	return nil
 }
 
@@ -121,8 +194,8 @@ func (n ContentID) CalculateHash() ([]byte, error) {
 }
 
 // Equals tests for equality of two ContentIDs.
-func (n ContentID) Equals(other merkletree.Content) (bool, error) {
-	return n.ToString() == other.(*ContentID).ToString(), nil
+func (n ContentID) Equals(other *ContentID) (bool, error) {
+	return n.ToString() == other.ToString(), nil
 }
 
 // Map - used to returns a new slice containing CIDs as string values
@@ -134,12 +207,16 @@ func MapCIDToString(cids []ContentID) []string {
 	return results
 }
 
-// Map - used to returns a new slice containing CIDs as string values
-func MapStringToCID(cids []string) []ContentID {
+// MapStringToCID is used to return a new slice containing the CIDs of given string values.
+// An error is returned for the first string value which is not a valid CID.
+func MapStringToCID(cids []string) ([]ContentID, error) {
 	results := make([]ContentID, len(cids))
 	for i, v := range cids {
-		contentID, _ := NewContentIDFromHexString(v)
+		contentID, err := NewContentIDFromString(v)
+		if err != nil {
+			return nil, err
+		}
 		results[i] = *contentID
 	}
-	return results
+	return results, nil
 }
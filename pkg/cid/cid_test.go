@@ -183,3 +183,70 @@ func TestEqual(t *testing.T) {
 	assert.Empty(t, err)
 	assert.False(t, res2)
 }
+
+const (
+	testPieceCID = "baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq"
+	testCIDv0    = "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG"
+	testCIDv1    = "bafybeie5nqv6kd3qnfjupgvz34woh3oksc3iau6abmyajn7qvtf6d2ho34"
+)
+
+func TestNewContentIDFromPieceCID(t *testing.T) {
+	cid, err := NewContentIDFromString(testPieceCID)
+	assert.Empty(t, err)
+	assert.True(t, cid.IsCID())
+	assert.Equal(t, testPieceCID, cid.ToString())
+	assert.Equal(t, "fd0b2e27cb2d1912c972bdba2bc95bf798941c68abd58853659c895e8af0207f", cid.ToDHTKey())
+	assert.Equal(t, WordSize, len(cid.ToBytes()))
+	c, err := cid.ToCID()
+	assert.Empty(t, err)
+	assert.Equal(t, testPieceCID, c.String())
+}
+
+func TestNewContentIDFromCIDv0AndV1(t *testing.T) {
+	cid0, err := NewContentIDFromString(testCIDv0)
+	assert.Empty(t, err)
+	cid1, err := NewContentIDFromString(testCIDv1)
+	assert.Empty(t, err)
+	assert.Equal(t, testCIDv0, cid0.ToString())
+	assert.Equal(t, testCIDv1, cid1.ToString())
+	assert.Equal(t, cid0.ToDHTKey(), cid1.ToDHTKey())
+}
+
+func TestNewContentIDFromStringLegacyHex(t *testing.T) {
+	cid, err := NewContentIDFromString("10")
+	assert.Empty(t, err)
+	assert.False(t, cid.IsCID())
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000010", cid.ToString())
+	assert.Equal(t, cid.ToString(), cid.ToDHTKey())
+	_, err = cid.ToCID()
+	assert.NotEmpty(t, err)
+}
+
+func TestNewContentIDFromInvalidCIDString(t *testing.T) {
+	cid, err := NewContentIDFromString("baga6ea4seanotacid")
+	assert.NotEmpty(t, err)
+	assert.Empty(t, cid)
+}
+
+func TestJSONWithCID(t *testing.T) {
+	cid1, err := NewContentIDFromString(testPieceCID)
+	assert.Empty(t, err)
+	p, err := json.Marshal(cid1)
+	assert.Empty(t, err)
+	assert.Equal(t, `"`+testPieceCID+`"`, string(p))
+	cid2 := ContentID{}
+	err = json.Unmarshal(p, &cid2)
+	assert.Empty(t, err)
+	assert.Equal(t, cid1.ToString(), cid2.ToString())
+	assert.Equal(t, cid1.ToBytes(), cid2.ToBytes())
+}
+
+func TestMapStringToCIDWithCIDs(t *testing.T) {
+	cids, err := MapStringToCID([]string{testPieceCID, "10"})
+	assert.Empty(t, err)
+	assert.Equal(t, []string{testPieceCID, "0000000000000000000000000000000000000000000000000000000000000010"}, MapCIDToString(cids))
+
+	cids, err = MapStringToCID([]string{testPieceCID, "not a cid"})
+	assert.NotEmpty(t, err)
+	assert.Empty(t, cids)
+}
@@ -23,8 +23,6 @@ package cid
 import (
 	"crypto/sha256"
 	"fmt"
-
-	"github.com/cbergoon/merkletree"
 )
 
 // ContentIDAdapter represents a CID.
@@ -44,9 +42,9 @@ func (n ContentIDAdapter) CalculateHash() ([]byte, error) {
 	return h.Sum(nil), nil
 }
 
-//Equals tests for equality of two Contents
-func (n ContentIDAdapter) Equals(other merkletree.Content) (bool, error) {
-	return n.ToString() == other.(*ContentIDAdapter).ToString(), nil
+// Equals tests for equality of two Contents
+func (n ContentIDAdapter) Equals(other *ContentIDAdapter) (bool, error) {
+	return n.ToString() == other.ToString(), nil
 }
 
 // ToString returns a string for the ContentID.
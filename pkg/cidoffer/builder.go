@@ -0,0 +1,129 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// OfferBuilder creates the signed offers of a provider.
+// The offers are checked against the expiry policy and the maximum number of CIDs of the builder, their CIDs are
+// deduplicated and sorted so that offers of the same CIDs have the same merkle root and digest whatever the order
+// the CIDs are given in.
+type OfferBuilder struct {
+	providerID *nodeid.NodeID
+	keyPair    *fcrcrypto.KeyPair
+	keyVersion *fcrcrypto.KeyVersion
+
+	// maxTTL is the maximum duration between now and the expiry of an offer, 0 means no limit.
+	maxTTL time.Duration
+	// maxCIDs is the maximum number of distinct CIDs of an offer, 0 means no limit.
+	maxCIDs int
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewOfferBuilder creates an OfferBuilder signing the offers of a given provider with a given key pair and key version.
+func NewOfferBuilder(providerID *nodeid.NodeID, keyPair *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion) *OfferBuilder {
+	return &OfferBuilder{
+		providerID: providerID,
+		keyPair:    keyPair,
+		keyVersion: keyVersion,
+		now:        time.Now,
+	}
+}
+
+// SetClock is used to set the function returning the current time the expiry of the offers is checked against.
+func (b *OfferBuilder) SetClock(now func() time.Time) *OfferBuilder {
+	if now != nil {
+		b.now = now
+	}
+	return b
+}
+
+// SetMaxTTL is used to limit the duration between now and the expiry of the offers, 0 means no limit.
+func (b *OfferBuilder) SetMaxTTL(maxTTL time.Duration) *OfferBuilder {
+	if maxTTL >= 0 {
+		b.maxTTL = maxTTL
+	}
+	return b
+}
+
+// SetMaxCIDs is used to limit the number of distinct CIDs of the offers, 0 means no limit.
+func (b *OfferBuilder) SetMaxCIDs(maxCIDs int) *OfferBuilder {
+	if maxCIDs >= 0 {
+		b.maxCIDs = maxCIDs
+	}
+	return b
+}
+
+// Build creates a signed offer for given CIDs, price, expiry and quality of service.
+// The expiry is a unix time in seconds, it must be in the future and within the maximum TTL.
+func (b *OfferBuilder) Build(cids []cid.ContentID, price uint64, expiry int64, qos uint64) (*CIDOffer, error) {
+	if b.providerID == nil || b.keyPair == nil || b.keyVersion == nil {
+		return nil, errors.New("CID Offer: provider id, key pair and key version are required")
+	}
+	now := b.now()
+	expiryTime := time.Unix(expiry, 0)
+	if !expiryTime.After(now) {
+		return nil, fmt.Errorf("CID Offer: expiry %d is not in the future", expiry)
+	}
+	if b.maxTTL > 0 && expiryTime.Sub(now) > b.maxTTL {
+		return nil, fmt.Errorf("CID Offer: expiry %d is more than %s away", expiry, b.maxTTL)
+	}
+	sorted := SortCIDs(cids)
+	if b.maxCIDs > 0 && len(sorted) > b.maxCIDs {
+		return nil, fmt.Errorf("CID Offer: %d CIDs, should be at most %d", len(sorted), b.maxCIDs)
+	}
+	offer, err := NewCIDOffer(b.providerID, sorted, price, expiry, qos)
+	if err != nil {
+		return nil, err
+	}
+	if err = offer.Sign(b.keyPair, b.keyVersion); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// SortCIDs returns the CIDs of a given list with distinct DHT keys, ordered by DHT key. Of CIDs sharing a DHT key,
+// such as a CIDv0 and a CIDv1 of the same content, the one with the lowest string is kept.
+func SortCIDs(cids []cid.ContentID) []cid.ContentID {
+	sorted := make([]cid.ContentID, len(cids))
+	copy(sorted, cids)
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := bytes.Compare(sorted[i].ToBytes(), sorted[j].ToBytes()); c != 0 {
+			return c < 0
+		}
+		return sorted[i].ToString() < sorted[j].ToString()
+	})
+	distinct := sorted[:0]
+	for i, id := range sorted {
+		if i > 0 && bytes.Equal(id.ToBytes(), sorted[i-1].ToBytes()) {
+			continue
+		}
+		distinct = append(distinct, id)
+	}
+	return distinct
+}
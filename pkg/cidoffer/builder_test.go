@@ -0,0 +1,116 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBuilder(t *testing.T, now time.Time) *OfferBuilder {
+	aNodeID, err := nodeid.NewNodeID(big.NewInt(7))
+	assert.Empty(t, err)
+	privKey, err := fcrcrypto.DecodePrivateKey(PrivKey)
+	assert.Empty(t, err)
+	return NewOfferBuilder(aNodeID, privKey, fcrcrypto.InitialKeyVersion()).
+		SetClock(func() time.Time { return now })
+}
+
+func newTestCIDs(t *testing.T, ids ...int64) []cid.ContentID {
+	cids := make([]cid.ContentID, len(ids))
+	for i, id := range ids {
+		aCid, err := cid.NewContentID(big.NewInt(id))
+		assert.Empty(t, err)
+		cids[i] = *aCid
+	}
+	return cids
+}
+
+func TestOfferBuilder(t *testing.T) {
+	now := time.Unix(1_600_000_000, 0)
+	builder := newTestBuilder(t, now)
+	offer1, err := builder.Build(newTestCIDs(t, 3, 1, 2, 1), 5, now.Add(time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+	assert.Equal(t, cid.MapCIDToString(newTestCIDs(t, 1, 2, 3)), cid.MapCIDToString(offer1.GetCIDs()))
+	assert.NotEmpty(t, offer1.GetSignature())
+
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	assert.Empty(t, offer1.Verify(pubKey))
+
+	offer2, err := builder.Build(newTestCIDs(t, 2, 3, 1), 5, now.Add(time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+	assert.Equal(t, offer1.GetMessageDigest(), offer2.GetMessageDigest())
+	assert.Equal(t, offer1.merkleRoot, offer2.merkleRoot)
+	assert.Equal(t, offer1.GetSignature(), offer2.GetSignature())
+}
+
+func TestOfferBuilderWithError(t *testing.T) {
+	now := time.Unix(1_600_000_000, 0)
+	builder := newTestBuilder(t, now).SetMaxTTL(time.Hour).SetMaxCIDs(2)
+
+	_, err := builder.Build(newTestCIDs(t, 1), 5, now.Unix(), 5)
+	assert.EqualError(t, err, "CID Offer: expiry 1600000000 is not in the future")
+
+	_, err = builder.Build(newTestCIDs(t, 1), 5, now.Add(2*time.Hour).Unix(), 5)
+	assert.EqualError(t, err, "CID Offer: expiry 1600007200 is more than 1h0m0s away")
+
+	_, err = builder.Build(newTestCIDs(t, 1, 2, 3), 5, now.Add(time.Hour).Unix(), 5)
+	assert.EqualError(t, err, "CID Offer: 3 CIDs, should be at most 2")
+
+	_, err = builder.Build(newTestCIDs(t, 1, 2, 2), 5, now.Add(time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+
+	_, err = builder.Build(nil, 5, now.Add(time.Hour).Unix(), 5)
+	assert.NotEmpty(t, err)
+
+	_, err = NewOfferBuilder(nil, nil, nil).Build(newTestCIDs(t, 1), 5, time.Now().Add(time.Hour).Unix(), 5)
+	assert.NotEmpty(t, err)
+}
+
+func TestSortCIDs(t *testing.T) {
+	pieceCID, err := cid.NewContentIDFromString("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	assert.Empty(t, err)
+	cids := append(newTestCIDs(t, 2), *pieceCID)
+	cids = append(cids, newTestCIDs(t, 1, 2)...)
+	sorted := SortCIDs(cids)
+	assert.Equal(t, []string{
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		pieceCID.ToString(),
+	}, cid.MapCIDToString(sorted))
+	assert.Equal(t, 4, len(cids))
+}
+
+func TestSortCIDsSameContent(t *testing.T) {
+	v0, err := cid.NewContentIDFromString("QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG")
+	assert.Empty(t, err)
+	v1, err := cid.NewContentIDFromString("bafybeie5nqv6kd3qnfjupgvz34woh3oksc3iau6abmyajn7qvtf6d2ho34")
+	assert.Empty(t, err)
+	assert.Equal(t, v0.ToBytes(), v1.ToBytes())
+	assert.Equal(t, SortCIDs([]cid.ContentID{*v0, *v1}), SortCIDs([]cid.ContentID{*v1, *v0}))
+	assert.Equal(t, 1, len(SortCIDs([]cid.ContentID{*v1, *v0})))
+
+	offer, err := newTestBuilder(t, time.Now()).Build([]cid.ContentID{*v1, *v0}, 5, time.Now().Add(time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+	assert.Equal(t, 1, len(offer.GetCIDs()))
+}
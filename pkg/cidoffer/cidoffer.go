@@ -32,7 +32,6 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmerkletree"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
-	"github.com/cbergoon/merkletree"
 )
 
 const CIDOfferDigestSize = sha512.Size256
@@ -61,21 +60,13 @@ type cidOfferJson struct {
 	Signature  string   `json:"signature"`
 }
 
-// cidOfferSigning is used to generate and verify signature.
-type cidOfferSigning struct {
-	ProviderID nodeid.NodeID `json:"provider_id"`
-	MerkleRoot string        `json:"merkle_root"` // this field is missing in json struct
-	Price      uint64        `json:"price"`
-	Expiry     int64         `json:"expiry"`
-	QoS        uint64        `json:"qos"`
-}
-
 // NewCidOffer creates an unsigned CID Offer.
+// The expiry is not checked and the CIDs are kept in the given order so that received offers can be loaded as is,
+// new offers should be created with an OfferBuilder.
 func NewCIDOffer(providerID *nodeid.NodeID, cids []cid.ContentID, price uint64, expiry int64, qos uint64) (*CIDOffer, error) {
 	if len(cids) < 1 {
 		return nil, errors.New("Group CID Offer: need to provide at least 1 CID")
 	}
-	// TODO: Check that the expiry is in the future (are there scenarios where an expired offer should be loadable?)
 	var c = CIDOffer{
 		providerID: providerID,
 		cids:       cids,
@@ -85,7 +76,7 @@ func NewCIDOffer(providerID *nodeid.NodeID, cids []cid.ContentID, price uint64,
 	}
 
 	// Create merkle tree & merkle root
-	list := make([]merkletree.Content, len(cids))
+	list := make([]fcrmerkletree.Content, len(cids))
 	for i := 0; i < len(cids); i++ {
 		list[i] = (cids)[i]
 	}
@@ -212,15 +203,9 @@ func (c CIDOffer) MarshalJSON() ([]byte, error) {
 	})
 }
 
-// MarshalJSON is used to marshal offer into bytes.
+// MarshalToSign is used to marshal offer into the canonical bytes to sign, see offerSigningPayload.
 func (c CIDOffer) MarshalToSign() ([]byte, error) {
-	return json.Marshal(cidOfferSigning{
-		ProviderID: *c.providerID,
-		MerkleRoot: c.merkleRoot,
-		Price:      c.price,
-		Expiry:     c.expiry,
-		QoS:        c.qos,
-	})
+	return offerSigningPayload(c.providerID, c.merkleRoot, c.price, c.expiry, c.qos)
 }
 
 // UnmarshalJSON is used to unmarshal bytes into offer.
@@ -230,15 +215,21 @@ func (c *CIDOffer) UnmarshalJSON(p []byte) error {
 	if err != nil {
 		return err
 	}
-	nodeID, _ := nodeid.NewNodeIDFromHexString(cJson.ProviderID)
+	nodeID, err := nodeid.NewNodeIDFromHexString(cJson.ProviderID)
+	if err != nil {
+		return err
+	}
 	c.providerID = nodeID
-	c.cids = cid.MapStringToCID(cJson.CIDs)
+	c.cids, err = cid.MapStringToCID(cJson.CIDs)
+	if err != nil {
+		return err
+	}
 	c.price = cJson.Price
 	c.expiry = cJson.Expiry
 	c.qos = cJson.QoS
 	c.signature = cJson.Signature
 	// Reconstrct the merkle trie
-	list := make([]merkletree.Content, len(c.cids))
+	list := make([]fcrmerkletree.Content, len(c.cids))
 	for i := 0; i < len(c.cids); i++ {
 		list[i] = (c.cids)[i]
 	}
@@ -129,7 +129,7 @@ func TestSigning(t *testing.T) {
 	assert.Empty(t, err)
 	err = offer.Sign(privKey, fcrcrypto.InitialKeyVersion())
 	assert.Empty(t, err)
-	assert.Equal(t, "000000011445d8467f41ecc6c452f5dbb0a3a0fa2dacc24f0b3dd3a110713541c4fa8b3a2582b7724eb13ffad92dd0c312150bc196cc3eb981aa9a332dc7871cb0da18eb01", offer.GetSignature())
+	assert.Equal(t, "00000001dfb4fe107c3ed0df6463feeb9673d0309d9791b154339700525c69dcece1cc953ce4a0565c165bd26d71e2cd452d12e45962cc491b4945af18b623a31bddb85300", offer.GetSignature())
 
 	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
 	assert.Empty(t, err)
@@ -212,4 +212,8 @@ func TestJSON(t *testing.T) {
 	assert.Equal(t, offer.merkleRoot, offer2.merkleRoot)
 	err = offer2.UnmarshalJSON([]byte{})
 	assert.NotEmpty(t, err)
+	err = offer2.UnmarshalJSON([]byte(`{"provider_id":"zz","cids":["01"]}`))
+	assert.NotEmpty(t, err)
+	err = offer2.UnmarshalJSON([]byte(`{"provider_id":"01","cids":["zz"]}`))
+	assert.NotEmpty(t, err)
 }
@@ -0,0 +1,205 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmerkletree"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// MultiSubCIDOffer represents several sub CID Offers of the same CID offer.
+// It contains the sub cids and a single merkle multiproof showing that all the sub cids
+// are part of the cid array in the original cid offer.
+type MultiSubCIDOffer struct {
+	providerID  *nodeid.NodeID
+	subCIDs     []cid.ContentID
+	merkleRoot  string
+	merkleProof *fcrmerkletree.FCRMerkleMultiProof
+	price       uint64
+	expiry      int64
+	qos         uint64
+	signature   string
+}
+
+// multiSubCIDOfferJson is used to parse to and from json.
+type multiSubCIDOfferJson struct {
+	ProviderID  string                            `json:"provider_id"`
+	SubCIDs     []string                          `json:"sub_cids"`
+	MerkleRoot  string                            `json:"merkle_root"`
+	MerkleProof fcrmerkletree.FCRMerkleMultiProof `json:"merkle_proof"`
+	Price       uint64                            `json:"price"`
+	Expiry      int64                             `json:"expiry"`
+	QoS         uint64                            `json:"qos"`
+	Signature   string                            `json:"signature"`
+}
+
+// NewMultiSubCIDOffer creates a multi sub CID Offer.
+func NewMultiSubCIDOffer(providerID *nodeid.NodeID, subCIDs []cid.ContentID, merkleRoot string, merkleProof *fcrmerkletree.FCRMerkleMultiProof, price uint64, expiry int64, qos uint64, signature string) *MultiSubCIDOffer {
+	return &MultiSubCIDOffer{
+		providerID:  providerID,
+		subCIDs:     subCIDs,
+		merkleRoot:  merkleRoot,
+		merkleProof: merkleProof,
+		price:       price,
+		expiry:      expiry,
+		qos:         qos,
+		signature:   signature,
+	}
+}
+
+// GenerateMultiSubCIDOffer is used to generate a multi sub cid offer with a single proof for given distinct cids.
+func (c *CIDOffer) GenerateMultiSubCIDOffer(cids []cid.ContentID) (*MultiSubCIDOffer, error) {
+	proof, err := c.merkleTree.GenerateMerkleMultiProof(contentList(cids))
+	if err != nil {
+		return nil, err
+	}
+	return NewMultiSubCIDOffer(c.providerID, cids, c.merkleRoot, proof, c.price, c.expiry, c.qos, c.signature), nil
+}
+
+// GetProviderID returns the provider ID of this offer.
+func (c *MultiSubCIDOffer) GetProviderID() *nodeid.NodeID {
+	return c.providerID
+}
+
+// GetSubCIDs returns the sub cids of this offer.
+func (c *MultiSubCIDOffer) GetSubCIDs() []cid.ContentID {
+	return c.subCIDs
+}
+
+// GetMerkleRoot returns the merkle root of this offer.
+func (c *MultiSubCIDOffer) GetMerkleRoot() string {
+	return c.merkleRoot
+}
+
+// GetMerkleProof returns the merkle multiproof of this offer.
+func (c *MultiSubCIDOffer) GetMerkleProof() *fcrmerkletree.FCRMerkleMultiProof {
+	return c.merkleProof
+}
+
+// GetPrice returns the price of this offer.
+func (c *MultiSubCIDOffer) GetPrice() uint64 {
+	return c.price
+}
+
+// GetExpiry returns the expiry of this offer.
+func (c *MultiSubCIDOffer) GetExpiry() int64 {
+	return c.expiry
+}
+
+// GetQoS returns the quality of service of this offer.
+func (c *MultiSubCIDOffer) GetQoS() uint64 {
+	return c.qos
+}
+
+// GetSignature returns the signature of this offer.
+func (c *MultiSubCIDOffer) GetSignature() string {
+	return c.signature
+}
+
+// HasExpired returns true if the offer expiry date is in the past.
+func (c *MultiSubCIDOffer) HasExpired() bool {
+	expiryTime := time.Unix(c.expiry, 0)
+	now := time.Now()
+	return expiryTime.Before(now)
+}
+
+// Verify is used to verify the offer with a given public key.
+func (c *MultiSubCIDOffer) Verify(pubKey *fcrcrypto.KeyPair) error {
+	raw, err := c.MarshalToSign()
+	if err != nil {
+		return err
+	}
+	res, err := fcrcrypto.VerifyMessage(pubKey, c.signature, raw)
+	if err != nil {
+		return err
+	}
+	if !res {
+		return errors.New("Offer does not pass signature verification")
+	}
+	return nil
+}
+
+// VerifyMerkleProof is used to verify the sub cids are all part of the merkle trie
+func (c *MultiSubCIDOffer) VerifyMerkleProof() error {
+	if c.merkleProof.VerifyContents(contentList(c.subCIDs), c.merkleRoot) {
+		return nil
+	}
+	return errors.New("Offer does not pass merkle proof verification")
+}
+
+// MarshalJSON is used to marshal offer into bytes.
+func (c MultiSubCIDOffer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(multiSubCIDOfferJson{
+		ProviderID:  c.providerID.ToString(),
+		SubCIDs:     cid.MapCIDToString(c.subCIDs),
+		MerkleRoot:  c.merkleRoot,
+		MerkleProof: *c.merkleProof,
+		Price:       c.price,
+		Expiry:      c.expiry,
+		QoS:         c.qos,
+		Signature:   c.signature,
+	})
+}
+
+// UnmarshalJSON is used to unmarshal bytes into offer.
+func (c *MultiSubCIDOffer) UnmarshalJSON(p []byte) error {
+	cJson := multiSubCIDOfferJson{}
+	err := json.Unmarshal(p, &cJson)
+	if err != nil {
+		return err
+	}
+	providerID, err := nodeid.NewNodeIDFromHexString(cJson.ProviderID)
+	if err != nil {
+		return err
+	}
+	c.providerID = providerID
+	c.subCIDs = make([]cid.ContentID, len(cJson.SubCIDs))
+	for i, id := range cJson.SubCIDs {
+		contentID, err := cid.NewContentIDFromString(id)
+		if err != nil {
+			return err
+		}
+		c.subCIDs[i] = *contentID
+	}
+	c.merkleRoot = cJson.MerkleRoot
+	c.merkleProof = &cJson.MerkleProof
+	c.price = cJson.Price
+	c.expiry = cJson.Expiry
+	c.qos = cJson.QoS
+	c.signature = cJson.Signature
+	return nil
+}
+
+// MarshalToSign is used to marshal multi sub offer into the canonical bytes to sign, which are the ones of the offer
+// it is part of, see offerSigningPayload.
+func (c *MultiSubCIDOffer) MarshalToSign() ([]byte, error) {
+	return offerSigningPayload(c.providerID, c.merkleRoot, c.price, c.expiry, c.qos)
+}
+
+// contentList returns given cids as merkle tree contents.
+func contentList(cids []cid.ContentID) []fcrmerkletree.Content {
+	list := make([]fcrmerkletree.Content, len(cids))
+	for i := range cids {
+		list[i] = &cids[i]
+	}
+	return list
+}
@@ -0,0 +1,101 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSignedOffer(t *testing.T, cids []cid.ContentID) *CIDOffer {
+	aNodeID, err := nodeid.NewNodeID(big.NewInt(7))
+	assert.Empty(t, err)
+	offer, err := NewCIDOffer(aNodeID, cids, 5, time.Now().Add(12*time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+	privKey, err := fcrcrypto.DecodePrivateKey(PrivKey)
+	assert.Empty(t, err)
+	assert.Empty(t, offer.Sign(privKey, fcrcrypto.InitialKeyVersion()))
+	return offer
+}
+
+func TestNewMultiSubCIDOfferWithGet(t *testing.T) {
+	cids := newTestCIDs(t, 1, 2, 3, 4, 5, 6, 7)
+	offer := newTestSignedOffer(t, cids)
+	subCIDs := []cid.ContentID{cids[5], cids[1], cids[2]}
+	multiOffer, err := offer.GenerateMultiSubCIDOffer(subCIDs)
+	assert.Empty(t, err)
+	assert.Equal(t, offer.GetProviderID(), multiOffer.GetProviderID())
+	assert.Equal(t, subCIDs, multiOffer.GetSubCIDs())
+	assert.Equal(t, offer.merkleRoot, multiOffer.GetMerkleRoot())
+	assert.NotEmpty(t, multiOffer.GetMerkleProof())
+	assert.Equal(t, offer.GetPrice(), multiOffer.GetPrice())
+	assert.Equal(t, offer.GetExpiry(), multiOffer.GetExpiry())
+	assert.Equal(t, offer.GetQoS(), multiOffer.GetQoS())
+	assert.Equal(t, offer.GetSignature(), multiOffer.GetSignature())
+	assert.False(t, multiOffer.HasExpired())
+
+	_, err = offer.GenerateMultiSubCIDOffer(newTestCIDs(t, 8))
+	assert.NotEmpty(t, err)
+}
+
+func TestMultiSubOfferVerify(t *testing.T) {
+	cids := newTestCIDs(t, 1, 2, 3, 4, 5)
+	offer := newTestSignedOffer(t, cids)
+	multiOffer, err := offer.GenerateMultiSubCIDOffer(cids[1:4])
+	assert.Empty(t, err)
+
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	assert.Empty(t, multiOffer.Verify(pubKey))
+	pubKey, err = fcrcrypto.DecodePublicKey(PubKeyWrong)
+	assert.Empty(t, err)
+	assert.NotEmpty(t, multiOffer.Verify(pubKey))
+
+	assert.Empty(t, multiOffer.VerifyMerkleProof())
+	multiOffer.subCIDs = newTestCIDs(t, 2, 3, 5)
+	assert.NotEmpty(t, multiOffer.VerifyMerkleProof())
+}
+
+func TestJSONMultiSubOffer(t *testing.T) {
+	cids := newTestCIDs(t, 1, 2, 3, 4, 5)
+	offer := newTestSignedOffer(t, cids)
+	multiOffer, err := offer.GenerateMultiSubCIDOffer([]cid.ContentID{cids[4], cids[0]})
+	assert.Empty(t, err)
+
+	p, err := json.Marshal(multiOffer)
+	assert.Empty(t, err)
+	multiOffer2 := MultiSubCIDOffer{}
+	err = json.Unmarshal(p, &multiOffer2)
+	assert.Empty(t, err)
+	assert.Equal(t, cid.MapCIDToString(multiOffer.GetSubCIDs()), cid.MapCIDToString(multiOffer2.GetSubCIDs()))
+	assert.Equal(t, multiOffer.GetMerkleProof(), multiOffer2.GetMerkleProof())
+	assert.Equal(t, multiOffer.GetSignature(), multiOffer2.GetSignature())
+	assert.Empty(t, multiOffer2.VerifyMerkleProof())
+
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	assert.Empty(t, multiOffer2.Verify(pubKey))
+
+	err = json.Unmarshal([]byte(`{"provider_id":"07","sub_cids":["not a cid"]}`), &multiOffer2)
+	assert.NotEmpty(t, err)
+}
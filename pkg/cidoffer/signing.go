@@ -0,0 +1,67 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// Offer signing payload.
+//
+// A CIDOffer and the SubCIDOffers generated from it share the same signature, which is the signature of the
+// following payload. Version 1 layout, all integers are big-endian:
+//
+//	domain tag         13 bytes (ASCII "fcr-cid-offer")
+//	version            1 byte   (0x01)
+//	provider id        32 bytes
+//	merkle root        32 bytes
+//	price              8 bytes  (uint64)
+//	expiry             8 bytes  (int64, unix time in seconds)
+//	qos                8 bytes  (uint64)
+const (
+	offerSigningTag             = "fcr-cid-offer"
+	offerSigningVersion    byte = 0x01
+	offerMerkleRootSize         = 32
+	offerSigningPayloadLen      = len(offerSigningTag) + 1 + nodeid.WordSize + offerMerkleRootSize + 3*8
+)
+
+// offerSigningPayload returns the payload signed for an offer of a given provider, merkle root, price, expiry and qos.
+func offerSigningPayload(providerID *nodeid.NodeID, merkleRoot string, price uint64, expiry int64, qos uint64) ([]byte, error) {
+	if providerID == nil {
+		return nil, fmt.Errorf("CID Offer: provider id is required to sign")
+	}
+	root, err := hex.DecodeString(merkleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("CID Offer: invalid merkle root: %s", err.Error())
+	}
+	if len(root) != offerMerkleRootSize {
+		return nil, fmt.Errorf("CID Offer: merkle root of %d bytes, should be %d", len(root), offerMerkleRootSize)
+	}
+	b := make([]byte, offerSigningPayloadLen)
+	ofs := copy(b, offerSigningTag)
+	b[ofs] = offerSigningVersion
+	ofs++
+	ofs += copy(b[ofs:], providerID.ToBytes())
+	ofs += copy(b[ofs:], root)
+	binary.BigEndian.PutUint64(b[ofs:], price)
+	binary.BigEndian.PutUint64(b[ofs+8:], uint64(expiry))
+	binary.BigEndian.PutUint64(b[ofs+16:], qos)
+	return b, nil
+}
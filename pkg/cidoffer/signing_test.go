@@ -0,0 +1,99 @@
+package cidoffer
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of the offer signing payload, signed with PrivKey and the initial key version.
+var offerSigningVectors = []struct {
+	providerID int64
+	merkleRoot string
+	price      uint64
+	expiry     int64
+	qos        uint64
+	payload    string
+	signature  string
+}{
+	{
+		providerID: 7,
+		merkleRoot: "0000000000000000000000000000000000000000000000000000000000000000",
+		price:      0,
+		expiry:     0,
+		qos:        0,
+		payload:    "6663722d6369642d6f666665720100000000000000000000000000000000000000000000000000000000000000070000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+		signature:  "000000019ce42c273bb9723b5739cfe38e95d902765f99029ed06319fc7036b7cb5fa5773dcc5b13afb9a5801d194a283103bf5c76a0997e3c3bca60ba6a241257c11de300",
+	},
+	{
+		providerID: 0x0102030405,
+		merkleRoot: "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff",
+		price:      42,
+		expiry:     1_600_000_000,
+		qos:        100,
+		payload:    "6663722d6369642d6f6666657201000000000000000000000000000000000000000000000000000000010203040500112233445566778899aabbccddeeff00112233445566778899aabbccddeeff000000000000002a000000005f5e10000000000000000064",
+		signature:  "0000000180d4749d662f27b4bc4647fcc8d7f8b9e25f9ceb485eb21f619ac83d48367d99622ee8473f3e6ee8fc11ee01be70e478a946cde79cda3a3df09949db226f95a900",
+	},
+	{
+		providerID: 1,
+		merkleRoot: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		price:      ^uint64(0),
+		expiry:     -1,
+		qos:        ^uint64(0),
+		payload:    "6663722d6369642d6f66666572010000000000000000000000000000000000000000000000000000000000000001ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		signature:  "00000001a301517add465aa6bd1e2f78cd083f5aa110aef05e6c9f3ea47073b375d1753011a1038e51188321f98312f3b9e616658b95c48930ec8c358fce4d8e5d62040601",
+	},
+}
+
+func TestOfferSigningVectors(t *testing.T) {
+	privKey, err := fcrcrypto.DecodePrivateKey(PrivKey)
+	assert.Empty(t, err)
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	aCid, err := cid.NewContentID(big.NewInt(1))
+	assert.Empty(t, err)
+	for _, v := range offerSigningVectors {
+		providerID, err := nodeid.NewNodeID(big.NewInt(v.providerID))
+		assert.Empty(t, err)
+		offer := NewSubCIDOffer(providerID, aCid, v.merkleRoot, nil, v.price, v.expiry, v.qos, "")
+		payload, err := offer.MarshalToSign()
+		assert.Empty(t, err)
+		assert.Equal(t, v.payload, hex.EncodeToString(payload))
+		signature, err := fcrcrypto.SignMessage(privKey, fcrcrypto.InitialKeyVersion(), payload)
+		assert.Empty(t, err)
+		assert.Equal(t, v.signature, signature)
+		offer = NewSubCIDOffer(providerID, aCid, v.merkleRoot, nil, v.price, v.expiry, v.qos, v.signature)
+		assert.Empty(t, offer.Verify(pubKey))
+	}
+}
+
+func TestOfferSigningPayloadWithError(t *testing.T) {
+	providerID, err := nodeid.NewNodeID(big.NewInt(7))
+	assert.Empty(t, err)
+	_, err = offerSigningPayload(providerID, "not hex", 0, 0, 0)
+	assert.NotEmpty(t, err)
+	_, err = offerSigningPayload(providerID, "00", 0, 0, 0)
+	assert.EqualError(t, err, "CID Offer: merkle root of 1 bytes, should be 32")
+	_, err = offerSigningPayload(nil, "", 0, 0, 0)
+	assert.NotEmpty(t, err)
+}
@@ -52,15 +52,6 @@ type subCIDOfferJson struct {
 	Signature   string                       `json:"signature"`
 }
 
-// subCIDOfferSigning is used to generate and verify signature.
-type subCIDOfferSigning struct {
-	ProviderID nodeid.NodeID `json:"provider_id"`
-	MerkleRoot string        `json:"merkle_root"`
-	Price      uint64        `json:"price"`
-	Expiry     int64         `json:"expiry"`
-	QoS        uint64        `json:"qos"`
-}
-
 // NewSubCIDOffer creates a sub CID Offer.
 func NewSubCIDOffer(providerID *nodeid.NodeID, subCID *cid.ContentID, merkleRoot string, merkleProof *fcrmerkletree.FCRMerkleProof, price uint64, expiry int64, qos uint64, signature string) *SubCIDOffer {
 	return &SubCIDOffer{
@@ -167,9 +158,15 @@ func (c *SubCIDOffer) UnmarshalJSON(p []byte) error {
 	if err != nil {
 		return err
 	}
-	providerID, _ := nodeid.NewNodeIDFromHexString(cJson.ProviderID)
+	providerID, err := nodeid.NewNodeIDFromHexString(cJson.ProviderID)
+	if err != nil {
+		return err
+	}
 	c.providerID = providerID
-	subCID, _ := cid.NewContentIDFromHexString(cJson.SubCID)
+	subCID, err := cid.NewContentIDFromString(cJson.SubCID)
+	if err != nil {
+		return err
+	}
 	c.subCID = subCID
 	c.merkleRoot = cJson.MerkleRoot
 	c.merkleProof = &cJson.MerkleProof
@@ -180,12 +177,8 @@ func (c *SubCIDOffer) UnmarshalJSON(p []byte) error {
 	return nil
 }
 
+// MarshalToSign is used to marshal sub offer into the canonical bytes to sign, which are the ones of the offer it is
+// part of, see offerSigningPayload.
 func (c *SubCIDOffer) MarshalToSign() ([]byte, error) {
-	return json.Marshal(subCIDOfferSigning{
-		ProviderID: *c.providerID,
-		MerkleRoot: c.merkleRoot,
-		Price:      c.price,
-		Expiry:     c.expiry,
-		QoS:        c.qos,
-	})
+	return offerSigningPayload(c.providerID, c.merkleRoot, c.price, c.expiry, c.qos)
 }
@@ -54,23 +54,21 @@ func TestNewSubCIDOfferWithGet(t *testing.T) {
 	assert.Equal(t, offer.GetSignature(), subOffer.GetSignature())
 	p, err := subOffer.GetMerkleProof().MarshalJSON()
 	assert.Empty(t, err)
-	assert.Equal(t, []byte{0x22, 0x41, 0x41, 0x41, 0x41, 0x58,
-		0x31, 0x73, 0x69, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55,
-		0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42,
-		0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51,
-		0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55,
-		0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x5a, 0x7a, 0x30, 0x69,
-		0x4c, 0x43, 0x4a, 0x6a, 0x52, 0x30, 0x31, 0x55, 0x63,
-		0x57, 0x4d, 0x32, 0x62, 0x30, 0x6c, 0x56, 0x52, 0x55,
-		0x68, 0x48, 0x61, 0x6b, 0x39, 0x6f, 0x4f, 0x53, 0x74,
-		0x78, 0x64, 0x6b, 0x4e, 0x75, 0x5a, 0x48, 0x4e, 0x31,
-		0x64, 0x32, 0x73, 0x77, 0x4b, 0x79, 0x74, 0x73, 0x57,
-		0x48, 0x42, 0x32, 0x4e, 0x56, 0x6c, 0x59, 0x4e, 0x6b,
-		0x35, 0x77, 0x51, 0x6d, 0x46, 0x6e, 0x50, 0x53, 0x4a,
-		0x64, 0x41, 0x41, 0x41, 0x41, 0x42, 0x56, 0x73, 0x78,
-		0x4c, 0x44, 0x46, 0x64, 0x22}, p)
+	assert.Equal(t, []byte{0x7b, 0x22, 0x76, 0x65, 0x72, 0x73,
+		0x69, 0x6f, 0x6e, 0x22, 0x3a, 0x31, 0x2c, 0x22, 0x70,
+		0x61, 0x74, 0x68, 0x22, 0x3a, 0x5b, 0x22, 0x61, 0x78,
+		0x52, 0x39, 0x62, 0x65, 0x54, 0x6d, 0x4f, 0x37, 0x34,
+		0x78, 0x37, 0x4e, 0x32, 0x49, 0x38, 0x47, 0x53, 0x34,
+		0x59, 0x39, 0x76, 0x6f, 0x62, 0x65, 0x42, 0x69, 0x71,
+		0x43, 0x59, 0x76, 0x79, 0x6a, 0x62, 0x76, 0x65, 0x5a,
+		0x4f, 0x46, 0x47, 0x67, 0x55, 0x3d, 0x22, 0x2c, 0x22,
+		0x33, 0x59, 0x6a, 0x76, 0x54, 0x6e, 0x2b, 0x75, 0x74,
+		0x46, 0x6e, 0x44, 0x76, 0x4e, 0x4d, 0x48, 0x36, 0x41,
+		0x62, 0x75, 0x6b, 0x53, 0x72, 0x43, 0x57, 0x50, 0x7a,
+		0x35, 0x46, 0x57, 0x63, 0x57, 0x38, 0x4c, 0x55, 0x58,
+		0x52, 0x35, 0x47, 0x37, 0x73, 0x50, 0x73, 0x3d, 0x22,
+		0x5d, 0x2c, 0x22, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x22,
+		0x3a, 0x5b, 0x31, 0x2c, 0x31, 0x5d, 0x7d}, p)
 }
 
 func TestSubOfferHasExpired(t *testing.T) {
@@ -173,37 +171,36 @@ func TestJSONSubOffer(t *testing.T) {
 		0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x30,
 		0x30, 0x30, 0x30, 0x30, 0x30, 0x30, 0x37, 0x22, 0x2c,
 		0x22, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x72,
-		0x6f, 0x6f, 0x74, 0x22, 0x3a, 0x22, 0x61, 0x37, 0x62,
-		0x63, 0x34, 0x61, 0x32, 0x32, 0x32, 0x30, 0x30, 0x35,
-		0x38, 0x38, 0x33, 0x65, 0x38, 0x36, 0x63, 0x61, 0x38,
-		0x35, 0x36, 0x30, 0x63, 0x32, 0x66, 0x65, 0x63, 0x64,
-		0x37, 0x61, 0x30, 0x39, 0x32, 0x36, 0x34, 0x34, 0x66,
-		0x62, 0x66, 0x38, 0x66, 0x31, 0x65, 0x38, 0x37, 0x34,
-		0x65, 0x36, 0x31, 0x31, 0x30, 0x30, 0x62, 0x39, 0x33,
-		0x30, 0x62, 0x39, 0x32, 0x38, 0x65, 0x36, 0x22, 0x2c,
+		0x6f, 0x6f, 0x74, 0x22, 0x3a, 0x22, 0x30, 0x66, 0x38,
+		0x63, 0x39, 0x36, 0x30, 0x63, 0x36, 0x34, 0x34, 0x33,
+		0x30, 0x62, 0x31, 0x61, 0x32, 0x61, 0x38, 0x37, 0x31,
+		0x63, 0x32, 0x30, 0x35, 0x32, 0x61, 0x36, 0x34, 0x37,
+		0x66, 0x66, 0x33, 0x32, 0x31, 0x39, 0x38, 0x31, 0x39,
+		0x31, 0x35, 0x34, 0x61, 0x64, 0x33, 0x33, 0x30, 0x65,
+		0x61, 0x65, 0x37, 0x35, 0x30, 0x30, 0x64, 0x31, 0x64,
+		0x37, 0x61, 0x62, 0x65, 0x65, 0x62, 0x66, 0x22, 0x2c,
 		0x22, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x5f, 0x70,
-		0x72, 0x6f, 0x6f, 0x66, 0x22, 0x3a, 0x22, 0x41, 0x41,
-		0x41, 0x41, 0x58, 0x31, 0x73, 0x69, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42,
-		0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51,
-		0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55,
-		0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42,
-		0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x5a,
-		0x7a, 0x30, 0x69, 0x4c, 0x43, 0x4a, 0x6a, 0x52, 0x30,
-		0x31, 0x55, 0x63, 0x57, 0x4d, 0x32, 0x62, 0x30, 0x6c,
-		0x56, 0x52, 0x55, 0x68, 0x48, 0x61, 0x6b, 0x39, 0x6f,
-		0x4f, 0x53, 0x74, 0x78, 0x64, 0x6b, 0x4e, 0x75, 0x5a,
-		0x48, 0x4e, 0x31, 0x64, 0x32, 0x73, 0x77, 0x4b, 0x79,
-		0x74, 0x73, 0x57, 0x48, 0x42, 0x32, 0x4e, 0x56, 0x6c,
-		0x59, 0x4e, 0x6b, 0x35, 0x77, 0x51, 0x6d, 0x46, 0x6e,
-		0x50, 0x53, 0x4a, 0x64, 0x41, 0x41, 0x41, 0x41, 0x42,
-		0x56, 0x73, 0x78, 0x4c, 0x44, 0x46, 0x64, 0x22, 0x2c,
-		0x22, 0x70, 0x72, 0x69, 0x63, 0x65, 0x22, 0x3a, 0x35,
-		0x2c, 0x22, 0x65, 0x78, 0x70, 0x69, 0x72, 0x79, 0x22,
-		0x3a, 0x31, 0x30, 0x2c, 0x22, 0x71, 0x6f, 0x73, 0x22,
-		0x3a, 0x35, 0x2c, 0x22, 0x73, 0x69, 0x67, 0x6e, 0x61,
-		0x74, 0x75, 0x72, 0x65, 0x22, 0x3a, 0x22, 0x22, 0x7d}, p)
+		0x72, 0x6f, 0x6f, 0x66, 0x22, 0x3a, 0x7b, 0x22, 0x76,
+		0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x3a, 0x31,
+		0x2c, 0x22, 0x70, 0x61, 0x74, 0x68, 0x22, 0x3a, 0x5b,
+		0x22, 0x61, 0x78, 0x52, 0x39, 0x62, 0x65, 0x54, 0x6d,
+		0x4f, 0x37, 0x34, 0x78, 0x37, 0x4e, 0x32, 0x49, 0x38,
+		0x47, 0x53, 0x34, 0x59, 0x39, 0x76, 0x6f, 0x62, 0x65,
+		0x42, 0x69, 0x71, 0x43, 0x59, 0x76, 0x79, 0x6a, 0x62,
+		0x76, 0x65, 0x5a, 0x4f, 0x46, 0x47, 0x67, 0x55, 0x3d,
+		0x22, 0x2c, 0x22, 0x33, 0x59, 0x6a, 0x76, 0x54, 0x6e,
+		0x2b, 0x75, 0x74, 0x46, 0x6e, 0x44, 0x76, 0x4e, 0x4d,
+		0x48, 0x36, 0x41, 0x62, 0x75, 0x6b, 0x53, 0x72, 0x43,
+		0x57, 0x50, 0x7a, 0x35, 0x46, 0x57, 0x63, 0x57, 0x38,
+		0x4c, 0x55, 0x58, 0x52, 0x35, 0x47, 0x37, 0x73, 0x50,
+		0x73, 0x3d, 0x22, 0x5d, 0x2c, 0x22, 0x69, 0x6e, 0x64,
+		0x65, 0x78, 0x22, 0x3a, 0x5b, 0x31, 0x2c, 0x31, 0x5d,
+		0x7d, 0x2c, 0x22, 0x70, 0x72, 0x69, 0x63, 0x65, 0x22,
+		0x3a, 0x35, 0x2c, 0x22, 0x65, 0x78, 0x70, 0x69, 0x72,
+		0x79, 0x22, 0x3a, 0x31, 0x30, 0x2c, 0x22, 0x71, 0x6f,
+		0x73, 0x22, 0x3a, 0x35, 0x2c, 0x22, 0x73, 0x69, 0x67,
+		0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x3a, 0x22,
+		0x22, 0x7d}, p)
 	subOffer2 := SubCIDOffer{}
 	err = subOffer2.UnmarshalJSON(p)
 	assert.Empty(t, err)
@@ -0,0 +1,184 @@
+package fcrmerkletree
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// FCRMerkleMultiProof is the proof of several cids in a merkle tree.
+// It holds the hashes of the nodes which cannot be computed from the proven cids, level by level from the leaves and
+// by increasing index within a level, so every node shared by the paths of several cids is hashed and sent once.
+type FCRMerkleMultiProof struct {
+	version   int32
+	leafCount int64
+	indices   []int64
+	hashes    [][]byte
+}
+
+// multiProofJson is used to parse to and from json.
+type multiProofJson struct {
+	Version   int32    `json:"version"`
+	LeafCount int64    `json:"leaf_count"`
+	Indices   []int64  `json:"indices"`
+	Hashes    [][]byte `json:"hashes"`
+}
+
+// GenerateMerkleMultiProof gets the merkle multiproof for given cids, which must be distinct cids of the tree.
+func (mt *FCRMerkleTree) GenerateMerkleMultiProof(contents []Content) (*FCRMerkleMultiProof, error) {
+	if len(contents) == 0 {
+		return nil, errors.New("FCRMerkleMultiProof: no content to prove")
+	}
+	leaves := mt.levels[0]
+	indices := make([]int64, len(contents))
+	known := make(map[int][]byte, len(contents))
+	for i, content := range contents {
+		index, err := mt.leafIndex(content)
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 {
+			return nil, errors.New("FCRMerkleMultiProof: content not in tree")
+		}
+		if known[index] != nil {
+			return nil, errors.New("FCRMerkleMultiProof: duplicate content")
+		}
+		indices[i] = int64(index)
+		known[index] = leaves[index]
+	}
+
+	hashes := make([][]byte, 0)
+	_, err := computeMultiProofRoot(len(leaves), known, func(level int, index int) ([]byte, error) {
+		hashes = append(hashes, mt.levels[level][index])
+		return mt.levels[level][index], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &FCRMerkleMultiProof{version: ProofVersion, leafCount: int64(len(leaves)), indices: indices, hashes: hashes}, nil
+}
+
+// GetVersion returns the version of the tree scheme of the multiproof.
+func (mp *FCRMerkleMultiProof) GetVersion() int32 {
+	return mp.version
+}
+
+// VerifyContents is used to verify given contents and a given root matches the multiproof.
+// The contents must be given in the order the multiproof has been generated for.
+func (mp *FCRMerkleMultiProof) VerifyContents(contents []Content, root string) bool {
+	if mp == nil || mp.version != ProofVersion || len(contents) == 0 || len(contents) != len(mp.indices) {
+		return false
+	}
+	known := make(map[int][]byte, len(contents))
+	for i, content := range contents {
+		index := mp.indices[i]
+		if index < 0 || index >= mp.leafCount {
+			return false
+		}
+		if _, exists := known[int(index)]; exists {
+			return false
+		}
+		hash, err := content.CalculateHash()
+		if err != nil {
+			return false
+		}
+		known[int(index)] = hashLeaf(hash)
+	}
+	next := 0
+	currentHash, err := computeMultiProofRoot(int(mp.leafCount), known, func(int, int) ([]byte, error) {
+		if next >= len(mp.hashes) {
+			return nil, errors.New("FCRMerkleMultiProof: missing hashes")
+		}
+		next++
+		return mp.hashes[next-1], nil
+	})
+	if err != nil || next != len(mp.hashes) {
+		return false
+	}
+	return hex.EncodeToString(currentHash) == root
+}
+
+// MarshalJSON is used to marshal FCRMerkleMultiProof into bytes.
+func (mp FCRMerkleMultiProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(multiProofJson{
+		Version:   mp.version,
+		LeafCount: mp.leafCount,
+		Indices:   mp.indices,
+		Hashes:    mp.hashes,
+	})
+}
+
+// UnmarshalJSON is used to unmarshal bytes into FCRMerkleMultiProof.
+func (mp *FCRMerkleMultiProof) UnmarshalJSON(p []byte) error {
+	mpJson := multiProofJson{}
+	err := json.Unmarshal(p, &mpJson)
+	if err != nil {
+		return err
+	}
+	if mpJson.LeafCount < 1 || len(mpJson.Indices) == 0 || int64(len(mpJson.Indices)) > mpJson.LeafCount {
+		return fmt.Errorf("FCRMerkleMultiProof: Incorrect size")
+	}
+	mp.version = mpJson.Version
+	mp.leafCount = mpJson.LeafCount
+	mp.indices = mpJson.Indices
+	mp.hashes = mpJson.Hashes
+	return nil
+}
+
+// computeMultiProofRoot computes the root of a tree of a given number of leaves from some of its leaves, by index.
+// The hashes of the other nodes needed are returned by a given function, which is called level by level from the
+// leaves and by increasing index within a level.
+func computeMultiProofRoot(leafCount int, known map[int][]byte, sibling func(level int, index int) ([]byte, error)) ([]byte, error) {
+	size := leafCount
+	for level := 0; size > 1; level++ {
+		indices := make([]int, 0, len(known))
+		for index := range known {
+			indices = append(indices, index)
+		}
+		sort.Ints(indices)
+		parents := make(map[int][]byte, len(known))
+		for _, index := range indices {
+			if _, done := parents[index/2]; done {
+				continue
+			}
+			left, right := index&^1, index|1
+			if right == size {
+				// The last node of an odd level is moved up as is.
+				parents[index/2] = known[index]
+				continue
+			}
+			pair := [2][]byte{known[left], known[right]}
+			for i, pos := range []int{left, right} {
+				if pair[i] != nil {
+					continue
+				}
+				hash, err := sibling(level, pos)
+				if err != nil {
+					return nil, err
+				}
+				pair[i] = hash
+			}
+			parents[index/2] = hashNode(pair[0], pair[1])
+		}
+		known = parents
+		size = (size + 1) / 2
+	}
+	return known[0], nil
+}
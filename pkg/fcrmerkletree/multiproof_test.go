@@ -0,0 +1,136 @@
+package fcrmerkletree
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestContents(t *testing.T, count int) []Content {
+	elements := make([]Content, 0)
+	for i := 0; i < count; i++ {
+		cid, err := cid.NewContentID(big.NewInt(int64(i + 1)))
+		assert.Empty(t, err)
+		elements = append(elements, cid)
+	}
+	return elements
+}
+
+func TestCreateMerkleMultiProof(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		elements := createTestContents(t, count)
+		tree, err := CreateMerkleTree(elements)
+		assert.Empty(t, err)
+		root := tree.GetMerkleRoot()
+		// Every subset of the leaves
+		for subset := 1; subset < 1<<count; subset++ {
+			contents := make([]Content, 0)
+			for i := count - 1; i >= 0; i-- {
+				if subset&(1<<i) != 0 {
+					contents = append(contents, elements[i])
+				}
+			}
+			proof, err := tree.GenerateMerkleMultiProof(contents)
+			assert.Empty(t, err)
+			assert.True(t, proof.VerifyContents(contents, root), "%d leaves, subset %b", count, subset)
+		}
+	}
+}
+
+func TestCreateMerkleMultiProofSize(t *testing.T) {
+	elements := createTestContents(t, 100)
+	tree, err := CreateMerkleTree(elements)
+	assert.Empty(t, err)
+	contents := elements[10:60]
+	proof, err := tree.GenerateMerkleMultiProof(contents)
+	assert.Empty(t, err)
+	assert.True(t, proof.VerifyContents(contents, tree.GetMerkleRoot()))
+
+	singleHashes := 0
+	for _, content := range contents {
+		single, err := tree.GenerateMerkleProof(content)
+		assert.Empty(t, err)
+		singleHashes += len(single.path)
+	}
+	assert.Equal(t, 7*len(contents), singleHashes)
+	assert.Less(t, len(proof.hashes), len(contents))
+}
+
+func TestVerifyMerkleMultiProofWithError(t *testing.T) {
+	elements := createTestContents(t, 10)
+	tree, err := CreateMerkleTree(elements)
+	assert.Empty(t, err)
+	root := tree.GetMerkleRoot()
+	contents := []Content{elements[2], elements[7]}
+	proof, err := tree.GenerateMerkleMultiProof(contents)
+	assert.Empty(t, err)
+	assert.True(t, proof.VerifyContents(contents, root))
+
+	// Wrong order, content or count
+	assert.False(t, proof.VerifyContents([]Content{elements[7], elements[2]}, root))
+	assert.False(t, proof.VerifyContents([]Content{elements[2], elements[8]}, root))
+	assert.False(t, proof.VerifyContents([]Content{elements[2]}, root))
+	// Wrong root
+	otherTree, err := CreateMerkleTree(elements[:9])
+	assert.Empty(t, err)
+	assert.False(t, proof.VerifyContents(contents, otherTree.GetMerkleRoot()))
+	// Missing or extra hashes
+	hashes := proof.hashes
+	proof.hashes = hashes[:len(hashes)-1]
+	assert.False(t, proof.VerifyContents(contents, root))
+	proof.hashes = append(append([][]byte{}, hashes...), hashes[0])
+	assert.False(t, proof.VerifyContents(contents, root))
+	proof.hashes = hashes
+	// Duplicate index
+	proof.indices = []int64{2, 2}
+	assert.False(t, proof.VerifyContents([]Content{elements[2], elements[2]}, root))
+
+	_, err = tree.GenerateMerkleMultiProof([]Content{elements[2], elements[2]})
+	assert.NotEmpty(t, err)
+	cidX, err := cid.NewContentID(big.NewInt(101))
+	assert.Empty(t, err)
+	_, err = tree.GenerateMerkleMultiProof([]Content{cidX})
+	assert.NotEmpty(t, err)
+	_, err = tree.GenerateMerkleMultiProof(nil)
+	assert.NotEmpty(t, err)
+}
+
+func TestMultiProofJSON(t *testing.T) {
+	elements := createTestContents(t, 5)
+	tree, err := CreateMerkleTree(elements)
+	assert.Empty(t, err)
+	contents := []Content{elements[0], elements[4]}
+	proof, err := tree.GenerateMerkleMultiProof(contents)
+	assert.Empty(t, err)
+
+	p, err := json.Marshal(proof)
+	assert.Empty(t, err)
+	proof2 := FCRMerkleMultiProof{}
+	err = json.Unmarshal(p, &proof2)
+	assert.Empty(t, err)
+	assert.Equal(t, *proof, proof2)
+	assert.True(t, proof2.VerifyContents(contents, tree.GetMerkleRoot()))
+
+	err = json.Unmarshal([]byte(`{"leaf_count":1,"indices":[0,1],"hashes":[]}`), &proof2)
+	assert.NotEmpty(t, err)
+	err = json.Unmarshal([]byte(`[]`), &proof2)
+	assert.NotEmpty(t, err)
+}
@@ -19,38 +19,78 @@ package fcrmerkletree
  */
 
 import (
-	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+)
 
-	"github.com/cbergoon/merkletree"
+// Merkle proof versions.
+const (
+	// ProofVersionLegacy identifies the proofs of the trees without leaf and node prefixes, which duplicate the last
+	// leaf on odd counts. They are not verified anymore.
+	ProofVersionLegacy int32 = 0
+	// ProofVersion identifies the proofs of the current tree scheme.
+	ProofVersion int32 = 1
 )
 
 // FCRMerkleProof is the proof of a single cid in a merkle tree.
 type FCRMerkleProof struct {
-	path  [][]byte
-	index []int64
+	version int32
+	path    [][]byte
+	index   []int64
+}
+
+// merkleProofJson is used to parse to and from json, legacy proofs use the bytes format instead.
+type merkleProofJson struct {
+	Version int32    `json:"version"`
+	Path    [][]byte `json:"path"`
+	Index   []int64  `json:"index"`
+}
+
+// GetVersion returns the version of the tree scheme of the proof.
+func (mp *FCRMerkleProof) GetVersion() int32 {
+	return mp.version
 }
 
 // VerifyContent is used to verify a given content and a given root matches the proof.
-func (mp *FCRMerkleProof) VerifyContent(content merkletree.Content, root string) bool {
-	currentHash, _ := content.CalculateHash()
+// Only the proofs of the current tree scheme are verified.
+func (mp *FCRMerkleProof) VerifyContent(content Content, root string) bool {
+	if mp == nil || mp.version != ProofVersion || len(mp.path) != len(mp.index) {
+		return false
+	}
+	contentHash, err := content.CalculateHash()
+	if err != nil {
+		return false
+	}
+	currentHash := hashLeaf(contentHash)
 	for i, path := range mp.path {
-		hashFunc := sha256.New()
-		if mp.index[i] == 1 {
-			hashFunc.Write(append(currentHash, path...))
-		} else {
-			hashFunc.Write(append(path, currentHash...))
+		switch mp.index[i] {
+		case 1:
+			currentHash = hashNode(currentHash, path)
+		case 0:
+			currentHash = hashNode(path, currentHash)
+		default:
+			return false
 		}
-		currentHash = hashFunc.Sum(nil)
 	}
 	return hex.EncodeToString(currentHash) == root
 }
 
 // MarshalJSON is used to marshal FCRMerkleProof into bytes.
 func (mp FCRMerkleProof) MarshalJSON() ([]byte, error) {
+	if mp.version != ProofVersionLegacy {
+		return json.Marshal(merkleProofJson{
+			Version: mp.version,
+			Path:    mp.path,
+			Index:   mp.index,
+		})
+	}
+	return mp.marshalLegacyJSON()
+}
+
+// marshalLegacyJSON is used to marshal a legacy FCRMerkleProof into bytes.
+func (mp FCRMerkleProof) marshalLegacyJSON() ([]byte, error) {
 	// Encode path
 	pathBytes, err := json.Marshal(mp.path)
 	if err != nil {
@@ -77,6 +117,24 @@ func (mp FCRMerkleProof) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON is used to unmarshal bytes into FCRMerkleProof.
 func (mp *FCRMerkleProof) UnmarshalJSON(p []byte) error {
+	if len(p) > 0 && p[0] == '{' {
+		mpJson := merkleProofJson{}
+		if err := json.Unmarshal(p, &mpJson); err != nil {
+			return err
+		}
+		if mpJson.Version == ProofVersionLegacy || len(mpJson.Path) != len(mpJson.Index) {
+			return fmt.Errorf("FCRMerkleProof: Incorrect version or size")
+		}
+		mp.version = mpJson.Version
+		mp.path = mpJson.Path
+		mp.index = mpJson.Index
+		return nil
+	}
+	return mp.unmarshalLegacyJSON(p)
+}
+
+// unmarshalLegacyJSON is used to unmarshal bytes into a legacy FCRMerkleProof.
+func (mp *FCRMerkleProof) unmarshalLegacyJSON(p []byte) error {
 	var current []byte
 	err := json.Unmarshal(p, &current)
 	if err != nil {
@@ -114,6 +172,7 @@ func (mp *FCRMerkleProof) UnmarshalJSON(p []byte) error {
 	if err != nil {
 		return err
 	}
+	mp.version = ProofVersionLegacy
 	mp.path = path
 	mp.index = index
 	return nil
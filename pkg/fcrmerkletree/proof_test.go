@@ -21,7 +21,6 @@ import (
 	"testing"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
-	"github.com/cbergoon/merkletree"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,7 +35,7 @@ func TestCreateMerkleProof(t *testing.T) {
 	assert.Empty(t, err)
 	cid5, err := cid.NewContentIDFromHexString("05")
 	assert.Empty(t, err)
-	tree, err := CreateMerkleTree([]merkletree.Content{cid1, cid2, cid3, cid4, cid5})
+	tree, err := CreateMerkleTree([]Content{cid1, cid2, cid3, cid4, cid5})
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
 
@@ -77,7 +76,7 @@ func TestCreateMerkleProofOneElement(t *testing.T) {
 	cid1, err := cid.NewContentIDFromHexString("01")
 	assert.Empty(t, err)
 
-	tree, err := CreateMerkleTree([]merkletree.Content{cid1})
+	tree, err := CreateMerkleTree([]Content{cid1})
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
 
@@ -88,7 +87,7 @@ func TestCreateMerkleProofOneElement(t *testing.T) {
 }
 
 func TestCreateMerkleProofManyElement(t *testing.T) {
-	elements := make([]merkletree.Content, 0)
+	elements := make([]Content, 0)
 	for i := 0; i < 100; i++ {
 		cid, err := cid.NewContentID(big.NewInt(int64(i)))
 		assert.Empty(t, err)
@@ -125,7 +124,7 @@ func TestJSON(t *testing.T) {
 	assert.Empty(t, err)
 	cid5, err := cid.NewContentIDFromHexString("05")
 	assert.Empty(t, err)
-	tree, err := CreateMerkleTree([]merkletree.Content{cid1, cid2, cid3, cid4, cid5})
+	tree, err := CreateMerkleTree([]Content{cid1, cid2, cid3, cid4, cid5})
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
 
@@ -135,31 +134,27 @@ func TestJSON(t *testing.T) {
 
 	p, err := proof.MarshalJSON()
 	assert.Empty(t, err)
-	assert.Equal(t, []byte{0x22, 0x41, 0x41, 0x41, 0x41, 0x6a,
-		0x6c, 0x73, 0x69, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55,
-		0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42,
-		0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51,
-		0x55, 0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55,
-		0x46, 0x42, 0x51, 0x55, 0x46, 0x42, 0x51, 0x55, 0x46,
-		0x42, 0x51, 0x55, 0x46, 0x42, 0x53, 0x54, 0x30, 0x69,
-		0x4c, 0x43, 0x49, 0x79, 0x5a, 0x33, 0x70, 0x58, 0x63,
-		0x32, 0x63, 0x34, 0x4c, 0x30, 0x52, 0x54, 0x4e, 0x54,
-		0x68, 0x43, 0x51, 0x54, 0x5a, 0x73, 0x4d, 0x55, 0x4a,
-		0x49, 0x4b, 0x7a, 0x42, 0x43, 0x65, 0x53, 0x74, 0x6f,
-		0x59, 0x54, 0x68, 0x79, 0x4d, 0x30, 0x52, 0x43, 0x63,
-		0x44, 0x45, 0x35, 0x52, 0x31, 0x4a, 0x43, 0x65, 0x6e,
-		0x52, 0x4d, 0x4d, 0x48, 0x46, 0x6a, 0x50, 0x53, 0x49,
-		0x73, 0x49, 0x6d, 0x46, 0x31, 0x52, 0x30, 0x5a, 0x4d,
-		0x57, 0x6d, 0x4a, 0x4d, 0x64, 0x33, 0x64, 0x6d, 0x55,
-		0x6c, 0x68, 0x42, 0x4d, 0x30, 0x31, 0x6d, 0x63, 0x56,
-		0x52, 0x75, 0x5a, 0x56, 0x46, 0x7a, 0x51, 0x6b, 0x56,
-		0x68, 0x64, 0x57, 0x4a, 0x51, 0x5a, 0x54, 0x46, 0x45,
-		0x4d, 0x46, 0x52, 0x72, 0x59, 0x56, 0x52, 0x7a, 0x53,
-		0x55, 0x46, 0x6a, 0x59, 0x32, 0x63, 0x39, 0x49, 0x6c,
-		0x30, 0x41, 0x41, 0x41, 0x41, 0x48, 0x57, 0x7a, 0x45,
-		0x73, 0x4d, 0x53, 0x77, 0x78, 0x58, 0x51, 0x3d, 0x3d,
-		0x22}, p)
+	assert.Equal(t, []byte{0x7b, 0x22, 0x76, 0x65, 0x72, 0x73,
+		0x69, 0x6f, 0x6e, 0x22, 0x3a, 0x31, 0x2c, 0x22, 0x70,
+		0x61, 0x74, 0x68, 0x22, 0x3a, 0x5b, 0x22, 0x57, 0x4d,
+		0x77, 0x76, 0x52, 0x4e, 0x4f, 0x69, 0x65, 0x47, 0x61,
+		0x48, 0x52, 0x77, 0x48, 0x37, 0x72, 0x56, 0x63, 0x39,
+		0x71, 0x61, 0x30, 0x63, 0x2f, 0x59, 0x6a, 0x36, 0x4d,
+		0x55, 0x56, 0x54, 0x48, 0x49, 0x49, 0x76, 0x49, 0x4b,
+		0x57, 0x4c, 0x37, 0x71, 0x45, 0x3d, 0x22, 0x2c, 0x22,
+		0x76, 0x6f, 0x43, 0x4e, 0x36, 0x4a, 0x52, 0x6a, 0x66,
+		0x6b, 0x35, 0x4f, 0x54, 0x32, 0x73, 0x57, 0x6b, 0x56,
+		0x4b, 0x32, 0x67, 0x71, 0x34, 0x4b, 0x65, 0x6b, 0x73,
+		0x46, 0x44, 0x65, 0x36, 0x4c, 0x34, 0x32, 0x56, 0x56,
+		0x74, 0x32, 0x74, 0x6a, 0x69, 0x50, 0x6b, 0x3d, 0x22,
+		0x2c, 0x22, 0x43, 0x47, 0x2b, 0x32, 0x43, 0x39, 0x6c,
+		0x6f, 0x2f, 0x6d, 0x6a, 0x73, 0x37, 0x47, 0x71, 0x4e,
+		0x67, 0x6d, 0x36, 0x6c, 0x71, 0x6e, 0x30, 0x39, 0x67,
+		0x43, 0x44, 0x6d, 0x52, 0x4e, 0x66, 0x46, 0x30, 0x4f,
+		0x42, 0x39, 0x37, 0x55, 0x56, 0x73, 0x6f, 0x2b, 0x67,
+		0x3d, 0x22, 0x5d, 0x2c, 0x22, 0x69, 0x6e, 0x64, 0x65,
+		0x78, 0x22, 0x3a, 0x5b, 0x31, 0x2c, 0x31, 0x2c, 0x31,
+		0x5d, 0x7d}, p)
 	proof2 := FCRMerkleProof{}
 	err = proof2.UnmarshalJSON(p)
 	assert.Empty(t, err)
@@ -214,3 +209,27 @@ func TestJSONError(t *testing.T) {
 	err = proof.UnmarshalJSON(p)
 	assert.NotEmpty(t, err)
 }
+
+func TestLegacyProof(t *testing.T) {
+	elements := createTestContents(t, 5)
+	tree, err := CreateMerkleTree(elements)
+	assert.Empty(t, err)
+	proof, err := tree.GenerateMerkleProof(elements[0])
+	assert.Empty(t, err)
+	assert.Equal(t, ProofVersion, proof.GetVersion())
+
+	legacy := FCRMerkleProof{path: proof.path, index: proof.index}
+	p, err := legacy.MarshalJSON()
+	assert.Empty(t, err)
+	assert.Equal(t, byte('"'), p[0])
+	proof2 := FCRMerkleProof{}
+	err = proof2.UnmarshalJSON(p)
+	assert.Empty(t, err)
+	assert.Equal(t, ProofVersionLegacy, proof2.GetVersion())
+	assert.Equal(t, proof.path, proof2.path)
+	assert.False(t, proof2.VerifyContent(elements[0], tree.GetMerkleRoot()))
+
+	// The object format has no legacy version.
+	err = proof2.UnmarshalJSON([]byte(`{"version":0,"path":[],"index":[]}`))
+	assert.NotEmpty(t, err)
+}
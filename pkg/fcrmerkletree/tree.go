@@ -16,35 +16,121 @@ package fcrmerkletree
  */
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+)
 
-	"github.com/cbergoon/merkletree"
+// Merkle tree scheme, version 1:
+//
+// A leaf is the sha256 hash of 0x00 followed by the hash of its content, a node is the sha256 hash of 0x01
+// followed by its left and right children, so a leaf can never be taken for a node. When a level has an odd number of
+// nodes, the last node has no sibling and is moved up to the next level as is: no leaf or node is duplicated, so trees
+// of different contents have different roots. The root of a tree of a single content is the leaf of the content.
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
 )
 
+// Content is the data stored in a merkle tree.
+type Content interface {
+	// CalculateHash returns the hash identifying the content.
+	CalculateHash() ([]byte, error)
+}
+
 // FCRMerkleTree is used to store a list of CIDs.
 type FCRMerkleTree struct {
-	tree *merkletree.MerkleTree
+	// contentHashes are the hashes of the contents, by leaf index.
+	contentHashes [][]byte
+	// levels are the hashes of the tree, from the leaves to the root.
+	levels [][][]byte
 }
 
 // CreateMerkleTree creates a merkle tree from a list of cids.
-func CreateMerkleTree(contents []merkletree.Content) (*FCRMerkleTree, error) {
-	tree, err := merkletree.NewTree(contents)
-	if err != nil {
-		return nil, err
+func CreateMerkleTree(contents []Content) (*FCRMerkleTree, error) {
+	if len(contents) == 0 {
+		return nil, errors.New("FCRMerkleTree: cannot construct tree with no content")
 	}
-	return &FCRMerkleTree{tree: tree}, nil
+	mt := &FCRMerkleTree{contentHashes: make([][]byte, len(contents))}
+	leaves := make([][]byte, len(contents))
+	for i, content := range contents {
+		hash, err := content.CalculateHash()
+		if err != nil {
+			return nil, err
+		}
+		mt.contentHashes[i] = hash
+		leaves[i] = hashLeaf(hash)
+	}
+	mt.levels = [][][]byte{leaves}
+	for nodes := leaves; len(nodes) > 1; {
+		parents := make([][]byte, (len(nodes)+1)/2)
+		for i := range parents {
+			if 2*i+1 == len(nodes) {
+				parents[i] = nodes[2*i]
+			} else {
+				parents[i] = hashNode(nodes[2*i], nodes[2*i+1])
+			}
+		}
+		mt.levels = append(mt.levels, parents)
+		nodes = parents
+	}
+	return mt, nil
 }
 
 // GetMerkleRoot returns the merkle root of the tree.
 func (mt *FCRMerkleTree) GetMerkleRoot() string {
-	return hex.EncodeToString(mt.tree.MerkleRoot())
+	return hex.EncodeToString(mt.levels[len(mt.levels)-1][0])
 }
 
-// GenerateMerkleProof gets the merkle proof for a given cid.
-func (mt *FCRMerkleTree) GenerateMerkleProof(content merkletree.Content) (*FCRMerkleProof, error) {
-	path, index, err := mt.tree.GetMerklePath(content)
+// GenerateMerkleProof gets the merkle proof for a given cid, the proof is empty if the cid is not in the tree.
+func (mt *FCRMerkleTree) GenerateMerkleProof(content Content) (*FCRMerkleProof, error) {
+	index, err := mt.leafIndex(content)
 	if err != nil {
 		return nil, err
 	}
-	return &FCRMerkleProof{path: path, index: index}, nil
+	if index < 0 {
+		return &FCRMerkleProof{}, nil
+	}
+	proof := &FCRMerkleProof{version: ProofVersion, path: make([][]byte, 0), index: make([]int64, 0)}
+	for _, nodes := range mt.levels[:len(mt.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(nodes) {
+			proof.path = append(proof.path, nodes[sibling])
+			proof.index = append(proof.index, int64(1-index%2))
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// leafIndex returns the index of the leaf of a given content, -1 if the content is not in the tree.
+func (mt *FCRMerkleTree) leafIndex(content Content) (int, error) {
+	hash, err := content.CalculateHash()
+	if err != nil {
+		return -1, err
+	}
+	for i, contentHash := range mt.contentHashes {
+		if bytes.Equal(contentHash, hash) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// hashLeaf returns the leaf of a given content hash.
+func hashLeaf(contentHash []byte) []byte {
+	hashFunc := sha256.New()
+	hashFunc.Write([]byte{leafPrefix})
+	hashFunc.Write(contentHash)
+	return hashFunc.Sum(nil)
+}
+
+// hashNode returns the parent node of two given nodes.
+func hashNode(left []byte, right []byte) []byte {
+	hashFunc := sha256.New()
+	hashFunc.Write([]byte{nodePrefix})
+	hashFunc.Write(left)
+	hashFunc.Write(right)
+	return hashFunc.Sum(nil)
 }
@@ -20,7 +20,6 @@ import (
 	"testing"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
-	"github.com/cbergoon/merkletree"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,12 +39,12 @@ func TestCreateTree(t *testing.T) {
 	cid5, err := cid.NewContentIDFromHexString("05")
 	cida5 := cid.ContentIDAdapter{Id: cid5.ToString()}
 	assert.Empty(t, err)
-	tree, err := CreateMerkleTree([]merkletree.Content{cida1, cida2, cida3, cida4, cida5})
+	tree, err := CreateMerkleTree([]Content{cida1, cida2, cida3, cida4, cida5})
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
-	_, err = CreateMerkleTree([]merkletree.Content{})
+	_, err = CreateMerkleTree([]Content{})
 	assert.NotEmpty(t, err)
-	assert.Equal(t, "93fe3ef34b47ac151048bb83ddce0656f794ad70adde0f563e1e6fb129d5a15f", tree.GetMerkleRoot())
+	assert.Equal(t, "efde202155f0aa1cd5fc1cbc0a86afc79d5e157106fe6376a11191bd21fc43e2", tree.GetMerkleRoot())
 }
 
 func TestCreateTreeOneElement(t *testing.T) {
@@ -54,15 +53,15 @@ func TestCreateTreeOneElement(t *testing.T) {
 	cida1 := cid.ContentIDAdapter{Id: cid1.ToString()}
 
 	assert.Empty(t, err)
-	tree, err := CreateMerkleTree([]merkletree.Content{cida1})
+	tree, err := CreateMerkleTree([]Content{cida1})
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
 
-	assert.Equal(t, "c8bc8219fe198ce0a3b7fa9d14c7f88c16fc9bae96622761643eaafbc7eb7303", tree.GetMerkleRoot())
+	assert.Equal(t, "37ac511088750a2443963ad2378c1e27074d1b2ccf134d1b86a25e6450f4639a", tree.GetMerkleRoot())
 }
 
 func TestCreateTreeManyElements(t *testing.T) {
-	elements := make([]merkletree.Content, 0)
+	elements := make([]Content, 0)
 	for i := 0; i < 100; i++ {
 		cid1, err := cid.NewContentID(big.NewInt(int64(i)))
 		cida := cid.ContentIDAdapter{Id: cid1.ToString()}
@@ -72,5 +71,35 @@ func TestCreateTreeManyElements(t *testing.T) {
 	tree, err := CreateMerkleTree(elements)
 	assert.Empty(t, err)
 	assert.NotEmpty(t, tree)
-	assert.Equal(t, "f217029c872d9d64f6f5a860fcd027dd8348229f3fb8eb0c7a9ad534ecfa3eae", tree.GetMerkleRoot())
+	assert.Equal(t, "a2f7a1551ce79975b74047c8518c043ead4ad0762e582dd38b64d6cbef7cfdb8", tree.GetMerkleRoot())
+}
+
+// hashContent is a content whose hash is given as is.
+type hashContent []byte
+
+func (h hashContent) CalculateHash() ([]byte, error) {
+	return h, nil
+}
+
+func TestCreateTreeOddLeaf(t *testing.T) {
+	elements := createTestContents(t, 3)
+	tree, err := CreateMerkleTree(elements)
+	assert.Empty(t, err)
+	// Duplicating the last leaf must not give the same root.
+	duplicated, err := CreateMerkleTree(append(elements, elements[2]))
+	assert.Empty(t, err)
+	assert.NotEqual(t, tree.GetMerkleRoot(), duplicated.GetMerkleRoot())
+}
+
+func TestCreateTreeSecondPreimage(t *testing.T) {
+	tree, err := CreateMerkleTree(createTestContents(t, 4))
+	assert.Empty(t, err)
+	// The interior nodes presented as leaves must not give the same root.
+	nodes := []Content{hashContent(tree.levels[1][0]), hashContent(tree.levels[1][1])}
+	forged, err := CreateMerkleTree(nodes)
+	assert.Empty(t, err)
+	assert.NotEqual(t, tree.GetMerkleRoot(), forged.GetMerkleRoot())
+
+	proof := &FCRMerkleProof{version: ProofVersion, path: [][]byte{tree.levels[1][1]}, index: []int64{1}}
+	assert.False(t, proof.VerifyContent(nodes[0], tree.GetMerkleRoot()))
 }
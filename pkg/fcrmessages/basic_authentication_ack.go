@@ -0,0 +1,42 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+import (
+	"errors"
+)
+
+// AuthenticationAck message is sent in response to AuthenticationProof once both entities are authenticated.
+// A failed authentication is answered with InvalidMessageResponse instead.
+type AuthenticationAck struct {
+}
+
+// MessageType returns the message type of AuthenticationAck
+func (AuthenticationAck) MessageType() int32 {
+	return AuthenticationAckType
+}
+
+// EncodeAuthenticationAck is used to get the FCRMessage of AuthenticationAck
+func EncodeAuthenticationAck() (*FCRMessage, error) {
+	return Encode(AuthenticationAck{})
+}
+
+// DecodeAuthenticationAck is used to check a FCRMessage is an AuthenticationAck
+func DecodeAuthenticationAck(fcrMsg *FCRMessage) error {
+	if fcrMsg.GetMessageType() != AuthenticationAckType {
+		return errors.New("message type mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuthenticationAck success test
+func TestAuthenticationAck(t *testing.T) {
+	msg, err := EncodeAuthenticationAck()
+	assert.Empty(t, err)
+	assert.Equal(t, int32(906), msg.GetMessageType())
+	assert.Empty(t, DecodeAuthenticationAck(msg))
+	assert.NotEmpty(t, DecodeAuthenticationAck(CreateFCRMessage(AuthenticationProofType, []byte(`{}`))))
+}
@@ -0,0 +1,63 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AuthenticationProof message is sent in response to AuthenticationResponse.
+// It carries the signature of the challenge of the response by the entity opening the connection.
+type AuthenticationProof struct {
+	Signature string `json:"signature"`
+}
+
+// MessageType returns the message type of AuthenticationProof
+func (AuthenticationProof) MessageType() int32 {
+	return AuthenticationProofType
+}
+
+// Validate checks the fields of AuthenticationProof
+func (m AuthenticationProof) Validate() error {
+	return validateRequired("signature", m.Signature)
+}
+
+// EncodeAuthenticationProof is used to get the FCRMessage of AuthenticationProof
+func EncodeAuthenticationProof(signature string) (*FCRMessage, error) {
+	return Encode(AuthenticationProof{
+		Signature: signature,
+	})
+}
+
+// DecodeAuthenticationProof is used to get the fields from FCRMessage of AuthenticationProof
+func DecodeAuthenticationProof(fcrMsg *FCRMessage) (
+	string, // signature
+	error, // error
+) {
+	if fcrMsg.GetMessageType() != AuthenticationProofType {
+		return "", errors.New("message type mismatch")
+	}
+	msg := AuthenticationProof{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return "", fmt.Errorf("invalid message: %s", err)
+	}
+	if err = msg.Validate(); err != nil {
+		return "", err
+	}
+	return msg.Signature, nil
+}
@@ -0,0 +1,26 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuthenticationProof success test
+func TestAuthenticationProof(t *testing.T) {
+	msg, err := EncodeAuthenticationProof("sig")
+	assert.Empty(t, err)
+	assert.Equal(t, int32(905), msg.GetMessageType())
+	assert.Equal(t, []byte(`{"signature":"sig"}`), msg.GetMessageBody())
+	signature, err := DecodeAuthenticationProof(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, "sig", signature)
+}
+
+// TestDecodeAuthenticationProof failure test
+func TestDecodeAuthenticationProofErrors(t *testing.T) {
+	_, err := DecodeAuthenticationProof(CreateFCRMessage(AuthenticationAckType, []byte(`{}`)))
+	assert.Equal(t, "message type mismatch", err.Error())
+	_, err = DecodeAuthenticationProof(CreateFCRMessage(AuthenticationProofType, []byte(`{}`)))
+	assert.Equal(t, &ErrMalformedField{Field: "signature", Reason: "required"}, err)
+}
@@ -0,0 +1,72 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// AuthenticationRequest message is sent by the entity opening a connection to start the mutual authentication handshake.
+// It carries the node id of the entity and a challenge the other entity must sign.
+type AuthenticationRequest struct {
+	NodeID    string `json:"node_id"`
+	Challenge string `json:"challenge"`
+}
+
+// MessageType returns the message type of AuthenticationRequest
+func (AuthenticationRequest) MessageType() int32 {
+	return AuthenticationRequestType
+}
+
+// Validate checks the fields of AuthenticationRequest
+func (m AuthenticationRequest) Validate() error {
+	return firstError(
+		validateNodeID("node_id", m.NodeID),
+		validateRequired("challenge", m.Challenge),
+	)
+}
+
+// EncodeAuthenticationRequest is used to get the FCRMessage of AuthenticationRequest
+func EncodeAuthenticationRequest(nodeID *nodeid.NodeID, challenge string) (*FCRMessage, error) {
+	return Encode(AuthenticationRequest{
+		NodeID:    nodeID.ToString(),
+		Challenge: challenge,
+	})
+}
+
+// DecodeAuthenticationRequest is used to get the fields from FCRMessage of AuthenticationRequest
+func DecodeAuthenticationRequest(fcrMsg *FCRMessage) (
+	*nodeid.NodeID, // node id
+	string, // challenge
+	error, // error
+) {
+	if fcrMsg.GetMessageType() != AuthenticationRequestType {
+		return nil, "", errors.New("message type mismatch")
+	}
+	msg := AuthenticationRequest{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid message: %s", err)
+	}
+	if err = msg.Validate(); err != nil {
+		return nil, "", err
+	}
+	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.NodeID)
+	return nodeID, msg.Challenge, nil
+}
@@ -0,0 +1,42 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncodeAuthenticationRequest success test
+func TestEncodeAuthenticationRequest(t *testing.T) {
+	mockNodeID, _ := nodeid.NewNodeIDFromHexString("42")
+	validMsg := &FCRMessage{
+		messageType:       903,
+		protocolVersion:   1,
+		protocolSupported: []int32{1, 1},
+		messageBody:       []byte(`{"node_id":"0000000000000000000000000000000000000000000000000000000000000042","challenge":"abc"}`),
+		signature:         "",
+	}
+	msg, err := EncodeAuthenticationRequest(mockNodeID, "abc")
+	assert.Empty(t, err)
+	assert.Equal(t, validMsg, msg)
+}
+
+// TestDecodeAuthenticationRequest success test
+func TestDecodeAuthenticationRequest(t *testing.T) {
+	mockNodeID, _ := nodeid.NewNodeIDFromHexString("42")
+	msg, err := EncodeAuthenticationRequest(mockNodeID, "abc")
+	assert.Empty(t, err)
+	nodeID, challenge, err := DecodeAuthenticationRequest(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, mockNodeID, nodeID)
+	assert.Equal(t, "abc", challenge)
+}
+
+// TestDecodeAuthenticationRequest failure test
+func TestDecodeAuthenticationRequestErrors(t *testing.T) {
+	_, _, err := DecodeAuthenticationRequest(CreateFCRMessage(AuthenticationResponseType, []byte(`{}`)))
+	assert.Equal(t, "message type mismatch", err.Error())
+	_, _, err = DecodeAuthenticationRequest(CreateFCRMessage(AuthenticationRequestType, []byte(`{"node_id":"42"}`)))
+	assert.Equal(t, &ErrMalformedField{Field: "challenge", Reason: "required"}, err)
+}
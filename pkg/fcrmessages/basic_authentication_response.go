@@ -0,0 +1,77 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// AuthenticationResponse message is sent in response to AuthenticationRequest.
+// It carries the node id of the entity, its signature of the challenge of the request
+// and a challenge the entity opening the connection must sign.
+type AuthenticationResponse struct {
+	NodeID    string `json:"node_id"`
+	Signature string `json:"signature"`
+	Challenge string `json:"challenge"`
+}
+
+// MessageType returns the message type of AuthenticationResponse
+func (AuthenticationResponse) MessageType() int32 {
+	return AuthenticationResponseType
+}
+
+// Validate checks the fields of AuthenticationResponse
+func (m AuthenticationResponse) Validate() error {
+	return firstError(
+		validateNodeID("node_id", m.NodeID),
+		validateRequired("signature", m.Signature),
+		validateRequired("challenge", m.Challenge),
+	)
+}
+
+// EncodeAuthenticationResponse is used to get the FCRMessage of AuthenticationResponse
+func EncodeAuthenticationResponse(nodeID *nodeid.NodeID, signature string, challenge string) (*FCRMessage, error) {
+	return Encode(AuthenticationResponse{
+		NodeID:    nodeID.ToString(),
+		Signature: signature,
+		Challenge: challenge,
+	})
+}
+
+// DecodeAuthenticationResponse is used to get the fields from FCRMessage of AuthenticationResponse
+func DecodeAuthenticationResponse(fcrMsg *FCRMessage) (
+	*nodeid.NodeID, // node id
+	string, // signature
+	string, // challenge
+	error, // error
+) {
+	if fcrMsg.GetMessageType() != AuthenticationResponseType {
+		return nil, "", "", errors.New("message type mismatch")
+	}
+	msg := AuthenticationResponse{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid message: %s", err)
+	}
+	if err = msg.Validate(); err != nil {
+		return nil, "", "", err
+	}
+	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.NodeID)
+	return nodeID, msg.Signature, msg.Challenge, nil
+}
@@ -0,0 +1,43 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncodeAuthenticationResponse success test
+func TestEncodeAuthenticationResponse(t *testing.T) {
+	mockNodeID, _ := nodeid.NewNodeIDFromHexString("42")
+	validMsg := &FCRMessage{
+		messageType:       904,
+		protocolVersion:   1,
+		protocolSupported: []int32{1, 1},
+		messageBody:       []byte(`{"node_id":"0000000000000000000000000000000000000000000000000000000000000042","signature":"sig","challenge":"abc"}`),
+		signature:         "",
+	}
+	msg, err := EncodeAuthenticationResponse(mockNodeID, "sig", "abc")
+	assert.Empty(t, err)
+	assert.Equal(t, validMsg, msg)
+}
+
+// TestDecodeAuthenticationResponse success test
+func TestDecodeAuthenticationResponse(t *testing.T) {
+	mockNodeID, _ := nodeid.NewNodeIDFromHexString("42")
+	msg, err := EncodeAuthenticationResponse(mockNodeID, "sig", "abc")
+	assert.Empty(t, err)
+	nodeID, signature, challenge, err := DecodeAuthenticationResponse(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, mockNodeID, nodeID)
+	assert.Equal(t, "sig", signature)
+	assert.Equal(t, "abc", challenge)
+}
+
+// TestDecodeAuthenticationResponse failure test
+func TestDecodeAuthenticationResponseErrors(t *testing.T) {
+	_, _, _, err := DecodeAuthenticationResponse(CreateFCRMessage(AuthenticationRequestType, []byte(`{}`)))
+	assert.Equal(t, "message type mismatch", err.Error())
+	_, _, _, err = DecodeAuthenticationResponse(CreateFCRMessage(AuthenticationResponseType, []byte(`{"node_id":"42","challenge":"abc"}`)))
+	assert.Equal(t, &ErrMalformedField{Field: "signature", Reason: "required"}, err)
+}
@@ -18,26 +18,124 @@ package fcrmessages
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
-// invalidMessageResponse message is sent to indicate that the message is invalid
-type invalidMessageResponse struct {
+// InvalidMessageCode explains why a message has been rejected.
+type InvalidMessageCode int32
+
+// Reasons for rejecting a message.
+const (
+	InvalidMessageUnspecified     InvalidMessageCode = 0
+	InvalidMessageUnknownType     InvalidMessageCode = 1
+	InvalidMessageSignature       InvalidMessageCode = 2
+	InvalidMessageProtocolVersion InvalidMessageCode = 3
+	InvalidMessageMalformed       InvalidMessageCode = 4
+	InvalidMessageTooLarge        InvalidMessageCode = 5
+	InvalidMessageUnauthenticated InvalidMessageCode = 6
+	InvalidMessageRateLimited     InvalidMessageCode = 7
+	InvalidMessageOverloaded      InvalidMessageCode = 8
+)
+
+// String returns the name of the code.
+func (c InvalidMessageCode) String() string {
+	switch c {
+	case InvalidMessageUnspecified:
+		return "unspecified"
+	case InvalidMessageUnknownType:
+		return "unknown message type"
+	case InvalidMessageSignature:
+		return "signature verification failed"
+	case InvalidMessageProtocolVersion:
+		return "protocol version mismatch"
+	case InvalidMessageMalformed:
+		return "malformed message"
+	case InvalidMessageTooLarge:
+		return "message too large"
+	case InvalidMessageUnauthenticated:
+		return "peer not authenticated"
+	case InvalidMessageRateLimited:
+		return "rate limit exceeded"
+	case InvalidMessageOverloaded:
+		return "server overloaded"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(c))
+	}
+}
+
+// InvalidMessageResponse message is sent to indicate that the message is invalid
+// OffendingType and OffendingNonce echo the offending message when they are known.
+type InvalidMessageResponse struct {
+	Code           InvalidMessageCode `json:"code,omitempty"`
+	Reason         string             `json:"reason,omitempty"`
+	OffendingType  int32              `json:"offending_type,omitempty"`
+	OffendingNonce int64              `json:"offending_nonce,omitempty"`
+}
+
+// MessageType returns the message type of InvalidMessageResponse
+func (InvalidMessageResponse) MessageType() int32 {
+	return InvalidMessageResponseType
 }
 
-// EncodeInvalidMessageResponse is used to get the FCRMessage of invalidMessageResponse
+// EncodeInvalidMessageResponse is used to get the FCRMessage of InvalidMessageResponse
 func EncodeInvalidMessageResponse() (*FCRMessage, error) {
-	body, err := json.Marshal(invalidMessageResponse{})
-	if err != nil {
-		return nil, err
+	return Encode(InvalidMessageResponse{})
+}
+
+// EncodeInvalidMessageResponseWithReason is used to get the FCRMessage of InvalidMessageResponse with a code,
+// a reason and the type and nonce of a given offending message, the offending message can be nil.
+func EncodeInvalidMessageResponseWithReason(
+	code InvalidMessageCode,
+	reason string,
+	offending *FCRMessage,
+) (*FCRMessage, error) {
+	msg := InvalidMessageResponse{
+		Code:   code,
+		Reason: reason,
+	}
+	if offending != nil {
+		msg.OffendingType = offending.GetMessageType()
+		// The nonce is optional, ignore bodies without one.
+		nonce := struct {
+			Nonce int64 `json:"nonce"`
+		}{}
+		if json.Unmarshal(offending.GetMessageBody(), &nonce) == nil {
+			msg.OffendingNonce = nonce.Nonce
+		}
 	}
-	return CreateFCRMessage(InvalidMessageResponseType, body), nil
+	return Encode(msg)
 }
 
-// DecodeInvalidMessageResponse is used to get the fields from FCRMessage of invalidMessageResponse
+// DecodeInvalidMessageResponse is used to get the fields from FCRMessage of InvalidMessageResponse
 func DecodeInvalidMessageResponse(fcrMsg *FCRMessage) error {
+	_, _, _, _, err := DecodeInvalidMessageResponseWithReason(fcrMsg)
+	return err
+}
+
+// DecodeInvalidMessageResponseWithReason is used to get the code, reason and offending message fields from FCRMessage of InvalidMessageResponse
+func DecodeInvalidMessageResponseWithReason(fcrMsg *FCRMessage) (
+	InvalidMessageCode, // code
+	string, // reason
+	int32, // offending message type
+	int64, // offending message nonce
+	error, // error
+) {
 	if fcrMsg.GetMessageType() != InvalidMessageResponseType {
-		return errors.New("message type mismatch")
+		return InvalidMessageUnspecified, "", 0, 0, errors.New("message type mismatch")
+	}
+	msg := InvalidMessageResponse{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return InvalidMessageUnspecified, "", 0, 0, err
+	}
+	return msg.Code, msg.Reason, msg.OffendingType, msg.OffendingNonce, nil
+}
+
+// InvalidMessageCodeFromError returns the code matching an error returned when decoding a message.
+func InvalidMessageCodeFromError(err error) InvalidMessageCode {
+	var malformed *ErrMalformedField
+	if errors.As(err, &malformed) {
+		return InvalidMessageMalformed
 	}
-	msg := invalidMessageResponse{}
-	return json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	return InvalidMessageUnspecified
 }
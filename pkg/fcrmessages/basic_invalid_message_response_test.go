@@ -48,3 +48,28 @@ func TestDecodeInvalidMessageResponseInvalid(t *testing.T) {
 	err := DecodeInvalidMessageResponse(invalidTypeMsg)
 	assert.Equal(t, err, errors.New("message type mismatch"))
 }
+
+// TestEncodeInvalidMessageResponseWithReason success test
+func TestEncodeInvalidMessageResponseWithReason(t *testing.T) {
+	request := CreateFCRMessage(ClientStandardDiscoverRequestV2Type, []byte(`{"piece_cid":"zz","nonce":42}`))
+	msg, err := EncodeInvalidMessageResponseWithReason(InvalidMessageMalformed, "bad piece cid", request)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`{"code":4,"reason":"bad piece cid","offending_type":108,"offending_nonce":42}`), msg.GetMessageBody())
+
+	code, reason, msgType, nonce, err := DecodeInvalidMessageResponseWithReason(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, InvalidMessageMalformed, code)
+	assert.Equal(t, "bad piece cid", reason)
+	assert.Equal(t, int32(ClientStandardDiscoverRequestV2Type), msgType)
+	assert.Equal(t, int64(42), nonce)
+
+	msg, err = EncodeInvalidMessageResponseWithReason(InvalidMessageUnknownType, "unknown", CreateFCRMessage(-1, []byte(`[]`)))
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`{"code":1,"reason":"unknown","offending_type":-1}`), msg.GetMessageBody())
+}
+
+// TestInvalidMessageCodeFromError success test
+func TestInvalidMessageCodeFromError(t *testing.T) {
+	assert.Equal(t, InvalidMessageMalformed, InvalidMessageCodeFromError(&ErrMalformedField{Field: "nonce", Reason: "negative value"}))
+	assert.Equal(t, InvalidMessageUnspecified, InvalidMessageCodeFromError(errors.New("other")))
+}
@@ -20,36 +20,80 @@ import (
 	"errors"
 )
 
-// protocolChangeRequest message is sent to indicate that the entity is requesting the other entity change protocol version
-type protocolChangeRequest struct {
+// ProtocolChangeRequest message is sent to indicate that the entity is requesting the other entity change protocol version
+// and optionally the wire codec used on the connection and the use of request ids to multiplex requests on the connection
+type ProtocolChangeRequest struct {
 	DesiredVersion int32 `json:"desired_version"`
+	DesiredCodec   Codec `json:"desired_codec,omitempty"`
+	Multiplex      bool  `json:"multiplex,omitempty"`
 }
 
-// EncodeProtocolChangeRequest is used to get the FCRMessage of protocolChangeRequest
+// MessageType returns the message type of ProtocolChangeRequest
+func (ProtocolChangeRequest) MessageType() int32 {
+	return ProtocolChangeRequestType
+}
+
+// EncodeProtocolChangeRequest is used to get the FCRMessage of ProtocolChangeRequest
 func EncodeProtocolChangeRequest(
 	desiredVersion int32,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(protocolChangeRequest{
+	return EncodeProtocolChangeRequestWithCodec(desiredVersion, CodecJSON)
+}
+
+// EncodeProtocolChangeRequestWithCodec is used to get the FCRMessage of ProtocolChangeRequest requesting a codec
+func EncodeProtocolChangeRequestWithCodec(
+	desiredVersion int32,
+	desiredCodec Codec,
+) (*FCRMessage, error) {
+	return EncodeProtocolChangeRequestWithOptions(desiredVersion, desiredCodec, false)
+}
+
+// EncodeProtocolChangeRequestWithOptions is used to get the FCRMessage of ProtocolChangeRequest requesting a codec and multiplexing
+func EncodeProtocolChangeRequestWithOptions(
+	desiredVersion int32,
+	desiredCodec Codec,
+	multiplex bool,
+) (*FCRMessage, error) {
+	return Encode(ProtocolChangeRequest{
 		DesiredVersion: desiredVersion,
+		DesiredCodec:   desiredCodec,
+		Multiplex:      multiplex,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProtocolChangeRequestType, body), nil
 }
 
-// DecodeProtocolChangeRequest is used to get the fields from FCRMessage of protocolChangeRequest
+// DecodeProtocolChangeRequest is used to get the fields from FCRMessage of ProtocolChangeRequest
 func DecodeProtocolChangeRequest(fcrMsg *FCRMessage) (
 	int32, // desired version
 	error, // error
+) {
+	desiredVersion, _, err := DecodeProtocolChangeRequestWithCodec(fcrMsg)
+	return desiredVersion, err
+}
+
+// DecodeProtocolChangeRequestWithCodec is used to get the fields including the desired codec from FCRMessage of ProtocolChangeRequest
+func DecodeProtocolChangeRequestWithCodec(fcrMsg *FCRMessage) (
+	int32, // desired version
+	Codec, // desired codec
+	error, // error
+) {
+	desiredVersion, desiredCodec, _, err := DecodeProtocolChangeRequestWithOptions(fcrMsg)
+	return desiredVersion, desiredCodec, err
+}
+
+// DecodeProtocolChangeRequestWithOptions is used to get the fields including the desired codec and multiplexing from FCRMessage of ProtocolChangeRequest
+func DecodeProtocolChangeRequestWithOptions(fcrMsg *FCRMessage) (
+	int32, // desired version
+	Codec, // desired codec
+	bool, // multiplex
+	error, // error
 ) {
 	if fcrMsg.GetMessageType() != ProtocolChangeRequestType {
-		return 0, errors.New("message type mismatch")
+		return 0, CodecJSON, false, errors.New("message type mismatch")
 	}
-	msg := protocolChangeRequest{}
+	msg := ProtocolChangeRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
-		return 0, err
+		return 0, CodecJSON, false, err
 	}
-	return msg.DesiredVersion, nil
+	return msg.DesiredVersion, msg.DesiredCodec, msg.Multiplex, nil
 }
@@ -34,3 +34,15 @@ func TestDecodeProtocolChangeRequest(t *testing.T) {
 	assert.Equal(t, msg, int32(0))
 }
 
+
+// TestProtocolChangeRequestWithOptions success test
+func TestProtocolChangeRequestWithOptions(t *testing.T) {
+	msg, err := EncodeProtocolChangeRequestWithOptions(1, CodecJSON, true)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`{"desired_version":1,"multiplex":true}`), msg.GetMessageBody())
+	version, codec, multiplex, err := DecodeProtocolChangeRequestWithOptions(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, int32(1), version)
+	assert.Equal(t, CodecJSON, codec)
+	assert.True(t, multiplex)
+}
@@ -20,36 +20,79 @@ import (
 	"errors"
 )
 
-// protocolChangeResponse message is response to protocolChangeRequest
-type protocolChangeResponse struct {
-	Success bool `json:"success"`
+// ProtocolChangeResponse message is response to ProtocolChangeRequest
+type ProtocolChangeResponse struct {
+	Success   bool  `json:"success"`
+	Codec     Codec `json:"codec,omitempty"`
+	Multiplex bool  `json:"multiplex,omitempty"`
 }
 
-// EncodeProtocolChangeResponse is used to get the FCRMessage of protocolChangeResponse
+// MessageType returns the message type of ProtocolChangeResponse
+func (ProtocolChangeResponse) MessageType() int32 {
+	return ProtocolChangeResponseType
+}
+
+// EncodeProtocolChangeResponse is used to get the FCRMessage of ProtocolChangeResponse
 func EncodeProtocolChangeResponse(
 	success bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(protocolChangeResponse{
-		Success: success,
+	return EncodeProtocolChangeResponseWithCodec(success, CodecJSON)
+}
+
+// EncodeProtocolChangeResponseWithCodec is used to get the FCRMessage of ProtocolChangeResponse with the agreed codec
+func EncodeProtocolChangeResponseWithCodec(
+	success bool,
+	codec Codec,
+) (*FCRMessage, error) {
+	return EncodeProtocolChangeResponseWithOptions(success, codec, false)
+}
+
+// EncodeProtocolChangeResponseWithOptions is used to get the FCRMessage of ProtocolChangeResponse with the agreed codec and multiplexing
+func EncodeProtocolChangeResponseWithOptions(
+	success bool,
+	codec Codec,
+	multiplex bool,
+) (*FCRMessage, error) {
+	return Encode(ProtocolChangeResponse{
+		Success:   success,
+		Codec:     codec,
+		Multiplex: multiplex,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProtocolChangeResponseType, body), nil
 }
 
-// DecodeProtocolChangeResponse is used to get the fields from FCRMessage of protocolChangeResponse
+// DecodeProtocolChangeResponse is used to get the fields from FCRMessage of ProtocolChangeResponse
 func DecodeProtocolChangeResponse(fcrMsg *FCRMessage) (
 	bool, // success
 	error, // error
+) {
+	success, _, err := DecodeProtocolChangeResponseWithCodec(fcrMsg)
+	return success, err
+}
+
+// DecodeProtocolChangeResponseWithCodec is used to get the fields including the agreed codec from FCRMessage of ProtocolChangeResponse
+func DecodeProtocolChangeResponseWithCodec(fcrMsg *FCRMessage) (
+	bool, // success
+	Codec, // agreed codec
+	error, // error
+) {
+	success, codec, _, err := DecodeProtocolChangeResponseWithOptions(fcrMsg)
+	return success, codec, err
+}
+
+// DecodeProtocolChangeResponseWithOptions is used to get the fields including the agreed codec and multiplexing from FCRMessage of ProtocolChangeResponse
+func DecodeProtocolChangeResponseWithOptions(fcrMsg *FCRMessage) (
+	bool, // success
+	Codec, // agreed codec
+	bool, // multiplex
+	error, // error
 ) {
 	if fcrMsg.GetMessageType() != ProtocolChangeResponseType {
-		return false, errors.New("message type mismatch")
+		return false, CodecJSON, false, errors.New("message type mismatch")
 	}
-	msg := protocolChangeResponse{}
+	msg := ProtocolChangeResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
-		return false, err
+		return false, CodecJSON, false, err
 	}
-	return msg.Success, nil
+	return msg.Success, msg.Codec, msg.Multiplex, nil
 }
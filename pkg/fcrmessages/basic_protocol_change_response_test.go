@@ -47,3 +47,15 @@ func TestDecodeProtocolChangeResponseErrorType(t *testing.T) {
 	assert.Empty(t, msg)
 	assert.Equal(t, err, errors.New("message type mismatch"))
 }
+
+// TestProtocolChangeResponseWithOptions success test
+func TestProtocolChangeResponseWithOptions(t *testing.T) {
+	msg, err := EncodeProtocolChangeResponseWithOptions(true, CodecBinary, true)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`{"success":true,"codec":1,"multiplex":true}`), msg.GetMessageBody())
+	success, codec, multiplex, err := DecodeProtocolChangeResponseWithOptions(msg)
+	assert.Empty(t, err)
+	assert.True(t, success)
+	assert.Equal(t, CodecBinary, codec)
+	assert.True(t, multiplex)
+}
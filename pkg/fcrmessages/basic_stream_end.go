@@ -0,0 +1,66 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// StreamEnd message terminates a response streamed as a sequence of chunk messages.
+// Chunks is the number of chunks sent, Error is set if the sender failed before sending every chunk.
+type StreamEnd struct {
+	Chunks int64  `json:"chunks"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MessageType returns the message type of StreamEnd
+func (StreamEnd) MessageType() int32 {
+	return StreamEndType
+}
+
+// Validate checks the fields of StreamEnd
+func (m StreamEnd) Validate() error {
+	return validateNonNegative("chunks", m.Chunks)
+}
+
+// EncodeStreamEnd is used to get the FCRMessage of StreamEnd
+func EncodeStreamEnd(chunks int64, streamErr string) (*FCRMessage, error) {
+	return Encode(StreamEnd{
+		Chunks: chunks,
+		Error:  streamErr,
+	})
+}
+
+// DecodeStreamEnd is used to get the fields from FCRMessage of StreamEnd
+func DecodeStreamEnd(fcrMsg *FCRMessage) (
+	int64, // chunks
+	string, // error
+	error, // error
+) {
+	if fcrMsg.GetMessageType() != StreamEndType {
+		return 0, "", errors.New("message type mismatch")
+	}
+	msg := StreamEnd{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid message: %s", err)
+	}
+	if err = msg.Validate(); err != nil {
+		return 0, "", err
+	}
+	return msg.Chunks, msg.Error, nil
+}
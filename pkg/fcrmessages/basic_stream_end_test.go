@@ -0,0 +1,27 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStreamEnd success test
+func TestStreamEnd(t *testing.T) {
+	msg, err := EncodeStreamEnd(3, "")
+	assert.Empty(t, err)
+	assert.Equal(t, int32(907), msg.GetMessageType())
+	assert.Equal(t, []byte(`{"chunks":3}`), msg.GetMessageBody())
+	chunks, streamErr, err := DecodeStreamEnd(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, int64(3), chunks)
+	assert.Equal(t, "", streamErr)
+}
+
+// TestDecodeStreamEnd failure test
+func TestDecodeStreamEndErrors(t *testing.T) {
+	_, _, err := DecodeStreamEnd(CreateFCRMessage(AuthenticationAckType, []byte(`{}`)))
+	assert.Equal(t, "message type mismatch", err.Error())
+	_, _, err = DecodeStreamEnd(CreateFCRMessage(StreamEndType, []byte(`{"chunks":-1}`)))
+	assert.Equal(t, &ErrMalformedField{Field: "chunks", Reason: "negative value"}, err)
+}
@@ -0,0 +1,70 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// StreamWindowUpdate message gives back to the sender of a multiplexed request the credit of the messages its peer
+// has read from the stream of the request. Messages is the number of messages read, Bytes the size of their bodies.
+type StreamWindowUpdate struct {
+	Messages int64 `json:"messages"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// MessageType returns the message type of StreamWindowUpdate
+func (StreamWindowUpdate) MessageType() int32 {
+	return StreamWindowUpdateType
+}
+
+// Validate checks the fields of StreamWindowUpdate
+func (m StreamWindowUpdate) Validate() error {
+	if err := validateNonNegative("messages", m.Messages); err != nil {
+		return err
+	}
+	return validateNonNegative("bytes", m.Bytes)
+}
+
+// EncodeStreamWindowUpdate is used to get the FCRMessage of StreamWindowUpdate
+func EncodeStreamWindowUpdate(messages int64, bytes int64) (*FCRMessage, error) {
+	return Encode(StreamWindowUpdate{
+		Messages: messages,
+		Bytes:    bytes,
+	})
+}
+
+// DecodeStreamWindowUpdate is used to get the fields from FCRMessage of StreamWindowUpdate
+func DecodeStreamWindowUpdate(fcrMsg *FCRMessage) (
+	int64, // messages
+	int64, // bytes
+	error, // error
+) {
+	if fcrMsg.GetMessageType() != StreamWindowUpdateType {
+		return 0, 0, errors.New("message type mismatch")
+	}
+	msg := StreamWindowUpdate{}
+	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid message: %s", err)
+	}
+	if err = msg.Validate(); err != nil {
+		return 0, 0, err
+	}
+	return msg.Messages, msg.Bytes, nil
+}
@@ -0,0 +1,27 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStreamWindowUpdate success test
+func TestStreamWindowUpdate(t *testing.T) {
+	msg, err := EncodeStreamWindowUpdate(2, 1024)
+	assert.Empty(t, err)
+	assert.Equal(t, int32(908), msg.GetMessageType())
+	assert.Equal(t, []byte(`{"messages":2,"bytes":1024}`), msg.GetMessageBody())
+	messages, bytes, err := DecodeStreamWindowUpdate(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, int64(2), messages)
+	assert.Equal(t, int64(1024), bytes)
+}
+
+// TestDecodeStreamWindowUpdate failure test
+func TestDecodeStreamWindowUpdateErrors(t *testing.T) {
+	_, _, err := DecodeStreamWindowUpdate(CreateFCRMessage(StreamEndType, []byte(`{}`)))
+	assert.Equal(t, "message type mismatch", err.Error())
+	_, _, err = DecodeStreamWindowUpdate(CreateFCRMessage(StreamWindowUpdateType, []byte(`{"messages":1,"bytes":-1}`)))
+	assert.Equal(t, &ErrMalformedField{Field: "bytes", Reason: "negative value"}, err)
+}
@@ -24,8 +24,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTDiscoverOfferRequest is the requset from client to gateway to ask for cid offer via DHT
-type clientDHTDiscoverOfferRequest struct {
+// ClientDHTDiscoverOfferRequest is the requset from client to gateway to ask for cid offer via DHT
+type ClientDHTDiscoverOfferRequest struct {
 	PieceCID        string                                `json:"piece_cid"`
 	Nonce           int64                                 `json:"nonce"`
 	GatewaysDigests [][][cidoffer.CIDOfferDigestSize]byte `json:"gateways_digests"`
@@ -34,7 +34,22 @@ type clientDHTDiscoverOfferRequest struct {
 	Voucher         string                                `json:"voucher"`
 }
 
-// EncodeClientDHTDiscoverOfferRequest is used to get the FCRMessage of clientDHTDiscoverOfferRequest
+// MessageType returns the message type of ClientDHTDiscoverOfferRequest
+func (ClientDHTDiscoverOfferRequest) MessageType() int32 {
+	return ClientDHTDiscoverOfferRequestType
+}
+
+// Validate checks the fields of ClientDHTDiscoverOfferRequest
+func (m ClientDHTDiscoverOfferRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("gateways_digests", len(m.GatewaysDigests)),
+		validateNodeIDs("gateway_ids", m.GatewayIDs),
+	)
+}
+
+// EncodeClientDHTDiscoverOfferRequest is used to get the FCRMessage of ClientDHTDiscoverOfferRequest
 func EncodeClientDHTDiscoverOfferRequest(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -43,7 +58,7 @@ func EncodeClientDHTDiscoverOfferRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverOfferRequest{
+	return Encode(ClientDHTDiscoverOfferRequest{
 		PieceCID:        pieceCID.ToString(),
 		Nonce:           nonce,
 		GatewaysDigests: gatewaysDigests,
@@ -51,13 +66,9 @@ func EncodeClientDHTDiscoverOfferRequest(
 		PaychAddr:       paychAddr,
 		Voucher:         voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverOfferRequestType, body), nil
 }
 
-// DecodeClientDHTDiscoverOfferRequest is used to get the fields from FCRMessage of clientDHTDiscoverOfferRequest
+// DecodeClientDHTDiscoverOfferRequest is used to get the fields from FCRMessage of ClientDHTDiscoverOfferRequest
 func DecodeClientDHTDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -70,11 +81,14 @@ func DecodeClientDHTDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverOfferRequestType {
 		return nil, 0, nil, nil, "", "", errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverOfferRequest{}
+	msg := ClientDHTDiscoverOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, nil, nil, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, nil, nil, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.GatewaysDigests, nodeid.MapStringToNodeID(msg.GatewayIDs), msg.PaychAddr, msg.Voucher, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTDiscoverOfferResponse is the response to clientDHTDiscoverOfferRequest
-type clientDHTDiscoverOfferResponse struct {
+// ClientDHTDiscoverOfferResponse is the response to ClientDHTDiscoverOfferRequest
+type ClientDHTDiscoverOfferResponse struct {
 	PieceCID        string       `json:"piece_cid"`
 	Nonce           int64        `json:"nonce"`
 	GatewayIDs      []string     `json:"gateway_ids"`
@@ -33,7 +33,22 @@ type clientDHTDiscoverOfferResponse struct {
 	PaymentChannel  int64        `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
-// EncodeClientDHTDiscoverOfferResponse is used to get the FCRMessage of clientDHTDiscoverOfferResponse
+// MessageType returns the message type of ClientDHTDiscoverOfferResponse
+func (ClientDHTDiscoverOfferResponse) MessageType() int32 {
+	return ClientDHTDiscoverOfferResponseType
+}
+
+// Validate checks the fields of ClientDHTDiscoverOfferResponse
+func (m ClientDHTDiscoverOfferResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNodeIDs("gateway_ids", m.GatewayIDs),
+		validateLength("response", len(m.Response)),
+	)
+}
+
+// EncodeClientDHTDiscoverOfferResponse is used to get the FCRMessage of ClientDHTDiscoverOfferResponse
 func EncodeClientDHTDiscoverOfferResponse(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -42,7 +57,7 @@ func EncodeClientDHTDiscoverOfferResponse(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverOfferResponse{
+	return Encode(ClientDHTDiscoverOfferResponse{
 		PieceCID:        pieceCID.ToString(),
 		Nonce:           nonce,
 		GatewayIDs:      nodeid.MapNodeIDToString(gatewayIDs),
@@ -50,13 +65,9 @@ func EncodeClientDHTDiscoverOfferResponse(
 		PaymentRequired: paymentRequired,
 		PaymentChannel:  paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverOfferResponseType, body), nil
 }
 
-// DecodeClientDHTDiscoverOfferResponse is used to get the fields from FCRMessage of clientDHTDiscoverOfferResponse
+// DecodeClientDHTDiscoverOfferResponse is used to get the fields from FCRMessage of ClientDHTDiscoverOfferResponse
 func DecodeClientDHTDiscoverOfferResponse(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -69,11 +80,14 @@ func DecodeClientDHTDiscoverOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverOfferResponseType {
 		return nil, 0, nil, nil, false, 0, errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverOfferResponse{}
+	msg := ClientDHTDiscoverOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, nil, nil, false, 0, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, nil, nil, false, 0, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, nodeid.MapStringToNodeID(msg.GatewayIDs), msg.Response, msg.PaymentRequired, msg.PaymentChannel, nil
 }
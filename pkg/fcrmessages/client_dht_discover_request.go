@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// clientDHTDiscoverRequest is the request from client to gateway to ask for cid offer using DHT
-type clientDHTDiscoverRequest struct {
+// ClientDHTDiscoverRequest is the request from client to gateway to ask for cid offer using DHT
+type ClientDHTDiscoverRequest struct {
 	PieceCID           string `json:"piece_cid"`
 	Nonce              int64  `json:"nonce"`
 	TTL                int64  `json:"ttl"`
@@ -33,7 +33,22 @@ type clientDHTDiscoverRequest struct {
 	Voucher            string `json:"voucher"`
 }
 
-// EncodeClientDHTDiscoverRequest is used to get the FCRMessage of clientDHTDiscoverRequest
+// MessageType returns the message type of ClientDHTDiscoverRequest
+func (ClientDHTDiscoverRequest) MessageType() int32 {
+	return ClientDHTDiscoverRequestType
+}
+
+// Validate checks the fields of ClientDHTDiscoverRequest
+func (m ClientDHTDiscoverRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+		validateNonNegative("num_dht", m.NumDHT),
+	)
+}
+
+// EncodeClientDHTDiscoverRequest is used to get the FCRMessage of ClientDHTDiscoverRequest
 func EncodeClientDHTDiscoverRequest(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -43,7 +58,7 @@ func EncodeClientDHTDiscoverRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverRequest{
+	return Encode(ClientDHTDiscoverRequest{
 		PieceCID:           pieceCID.ToString(),
 		Nonce:              nonce,
 		TTL:                ttl,
@@ -52,13 +67,9 @@ func EncodeClientDHTDiscoverRequest(
 		PaychAddr:          paychAddr,
 		Voucher:            voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverRequestType, body), nil
 }
 
-// DecodeClientDHTDiscoverRequest is used to get the fields from FCRMessage of clientDHTDiscoverRequest
+// DecodeClientDHTDiscoverRequest is used to get the fields from FCRMessage of ClientDHTDiscoverRequest
 func DecodeClientDHTDiscoverRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -72,11 +83,14 @@ func DecodeClientDHTDiscoverRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverRequestType {
 		return nil, 0, 0, 0, false, "", "", errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverRequest{}
+	msg := ClientDHTDiscoverRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, 0, false, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, 0, false, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.TTL, msg.NumDHT, msg.IncrementalResults, msg.PaychAddr, msg.Voucher, nil
 }
@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// clientDHTDiscoverRequestV2 is the request from client to gateway to ask for cid offer using DHT
-type clientDHTDiscoverRequestV2 struct {
+// ClientDHTDiscoverRequestV2 is the request from client to gateway to ask for cid offer using DHT
+type ClientDHTDiscoverRequestV2 struct {
 	PieceCID           string `json:"piece_cid"`
 	Nonce              int64  `json:"nonce"`
 	TTL                int64  `json:"ttl"`
@@ -33,7 +33,22 @@ type clientDHTDiscoverRequestV2 struct {
 	Voucher            string `json:"voucher"`
 }
 
-// EncodeClientDHTDiscoverRequestV2 is used to get the FCRMessage of clientDHTDiscoverRequest
+// MessageType returns the message type of ClientDHTDiscoverRequestV2
+func (ClientDHTDiscoverRequestV2) MessageType() int32 {
+	return ClientDHTDiscoverRequestV2Type
+}
+
+// Validate checks the fields of ClientDHTDiscoverRequestV2
+func (m ClientDHTDiscoverRequestV2) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+		validateNonNegative("num_dht", m.NumDHT),
+	)
+}
+
+// EncodeClientDHTDiscoverRequestV2 is used to get the FCRMessage of ClientDHTDiscoverRequest
 func EncodeClientDHTDiscoverRequestV2(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -43,7 +58,7 @@ func EncodeClientDHTDiscoverRequestV2(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverRequestV2{
+	return Encode(ClientDHTDiscoverRequestV2{
 		PieceCID:           pieceCID.ToString(),
 		Nonce:              nonce,
 		TTL:                ttl,
@@ -52,13 +67,9 @@ func EncodeClientDHTDiscoverRequestV2(
 		PaychAddr:          paychAddr,
 		Voucher:            voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverRequestV2Type, body), nil
 }
 
-// DecodeClientDHTDiscoverRequestV2 is used to get the fields from FCRMessage of clientDHTDiscoverRequestV2
+// DecodeClientDHTDiscoverRequestV2 is used to get the fields from FCRMessage of ClientDHTDiscoverRequestV2
 func DecodeClientDHTDiscoverRequestV2(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -72,11 +83,14 @@ func DecodeClientDHTDiscoverRequestV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverRequestV2Type {
 		return nil, 0, 0, 0, false, "", "", errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverRequestV2{}
+	msg := ClientDHTDiscoverRequestV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, 0, false, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, 0, false, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.TTL, msg.NumDHT, msg.IncrementalResults, msg.PaychAddr, msg.Voucher, nil
 }
@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTDiscoverResponse is the response to clientDHTDiscoverRequest
-type clientDHTDiscoverResponse struct {
+// ClientDHTDiscoverResponse is the response to ClientDHTDiscoverRequest
+type ClientDHTDiscoverResponse struct {
 	Contacted       []string     `json:"contacted_gateways"`
 	Response        []FCRMessage `json:"response"`
 	UnContactable   []string     `json:"uncontactable_gateways"`
@@ -32,6 +32,21 @@ type clientDHTDiscoverResponse struct {
 	PaymentChannel  int64        `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
+// MessageType returns the message type of ClientDHTDiscoverResponse
+func (ClientDHTDiscoverResponse) MessageType() int32 {
+	return ClientDHTDiscoverResponseType
+}
+
+// Validate checks the fields of ClientDHTDiscoverResponse
+func (m ClientDHTDiscoverResponse) Validate() error {
+	return firstError(
+		validateNodeIDs("contacted_gateways", m.Contacted),
+		validateLength("response", len(m.Response)),
+		validateNodeIDs("uncontactable_gateways", m.UnContactable),
+		validateNonNegative("nonce", m.Nonce),
+	)
+}
+
 // EncodeClientDHTDiscoverResponse is used to get the FCRMessage of ClientDHTDiscoverResponse
 func EncodeClientDHTDiscoverResponse(
 	contacted []nodeid.NodeID,
@@ -41,7 +56,7 @@ func EncodeClientDHTDiscoverResponse(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverResponse{
+	return Encode(ClientDHTDiscoverResponse{
 		Contacted:       nodeid.MapNodeIDToString(contacted),
 		Response:        response,
 		UnContactable:   nodeid.MapNodeIDToString(unContactable),
@@ -49,10 +64,6 @@ func EncodeClientDHTDiscoverResponse(
 		PaymentRequired: paymentRequired,
 		PaymentChannel:  paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverResponseType, body), nil
 }
 
 // DecodeClientDHTDiscoverResponse is used to get the fields from FCRMessage of ClientDHTDiscoverResponse
@@ -68,10 +79,13 @@ func DecodeClientDHTDiscoverResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverResponseType {
 		return nil, nil, nil, 0, false, 0, errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverResponse{}
+	msg := ClientDHTDiscoverResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, 0, false, 0, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, 0, false, 0, err
+	}
 	return nodeid.MapStringToNodeID(msg.Contacted), msg.Response, nodeid.MapStringToNodeID(msg.UnContactable), msg.Nonce, msg.PaymentRequired, msg.PaymentChannel, nil
 }
@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTDiscoverResponse is the response to clientDHTDiscoverRequest
-type clientDHTDiscoverResponseV2 struct {
+// ClientDHTDiscoverResponse is the response to ClientDHTDiscoverRequest
+type ClientDHTDiscoverResponseV2 struct {
 	Contacted       []string     `json:"contacted_gateways"`
 	Response        []FCRMessage `json:"response"`
 	UnContactable   []string     `json:"uncontactable_gateways"`
@@ -32,6 +32,21 @@ type clientDHTDiscoverResponseV2 struct {
 	PaymentChannel  int64        `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
+// MessageType returns the message type of ClientDHTDiscoverResponseV2
+func (ClientDHTDiscoverResponseV2) MessageType() int32 {
+	return ClientDHTDiscoverResponseV2Type
+}
+
+// Validate checks the fields of ClientDHTDiscoverResponseV2
+func (m ClientDHTDiscoverResponseV2) Validate() error {
+	return firstError(
+		validateNodeIDs("contacted_gateways", m.Contacted),
+		validateLength("response", len(m.Response)),
+		validateNodeIDs("uncontactable_gateways", m.UnContactable),
+		validateNonNegative("nonce", m.Nonce),
+	)
+}
+
 // EncodeClientDHTDiscoverResponseV2 is used to get the FCRMessage of ClientDHTDiscoverResponse
 func EncodeClientDHTDiscoverResponseV2(
 	contacted []nodeid.NodeID,
@@ -41,7 +56,7 @@ func EncodeClientDHTDiscoverResponseV2(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTDiscoverResponseV2{
+	return Encode(ClientDHTDiscoverResponseV2{
 		Contacted:       nodeid.MapNodeIDToString(contacted),
 		Response:        response,
 		UnContactable:   nodeid.MapNodeIDToString(unContactable),
@@ -49,10 +64,6 @@ func EncodeClientDHTDiscoverResponseV2(
 		PaymentRequired: paymentRequired,
 		PaymentChannel:  paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTDiscoverResponseV2Type, body), nil
 }
 
 // DecodeClientDHTDiscoverResponseV2 is used to get the fields from FCRMessage of ClientDHTDiscoverResponse
@@ -68,10 +79,13 @@ func DecodeClientDHTDiscoverResponseV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTDiscoverResponseV2Type {
 		return nil, nil, nil, 0, false, 0, errors.New("message type mismatch")
 	}
-	msg := clientDHTDiscoverResponseV2{}
+	msg := ClientDHTDiscoverResponseV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, 0, false, 0, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, 0, false, 0, err
+	}
 	return nodeid.MapStringToNodeID(msg.Contacted), msg.Response, nodeid.MapStringToNodeID(msg.UnContactable), msg.Nonce, msg.PaymentRequired, msg.PaymentChannel, nil
 }
@@ -23,28 +23,37 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTOfferAckRequest is the request from client to provider to request the signed ack of a dht offer publish
-type clientDHTOfferAckRequest struct {
+// ClientDHTOfferAckRequest is the request from client to provider to request the signed ack of a dht offer publish
+type ClientDHTOfferAckRequest struct {
 	PieceCID  string `json:"piece_cid"`
 	GatewayID string `json:"gateway_id"`
 }
 
-// EncodeClientDHTOfferAckRequest is used to get the FCRMessage of clientDHTOfferAckRequest
+// MessageType returns the message type of ClientDHTOfferAckRequest
+func (ClientDHTOfferAckRequest) MessageType() int32 {
+	return ClientDHTOfferAckRequestType
+}
+
+// Validate checks the fields of ClientDHTOfferAckRequest
+func (m ClientDHTOfferAckRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
+// EncodeClientDHTOfferAckRequest is used to get the FCRMessage of ClientDHTOfferAckRequest
 func EncodeClientDHTOfferAckRequest(
 	pieceCID *cid.ContentID,
 	gatewayID *nodeid.NodeID,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTOfferAckRequest{
+	return Encode(ClientDHTOfferAckRequest{
 		PieceCID:  pieceCID.ToString(),
 		GatewayID: gatewayID.ToString(),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTOfferAckRequestType, body), nil
 }
 
-// DecodeClientDHTOfferAckRequest is used to get the fields from FCRMessage of clientDHTOfferAckRequest
+// DecodeClientDHTOfferAckRequest is used to get the fields from FCRMessage of ClientDHTOfferAckRequest
 func DecodeClientDHTOfferAckRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	*nodeid.NodeID, // gateway id
@@ -53,12 +62,15 @@ func DecodeClientDHTOfferAckRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTOfferAckRequestType {
 		return nil, nil, errors.New("message type mismatch")
 	}
-	msg := clientDHTOfferAckRequest{}
+	msg := ClientDHTOfferAckRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return contentID, nodeID, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientDHTOfferAckResponse is the response to clientDHTOfferAckRequest
-type clientDHTOfferAckResponse struct {
+// ClientDHTOfferAckResponse is the response to ClientDHTOfferAckRequest
+type ClientDHTOfferAckResponse struct {
 	PieceCID                string     `json:"piece_cid"`
 	GatewayID               string     `json:"gateway_id"`
 	Found                   bool       `json:"found"`
@@ -32,7 +32,20 @@ type clientDHTOfferAckResponse struct {
 	PublishDHTOfferResponse FCRMessage `json:"publish_dht_offer_response"`
 }
 
-// EncodeClientDHTOfferAckResponse is used to get the FCRMessage of clientDHTOfferAckResponse
+// MessageType returns the message type of ClientDHTOfferAckResponse
+func (ClientDHTOfferAckResponse) MessageType() int32 {
+	return ClientDHTOfferAckResponseType
+}
+
+// Validate checks the fields of ClientDHTOfferAckResponse
+func (m ClientDHTOfferAckResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
+// EncodeClientDHTOfferAckResponse is used to get the FCRMessage of ClientDHTOfferAckResponse
 func EncodeClientDHTOfferAckResponse(
 	pieceCID *cid.ContentID,
 	gatewayID *nodeid.NodeID,
@@ -40,20 +53,16 @@ func EncodeClientDHTOfferAckResponse(
 	publishDHTOfferRequest *FCRMessage,
 	publishDHTOfferResponse *FCRMessage,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientDHTOfferAckResponse{
+	return Encode(ClientDHTOfferAckResponse{
 		PieceCID:                pieceCID.ToString(),
 		GatewayID:               gatewayID.ToString(),
 		Found:                   found,
 		PublishDHTOfferRequest:  *publishDHTOfferRequest,
 		PublishDHTOfferResponse: *publishDHTOfferResponse,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientDHTOfferAckResponseType, body), nil
 }
 
-// DecodeClientDHTOfferAckResponse is used to get the fields from FCRMessage of clientDHTOfferAckResponse
+// DecodeClientDHTOfferAckResponse is used to get the fields from FCRMessage of ClientDHTOfferAckResponse
 func DecodeClientDHTOfferAckResponse(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	*nodeid.NodeID, // gateway id
@@ -65,12 +74,15 @@ func DecodeClientDHTOfferAckResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientDHTOfferAckResponseType {
 		return nil, nil, false, nil, nil, errors.New("message type mismatch")
 	}
-	msg := clientDHTOfferAckResponse{}
+	msg := ClientDHTOfferAckResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, false, nil, nil, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, nil, false, nil, nil, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return contentID, nodeID, msg.Found, &msg.PublishDHTOfferRequest, &msg.PublishDHTOfferResponse, nil
 }
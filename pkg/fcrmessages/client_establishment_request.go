@@ -22,31 +22,40 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientEstablishmentRequest is the request from client to gateway to establish connection
-type clientEstablishmentRequest struct {
+// ClientEstablishmentRequest is the request from client to gateway to establish connection
+type ClientEstablishmentRequest struct {
 	ClientID  string `json:"client_id"`
 	Challenge string `json:"challenge"`
 	TTL       int64  `json:"ttl"`
 }
 
-// EncodeClientEstablishmentRequest is used to get the FCRMessage of clientEstablishmentRequest
+// MessageType returns the message type of ClientEstablishmentRequest
+func (ClientEstablishmentRequest) MessageType() int32 {
+	return ClientEstablishmentRequestType
+}
+
+// Validate checks the fields of ClientEstablishmentRequest
+func (m ClientEstablishmentRequest) Validate() error {
+	return firstError(
+		validateNodeID("client_id", m.ClientID),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeClientEstablishmentRequest is used to get the FCRMessage of ClientEstablishmentRequest
 func EncodeClientEstablishmentRequest(
 	clientID *nodeid.NodeID,
 	challenge string,
 	ttl int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientEstablishmentRequest{
+	return Encode(ClientEstablishmentRequest{
 		ClientID:  clientID.ToString(),
 		Challenge: challenge,
 		TTL:       ttl,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientEstablishmentRequestType, body), nil
 }
 
-// DecodeClientEstablishmentRequest is used to get the fields from FCRMessage of clientEstablishmentRequest
+// DecodeClientEstablishmentRequest is used to get the fields from FCRMessage of ClientEstablishmentRequest
 func DecodeClientEstablishmentRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // client id
 	string, // challenge
@@ -56,11 +65,14 @@ func DecodeClientEstablishmentRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientEstablishmentRequestType {
 		return nil, "", 0, errors.New("message type mismatch")
 	}
-	msg := clientEstablishmentRequest{}
+	msg := ClientEstablishmentRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, "", 0, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, "", 0, err
+	}
 	clientID, _ := nodeid.NewNodeIDFromHexString(msg.ClientID)
 	return clientID, msg.Challenge, msg.TTL, nil
 }
@@ -22,25 +22,33 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// clientEstablishmentResponse is the response to clientEstablishmentRequest
-type clientEstablishmentResponse struct {
+// ClientEstablishmentResponse is the response to ClientEstablishmentRequest
+type ClientEstablishmentResponse struct {
 	GatewayID string `json:"gateway_id"`
 	Challenge string `json:"challenge"`
 }
 
-// EncodeClientEstablishmentResponse is used to get the FCRMessage of clientEstablishmentResponse
+// MessageType returns the message type of ClientEstablishmentResponse
+func (ClientEstablishmentResponse) MessageType() int32 {
+	return ClientEstablishmentResponseType
+}
+
+// Validate checks the fields of ClientEstablishmentResponse
+func (m ClientEstablishmentResponse) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
+// EncodeClientEstablishmentResponse is used to get the FCRMessage of ClientEstablishmentResponse
 func EncodeClientEstablishmentResponse(
 	gatewayID *nodeid.NodeID,
 	challenge string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientEstablishmentResponse{
+	return Encode(ClientEstablishmentResponse{
 		GatewayID: gatewayID.ToString(),
 		Challenge: challenge,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientEstablishmentResponseType, body), nil
 }
 
 // DecodeClientEstablishmentResponse is used to get the fields from FCRMessage of ClientEstablishmentResponse
@@ -52,11 +60,14 @@ func DecodeClientEstablishmentResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientEstablishmentResponseType {
 		return nil, "", errors.New("message type mismatch")
 	}
-	msg := clientEstablishmentResponse{}
+	msg := ClientEstablishmentResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, "", err
+	}
 	gatewayID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return gatewayID, msg.Challenge, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// clientStandardDiscoverOfferRequest is the requset from client to gateway to ask for cid offer
-type clientStandardDiscoverOfferRequest struct {
+// ClientStandardDiscoverOfferRequest is the requset from client to gateway to ask for cid offer
+type ClientStandardDiscoverOfferRequest struct {
 	PieceCID     string                              `json:"piece_cid"`
 	Nonce        int64                               `json:"nonce"`
 	TTL          int64                               `json:"ttl"`
@@ -33,7 +33,22 @@ type clientStandardDiscoverOfferRequest struct {
 	Voucher      string                              `json:"voucher"`
 }
 
-// EncodeClientStandardDiscoverOfferRequest is used to get the FCRMessage of clientStandardDiscoverOfferRequest
+// MessageType returns the message type of ClientStandardDiscoverOfferRequest
+func (ClientStandardDiscoverOfferRequest) MessageType() int32 {
+	return ClientStandardDiscoverOfferRequestType
+}
+
+// Validate checks the fields of ClientStandardDiscoverOfferRequest
+func (m ClientStandardDiscoverOfferRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+		validateLength("offer_digests", len(m.OfferDigests)),
+	)
+}
+
+// EncodeClientStandardDiscoverOfferRequest is used to get the FCRMessage of ClientStandardDiscoverOfferRequest
 func EncodeClientStandardDiscoverOfferRequest(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -42,7 +57,7 @@ func EncodeClientStandardDiscoverOfferRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverOfferRequest{
+	return Encode(ClientStandardDiscoverOfferRequest{
 		PieceCID:     pieceCID.ToString(),
 		Nonce:        nonce,
 		TTL:          ttl,
@@ -50,13 +65,9 @@ func EncodeClientStandardDiscoverOfferRequest(
 		PaychAddr:    paychAddr,
 		Voucher:      voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverOfferRequestType, body), nil
 }
 
-// DecodeClientStandardDiscoverOfferRequest is used to get the fields from FCRMessage of clientStandardDiscoverOfferRequest
+// DecodeClientStandardDiscoverOfferRequest is used to get the fields from FCRMessage of ClientStandardDiscoverOfferRequest
 func DecodeClientStandardDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -69,11 +80,14 @@ func DecodeClientStandardDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverOfferRequestType {
 		return nil, 0, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", errors.New("message type mismatch")
 	}
-	msg := clientStandardDiscoverOfferRequest{}
+	msg := ClientStandardDiscoverOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.TTL, msg.OfferDigests, msg.PaychAddr, msg.Voucher, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// clientStandardDiscoverOfferResponse is the response to clientStandardDiscoverRequest
-type clientStandardDiscoverOfferResponse struct {
+// ClientStandardDiscoverOfferResponse is the response to ClientStandardDiscoverRequest
+type ClientStandardDiscoverOfferResponse struct {
 	PieceCID             string                 `json:"piece_cid"`
 	Nonce                int64                  `json:"nonce"`
 	Found                bool                   `json:"found"`
@@ -34,7 +34,22 @@ type clientStandardDiscoverOfferResponse struct {
 	PaymentChannel       int64                  `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
-// EncodeClientStandardDiscoverOfferResponse is used to get the FCRMessage of clientStandardDiscoverOfferResponse
+// MessageType returns the message type of ClientStandardDiscoverOfferResponse
+func (ClientStandardDiscoverOfferResponse) MessageType() int32 {
+	return ClientStandardDiscoverOfferResponseType
+}
+
+// Validate checks the fields of ClientStandardDiscoverOfferResponse
+func (m ClientStandardDiscoverOfferResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offers", len(m.SubCIDOffers)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeClientStandardDiscoverOfferResponse is used to get the FCRMessage of ClientStandardDiscoverOfferResponse
 func EncodeClientStandardDiscoverOfferResponse(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -44,7 +59,7 @@ func EncodeClientStandardDiscoverOfferResponse(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverOfferResponse{
+	return Encode(ClientStandardDiscoverOfferResponse{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
@@ -53,13 +68,9 @@ func EncodeClientStandardDiscoverOfferResponse(
 		PaymentRequired:      paymentRequired,
 		PaymentChannel:       paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverOfferResponseType, body), nil
 }
 
-// DecodeClientStandardDiscoverOfferResponse is used to get the fields from FCRMessage of clientStandardDiscoverOfferResponse
+// DecodeClientStandardDiscoverOfferResponse is used to get the fields from FCRMessage of ClientStandardDiscoverOfferResponse
 func DecodeClientStandardDiscoverOfferResponse(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -73,11 +84,14 @@ func DecodeClientStandardDiscoverOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverOfferResponseType {
 		return nil, 0, false, nil, nil, false, 0, errors.New("message type mismatch")
 	}
-	msg := clientStandardDiscoverOfferResponse{}
+	msg := ClientStandardDiscoverOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, false, 0, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, false, 0, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOffers, msg.FundedPaymentChannel, msg.PaymentRequired, msg.PaymentChannel, nil
 }
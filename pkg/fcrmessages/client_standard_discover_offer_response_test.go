@@ -28,7 +28,7 @@ func TestEncodeClientStandardDiscoverOfferResponse(t *testing.T) {
 		messageType:       111,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
-		messageBody:       []byte(`{"piece_cid":"0000000000000000000000000000000000000000000000000000000000000001","nonce":42,"found":true,"sub_cid_offers":[{"provider_id":"0000000000000000000000000000000000000000000000000000000000000042","sub_cid":"0000000000000000000000000000000000000000000000000000000000000001","merkle_root":"c3c3a46684c07d12a9c238787df3049a6f258e7af203e5ddb66a8bd66637e108","merkle_proof":"AAAAMFsiQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBRT0iXQAAAANbMV0=","price":41,"expiry":42,"qos":43,"signature":""}],"funded_payment_channel":[true],"payment_required":true,"payment_channel":43}`),
+		messageBody:       []byte(`{"piece_cid":"0000000000000000000000000000000000000000000000000000000000000001","nonce":42,"found":true,"sub_cid_offers":[{"provider_id":"0000000000000000000000000000000000000000000000000000000000000042","sub_cid":"0000000000000000000000000000000000000000000000000000000000000001","merkle_root":"1fd4247443c9440cb3c48c28851937196bc156032d70a96c98e127ecb347e45f","merkle_proof":{"version":1,"path":[],"index":[]},"price":41,"expiry":42,"qos":43,"signature":""}],"funded_payment_channel":[true],"payment_required":true,"payment_channel":43}`),
 		signature:         "",
 	}
 	fakePaymentRequired := true
@@ -65,7 +65,7 @@ func TestDecodeClientStandardDiscoverOfferResponse(t *testing.T) {
 		messageType:       111,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
-		messageBody:       []byte(`{"piece_cid":"0000000000000000000000000000000000000000000000000000000000000001","nonce":42,"found":true,"sub_cid_offers":[{"provider_id":"0000000000000000000000000000000000000000000000000000000000000042","sub_cid":"0000000000000000000000000000000000000000000000000000000000000001","merkle_root":"c3c3a46684c07d12a9c238787df3049a6f258e7af203e5ddb66a8bd66637e108","merkle_proof":"AAAAMFsiQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBRT0iXQAAAANbMV0=","price":41,"expiry":42,"qos":43,"signature":""}],"funded_payment_channel":[true],"payment_required":true,"payment_channel":43}`),
+		messageBody:       []byte(`{"piece_cid":"0000000000000000000000000000000000000000000000000000000000000001","nonce":42,"found":true,"sub_cid_offers":[{"provider_id":"0000000000000000000000000000000000000000000000000000000000000042","sub_cid":"0000000000000000000000000000000000000000000000000000000000000001","merkle_root":"1fd4247443c9440cb3c48c28851937196bc156032d70a96c98e127ecb347e45f","merkle_proof":{"version":1,"path":[],"index":[]},"price":41,"expiry":42,"qos":43,"signature":""}],"funded_payment_channel":[true],"payment_required":true,"payment_channel":43}`),
 		signature:         "",
 	}
 	fakePaymentRequired := true
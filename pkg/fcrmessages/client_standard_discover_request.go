@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// clientStandardDiscoverRequest is the requset from client to gateway to ask for cid offer
-type clientStandardDiscoverRequest struct {
+// ClientStandardDiscoverRequest is the requset from client to gateway to ask for cid offer
+type ClientStandardDiscoverRequest struct {
 	PieceCID  string `json:"piece_cid"`
 	Nonce     int64  `json:"nonce"`
 	TTL       int64  `json:"ttl"`
@@ -31,7 +31,21 @@ type clientStandardDiscoverRequest struct {
 	Voucher   string `json:"voucher"`
 }
 
-// EncodeClientStandardDiscoverRequest is used to get the FCRMessage of clientStandardDiscoverRequest
+// MessageType returns the message type of ClientStandardDiscoverRequest
+func (ClientStandardDiscoverRequest) MessageType() int32 {
+	return ClientStandardDiscoverRequestType
+}
+
+// Validate checks the fields of ClientStandardDiscoverRequest
+func (m ClientStandardDiscoverRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeClientStandardDiscoverRequest is used to get the FCRMessage of ClientStandardDiscoverRequest
 func EncodeClientStandardDiscoverRequest(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -39,20 +53,16 @@ func EncodeClientStandardDiscoverRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverRequest{
+	return Encode(ClientStandardDiscoverRequest{
 		PieceCID:  pieceCID.ToString(),
 		Nonce:     nonce,
 		TTL:       ttl,
 		PaychAddr: paychAddr,
 		Voucher:   voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverRequestType, body), nil
 }
 
-// DecodeClientStandardDiscoverRequest is used to get the fields from FCRMessage of clientStandardDiscoverRequest
+// DecodeClientStandardDiscoverRequest is used to get the fields from FCRMessage of ClientStandardDiscoverRequest
 func DecodeClientStandardDiscoverRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -64,11 +74,14 @@ func DecodeClientStandardDiscoverRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverRequestType {
 		return nil, 0, 0, "", "", errors.New("message type mismatch")
 	}
-	msg := clientStandardDiscoverRequest{}
+	msg := ClientStandardDiscoverRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.TTL, msg.PaychAddr, msg.Voucher, nil
 }
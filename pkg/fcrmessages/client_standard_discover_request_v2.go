@@ -22,8 +22,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// clientStandardDiscoverRequestV2 is the request from client to gateway to ask for cid offer
-type clientStandardDiscoverRequestV2 struct {
+// ClientStandardDiscoverRequestV2 is the request from client to gateway to ask for cid offer
+type ClientStandardDiscoverRequestV2 struct {
 	PieceCID  string `json:"piece_cid"`
 	Nonce     int64  `json:"nonce"`
 	TTL       int64  `json:"ttl"`
@@ -31,7 +31,21 @@ type clientStandardDiscoverRequestV2 struct {
 	Voucher   string `json:"voucher"`
 }
 
-// EncodeClientStandardDiscoverRequestV2 is used to get the FCRMessage of clientStandardDiscoverRequestV2
+// MessageType returns the message type of ClientStandardDiscoverRequestV2
+func (ClientStandardDiscoverRequestV2) MessageType() int32 {
+	return ClientStandardDiscoverRequestV2Type
+}
+
+// Validate checks the fields of ClientStandardDiscoverRequestV2
+func (m ClientStandardDiscoverRequestV2) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeClientStandardDiscoverRequestV2 is used to get the FCRMessage of ClientStandardDiscoverRequestV2
 func EncodeClientStandardDiscoverRequestV2(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -39,20 +53,16 @@ func EncodeClientStandardDiscoverRequestV2(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverRequestV2{
+	return Encode(ClientStandardDiscoverRequestV2{
 		PieceCID:  pieceCID.ToString(),
 		Nonce:     nonce,
 		TTL:       ttl,
 		PaychAddr: paychAddr,
 		Voucher:   voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverRequestV2Type, body), nil
 }
 
-// DecodeClientStandardDiscoverRequestV2 is used to get the fields from FCRMessage of clientStandardDiscoverRequestV2
+// DecodeClientStandardDiscoverRequestV2 is used to get the fields from FCRMessage of ClientStandardDiscoverRequestV2
 func DecodeClientStandardDiscoverRequestV2(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -64,11 +74,14 @@ func DecodeClientStandardDiscoverRequestV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverRequestV2Type {
 		return nil, 0, 0, "", "", errors.New("message type mismatch")
 	}
-	msg := clientStandardDiscoverRequestV2{}
+	msg := ClientStandardDiscoverRequestV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.TTL, msg.PaychAddr, msg.Voucher, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// clientStandardDiscoverResponse is the response to clientStandardDiscoverRequest
-type clientStandardDiscoverResponse struct {
+// ClientStandardDiscoverResponse is the response to ClientStandardDiscoverRequest
+type ClientStandardDiscoverResponse struct {
 	PieceCID             string                 `json:"piece_cid"`
 	Nonce                int64                  `json:"nonce"`
 	Found                bool                   `json:"found"`
@@ -32,7 +32,22 @@ type clientStandardDiscoverResponse struct {
 	FundedPaymentChannel []bool                 `json:"funded_payment_channel"`
 }
 
-// EncodeClientStandardDiscoverResponse is used to get the FCRMessage of clientStandardDiscoverResponse
+// MessageType returns the message type of ClientStandardDiscoverResponse
+func (ClientStandardDiscoverResponse) MessageType() int32 {
+	return ClientStandardDiscoverResponseType
+}
+
+// Validate checks the fields of ClientStandardDiscoverResponse
+func (m ClientStandardDiscoverResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offers", len(m.SubCIDOffers)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeClientStandardDiscoverResponse is used to get the FCRMessage of ClientStandardDiscoverResponse
 func EncodeClientStandardDiscoverResponse(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -40,20 +55,16 @@ func EncodeClientStandardDiscoverResponse(
 	offers []cidoffer.SubCIDOffer,
 	fundedPaymentChannel []bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverResponse{
+	return Encode(ClientStandardDiscoverResponse{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
 		SubCIDOffers:         offers,
 		FundedPaymentChannel: fundedPaymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverResponseType, body), nil
 }
 
-// DecodeClientStandardDiscoverResponse is used to get the fields from FCRMessage of clientStandardDiscoverResponse
+// DecodeClientStandardDiscoverResponse is used to get the fields from FCRMessage of ClientStandardDiscoverResponse
 func DecodeClientStandardDiscoverResponse(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -65,11 +76,14 @@ func DecodeClientStandardDiscoverResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverResponseType {
 		return nil, 0, false, nil, nil, errors.New("message type mismatch")
 	}
-	msg := clientStandardDiscoverResponse{}
+	msg := ClientStandardDiscoverResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOffers, msg.FundedPaymentChannel, nil
 }
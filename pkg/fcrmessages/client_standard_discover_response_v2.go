@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// clientStandardDiscoverResponseV2 is the response to clientStandardDiscoverRequest with sub cid offer digests
-type clientStandardDiscoverResponseV2 struct {
+// ClientStandardDiscoverResponseV2 is the response to ClientStandardDiscoverRequest with sub cid offer digests
+type ClientStandardDiscoverResponseV2 struct {
 	PieceCID             string                              `json:"piece_cid"`
 	Nonce                int64                               `json:"nonce"`
 	Found                bool                                `json:"found"`
@@ -34,7 +34,22 @@ type clientStandardDiscoverResponseV2 struct {
 	PaymentChannel       int64                               `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
-// EncodeClientStandardDiscoverResponseV2 is used to get the FCRMessage of clientStandardDiscoverResponseV2
+// MessageType returns the message type of ClientStandardDiscoverResponseV2
+func (ClientStandardDiscoverResponseV2) MessageType() int32 {
+	return ClientStandardDiscoverResponseV2Type
+}
+
+// Validate checks the fields of ClientStandardDiscoverResponseV2
+func (m ClientStandardDiscoverResponseV2) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offer_digests", len(m.SubCIDOfferDigests)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeClientStandardDiscoverResponseV2 is used to get the FCRMessage of ClientStandardDiscoverResponseV2
 func EncodeClientStandardDiscoverResponseV2(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -44,7 +59,7 @@ func EncodeClientStandardDiscoverResponseV2(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(clientStandardDiscoverResponseV2{
+	return Encode(ClientStandardDiscoverResponseV2{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
@@ -53,13 +68,9 @@ func EncodeClientStandardDiscoverResponseV2(
 		PaymentRequired:      paymentRequired,
 		PaymentChannel:       paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ClientStandardDiscoverResponseV2Type, body), nil
 }
 
-// DecodeClientStandardDiscoverResponseV2 is used to get the fields from FCRMessage of clientStandardDiscoverResponseV2
+// DecodeClientStandardDiscoverResponseV2 is used to get the fields from FCRMessage of ClientStandardDiscoverResponseV2
 func DecodeClientStandardDiscoverResponseV2(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -73,11 +84,14 @@ func DecodeClientStandardDiscoverResponseV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ClientStandardDiscoverResponseV2Type {
 		return nil, 0, false, nil, nil, false, 0, fmt.Errorf("message type mismatch, expected type ID: %d, actual type ID: %d", ClientStandardDiscoverResponseV2Type, fcrMsg.GetMessageType())
 	}
-	msg := clientStandardDiscoverResponseV2{}
+	msg := ClientStandardDiscoverResponseV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, false, 0, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, false, 0, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOfferDigests, msg.FundedPaymentChannel, msg.PaymentRequired, msg.PaymentChannel, nil
 }
@@ -0,0 +1,194 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Codec identifies the wire encoding of a FCRMessage.
+type Codec int32
+
+// Supported codecs.
+const (
+	// CodecJSON is the original json encoding, the message body is base64 encoded inside a json object.
+	CodecJSON Codec = 0
+	// CodecBinary is a length-prefixed binary encoding, the message body is carried as raw bytes.
+	CodecBinary Codec = 1
+)
+
+// Binary codec framing.
+//
+// Version 1 layout, all integers are big-endian:
+//
+//	magic              1 byte  (0xFC)
+//	codec version      1 byte  (0x01)
+//	message type       4 bytes (int32)
+//	protocol version   4 bytes (int32)
+//	supported count    2 bytes (uint16), followed by count * 4 bytes (int32)
+//	body length        4 bytes (uint32), followed by body
+//	signature length   2 bytes (uint16), followed by signature
+const (
+	binaryCodecMagic   byte = 0xFC
+	binaryCodecVersion byte = 0x01
+)
+
+// String returns the name of the codec.
+func (c Codec) String() string {
+	switch c {
+	case CodecJSON:
+		return "json"
+	case CodecBinary:
+		return "binary"
+	default:
+		return fmt.Sprintf("unknown(%d)", int32(c))
+	}
+}
+
+// IsSupported checks if the codec is known to this implementation.
+func (c Codec) IsSupported() bool {
+	return c == CodecJSON || c == CodecBinary
+}
+
+// FCRMsgToBytesWithCodec converts a FCRMessage to bytes using the given codec.
+func (fcrMsg *FCRMessage) FCRMsgToBytesWithCodec(codec Codec) ([]byte, error) {
+	switch codec {
+	case CodecJSON:
+		return json.Marshal(fcrMsg)
+	case CodecBinary:
+		return fcrMsg.marshalBinary()
+	default:
+		return nil, fmt.Errorf("unsupported codec: %s", codec)
+	}
+}
+
+// DetectCodec returns the codec used to encode the given bytes.
+func DetectCodec(data []byte) Codec {
+	if len(data) > 0 && data[0] == binaryCodecMagic {
+		return CodecBinary
+	}
+	return CodecJSON
+}
+
+// marshalBinary encodes the message using the binary codec.
+func (fcrMsg *FCRMessage) marshalBinary() ([]byte, error) {
+	if len(fcrMsg.protocolSupported) > math.MaxUint16 {
+		return nil, errors.New("too many supported protocol versions")
+	}
+	if len(fcrMsg.signature) > math.MaxUint16 {
+		return nil, errors.New("signature too long")
+	}
+	if uint64(len(fcrMsg.messageBody)) > math.MaxUint32 {
+		return nil, errors.New("message body too long")
+	}
+	size := 2 + 4 + 4 + 2 + 4*len(fcrMsg.protocolSupported) + 4 + len(fcrMsg.messageBody) + 2 + len(fcrMsg.signature)
+	res := make([]byte, 0, size)
+	res = append(res, binaryCodecMagic, binaryCodecVersion)
+	res = appendUint32(res, uint32(fcrMsg.messageType))
+	res = appendUint32(res, uint32(fcrMsg.protocolVersion))
+	res = appendUint16(res, uint16(len(fcrMsg.protocolSupported)))
+	for _, ver := range fcrMsg.protocolSupported {
+		res = appendUint32(res, uint32(ver))
+	}
+	res = appendUint32(res, uint32(len(fcrMsg.messageBody)))
+	res = append(res, fcrMsg.messageBody...)
+	res = appendUint16(res, uint16(len(fcrMsg.signature)))
+	res = append(res, fcrMsg.signature...)
+	return res, nil
+}
+
+// unmarshalBinary decodes the message using the binary codec.
+func (fcrMsg *FCRMessage) unmarshalBinary(data []byte) error {
+	r := binaryReader{data: data}
+	magic := r.bytes(1)
+	ver := r.bytes(1)
+	if r.err != nil || magic[0] != binaryCodecMagic {
+		return errors.New("binary codec: invalid magic")
+	}
+	if ver[0] != binaryCodecVersion {
+		return fmt.Errorf("binary codec: unsupported version %d", ver[0])
+	}
+	msgType := int32(r.uint32())
+	protoVer := int32(r.uint32())
+	var supported []int32
+	if count := int(r.uint16()); count > 0 && r.err == nil {
+		supported = make([]int32, 0, count)
+		for i := 0; i < count && r.err == nil; i++ {
+			supported = append(supported, int32(r.uint32()))
+		}
+	}
+	body := r.bytes(int(r.uint32()))
+	sig := r.bytes(int(r.uint16()))
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) != 0 {
+		return errors.New("binary codec: trailing bytes")
+	}
+	fcrMsg.messageType = msgType
+	fcrMsg.protocolVersion = protoVer
+	fcrMsg.protocolSupported = supported
+	fcrMsg.messageBody = append([]byte(nil), body...)
+	fcrMsg.signature = string(sig)
+	return nil
+}
+
+// binaryReader reads fields from a byte slice, remembering the first error.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data) < n {
+		r.err = errors.New("binary codec: message truncated")
+		return nil
+	}
+	res := r.data[:n]
+	r.data = r.data[n:]
+	return res
+}
+
+func (r *binaryReader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *binaryReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,72 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBinaryCodecRoundTrip success test
+func TestBinaryCodecRoundTrip(t *testing.T) {
+	msg := &FCRMessage{
+		messageType:       203,
+		protocolVersion:   1,
+		protocolSupported: []int32{1, 1},
+		messageBody:       []byte(`{"nonce":42}`),
+		signature:         "00000001faa9",
+	}
+	data, err := msg.FCRMsgToBytesWithCodec(CodecBinary)
+	assert.Empty(t, err)
+	assert.Equal(t, CodecBinary, DetectCodec(data))
+
+	res, err := FCRMsgFromBytes(data)
+	assert.Empty(t, err)
+	assert.Equal(t, msg, res)
+
+	jsonData, err := msg.FCRMsgToBytes()
+	assert.Empty(t, err)
+	assert.Equal(t, CodecJSON, DetectCodec(jsonData))
+	assert.Less(t, len(data), len(jsonData))
+
+	res, err = FCRMsgFromBytes(jsonData)
+	assert.Empty(t, err)
+	assert.Equal(t, msg, res)
+}
+
+// TestBinaryCodecInvalid error test
+func TestBinaryCodecInvalid(t *testing.T) {
+	msg := CreateFCRMessage(203, []byte(`{}`))
+	data, err := msg.FCRMsgToBytesWithCodec(CodecBinary)
+	assert.Empty(t, err)
+
+	_, err = FCRMsgFromBytes(data[:len(data)-1])
+	assert.NotEmpty(t, err)
+	_, err = FCRMsgFromBytes(append(data, 0))
+	assert.NotEmpty(t, err)
+	data[1] = 2
+	_, err = FCRMsgFromBytes(data)
+	assert.NotEmpty(t, err)
+	_, err = FCRMsgFromBytes([]byte{binaryCodecMagic})
+	assert.NotEmpty(t, err)
+
+	_, err = msg.FCRMsgToBytesWithCodec(Codec(42))
+	assert.NotEmpty(t, err)
+}
+
+// TestProtocolChangeWithCodec success test
+func TestProtocolChangeWithCodec(t *testing.T) {
+	msg, err := EncodeProtocolChangeRequestWithCodec(1, CodecBinary)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`{"desired_version":1,"desired_codec":1}`), msg.GetMessageBody())
+	version, codec, err := DecodeProtocolChangeRequestWithCodec(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, int32(1), version)
+	assert.Equal(t, CodecBinary, codec)
+
+	msg, err = EncodeProtocolChangeResponseWithCodec(true, CodecBinary)
+	assert.Empty(t, err)
+	success, codec, err := DecodeProtocolChangeResponseWithCodec(msg)
+	assert.Empty(t, err)
+	assert.True(t, success)
+	assert.Equal(t, CodecBinary, codec)
+}
@@ -102,9 +102,12 @@ func (fcrMsg *FCRMessage) Sign(privKey *fcrcrypto.KeyPair, keyVer *fcrcrypto.Key
 
 // Verify is used to verify the offer with a given public key.
 func (fcrMsg *FCRMessage) Verify(pubKey *fcrcrypto.KeyPair) error {
-	// Clear signature
+	// Clear signature, recover it on exit
 	sig := fcrMsg.signature
 	fcrMsg.signature = ""
+	defer func() {
+		fcrMsg.signature = sig
+	}()
 	raw, err := fcrMsg.MarshalToSign()
 	if err != nil {
 		return err
@@ -116,20 +119,23 @@ func (fcrMsg *FCRMessage) Verify(pubKey *fcrcrypto.KeyPair) error {
 	if !res {
 		return errors.New("message does not pass signature verification")
 	}
-	// Recover signature
-	fcrMsg.signature = sig
 	return nil
 }
 
-// FCRMsgToBytes converts a FCRMessage to bytes
+// FCRMsgToBytes converts a FCRMessage to bytes using the json codec
 func (fcrMsg *FCRMessage) FCRMsgToBytes() ([]byte, error) {
-	return json.Marshal(fcrMsg)
+	return fcrMsg.FCRMsgToBytesWithCodec(CodecJSON)
 }
 
-// FCRMsgFromBytes converts a bytes to FCRMessage
+// FCRMsgFromBytes converts a bytes to FCRMessage, the codec is detected automatically
 func FCRMsgFromBytes(data []byte) (*FCRMessage, error) {
 	res := FCRMessage{}
-	err := json.Unmarshal(data, &res)
+	var err error
+	if DetectCodec(data) == CodecBinary {
+		err = res.unmarshalBinary(data)
+	} else {
+		err = json.Unmarshal(data, &res)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -162,15 +168,10 @@ func (fcrMsg *FCRMessage) UnmarshalJSON(p []byte) error {
 	return nil
 }
 
-// MarshalJSON is used to marshal offer into bytes to sign the message.
+// MarshalToSign is used to marshal the message into the canonical bytes to sign, see signingPayload.
 // This not includes the signature field
 func (fcrMsg FCRMessage) MarshalToSign() ([]byte, error) {
-	return json.Marshal(fcrMessageJson{
-		MessageType:       fcrMsg.messageType,
-		ProtocolVersion:   fcrMsg.protocolVersion,
-		ProtocolSupported: fcrMsg.protocolSupported,
-		MessageBody:       fcrMsg.messageBody,
-	})
+	return fcrMsg.signingPayload()
 }
 
 // GetCurrentProtocolVersion gets the current protocol version of all messages.
@@ -47,7 +47,7 @@ func TestSign(t *testing.T) {
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"gateway_id":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEI=","piece_cid":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE=","nonce":42,"ttl":43,"payment_channel_address":"t2twbvr2oaxqzyktxqqjrv37bh7gzfhuqonfioayq","voucher":"i1UCnYNY6cC8M4VO8IJjXfwn-"}`),
-		signature:         "00000001faa9487c9e61d6ba0d05df6bca3ddcbc9f67abe62f4646630c6cea4012058cb61e66f4f65c72b0f2f8f0dffb66797427f178209c1a9491dc7b84fc3bc798e75801",
+		signature:         "000000014aacd8948e97ecaac038519fa6018c5eeb9db895c1f3aecd93d9283611e6dc383e1d109e7104fb49fc69b1b0837c27ce070d0b9b43924c382d8f1fd7a463de9a01",
 	}
 
 	msg := CreateFCRMessage(mockMsgType, mockMsgBody)
@@ -69,7 +69,7 @@ func TestVerify(t *testing.T) {
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"gateway_id":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEI=","piece_cid":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE=","nonce":42,"ttl":43,"payment_channel_address":"t2twbvr2oaxqzyktxqqjrv37bh7gzfhuqonfioayq","voucher":"i1UCnYNY6cC8M4VO8IJjXfwn-"}`),
-		signature:         "00000001faa9487c9e61d6ba0d05df6bca3ddcbc9f67abe62f4646630c6cea4012058cb61e66f4f65c72b0f2f8f0dffb66797427f178209c1a9491dc7b84fc3bc798e75801",
+		signature:         "000000014aacd8948e97ecaac038519fa6018c5eeb9db895c1f3aecd93d9283611e6dc383e1d109e7104fb49fc69b1b0837c27ce070d0b9b43924c382d8f1fd7a463de9a01",
 	}
 
 	msg := CreateFCRMessage(mockMsgType, mockMsgBody)
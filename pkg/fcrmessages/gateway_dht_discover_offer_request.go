@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// gatewayDHTDiscoverOfferRequest is the request to implement the payment system
-type gatewayDHTDiscoverOfferRequest struct {
+// GatewayDHTDiscoverOfferRequest is the request to implement the payment system
+type GatewayDHTDiscoverOfferRequest struct {
 	PieceCID     string                              `json:"piece_cid"`
 	Nonce        int64                               `json:"nonce"`
 	OfferDigests [][cidoffer.CIDOfferDigestSize]byte `json:"offer_digests"`
@@ -32,7 +32,21 @@ type gatewayDHTDiscoverOfferRequest struct {
 	Voucher      string                              `json:"voucher"`
 }
 
-// EncodeGatewayDHTDiscoverOfferRequest is used to get the FCRMessage of gatewayDHTDiscoverOfferRequest
+// MessageType returns the message type of GatewayDHTDiscoverOfferRequest
+func (GatewayDHTDiscoverOfferRequest) MessageType() int32 {
+	return GatewayDHTDiscoverOfferRequestType
+}
+
+// Validate checks the fields of GatewayDHTDiscoverOfferRequest
+func (m GatewayDHTDiscoverOfferRequest) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("offer_digests", len(m.OfferDigests)),
+	)
+}
+
+// EncodeGatewayDHTDiscoverOfferRequest is used to get the FCRMessage of GatewayDHTDiscoverOfferRequest
 func EncodeGatewayDHTDiscoverOfferRequest(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -40,20 +54,16 @@ func EncodeGatewayDHTDiscoverOfferRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverOfferRequest{
+	return Encode(GatewayDHTDiscoverOfferRequest{
 		PieceCID:     pieceCID.ToString(),
 		Nonce:        nonce,
 		OfferDigests: offerDigests,
 		PaychAddr:    paychAddr,
 		Voucher:      voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverOfferRequestType, body), nil
 }
 
-// DecodeGatewayDHTDiscoverOfferRequest is used to get the fields from FCRMessage of gatewayDHTDiscoverOfferRequest
+// DecodeGatewayDHTDiscoverOfferRequest is used to get the fields from FCRMessage of GatewayDHTDiscoverOfferRequest
 func DecodeGatewayDHTDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	*cid.ContentID, // piece cid
 	int64, // nonce
@@ -65,11 +75,14 @@ func DecodeGatewayDHTDiscoverOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverOfferRequestType {
 		return nil, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverOfferRequest{}
+	msg := GatewayDHTDiscoverOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, [][cidoffer.CIDOfferDigestSize]byte{}, "", "", err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.OfferDigests, msg.PaychAddr, msg.Voucher, nil
 }
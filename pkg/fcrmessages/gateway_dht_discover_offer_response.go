@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// gatewayDHTDiscoverOfferResponse is the response to gatewayDHTDiscoverRequest
-type gatewayDHTDiscoverOfferResponse struct {
+// GatewayDHTDiscoverOfferResponse is the response to GatewayDHTDiscoverRequest
+type GatewayDHTDiscoverOfferResponse struct {
 	PieceCID             string                 `json:"piece_cid"`
 	Nonce                int64                  `json:"nonce"`
 	Found                bool                   `json:"found"`
@@ -34,7 +34,22 @@ type gatewayDHTDiscoverOfferResponse struct {
 	PaymentChannel       int64                  `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
-// EncodeGatewayDHTDiscoverOfferResponse is used to get the FCRMessage of gatewayDHTDiscoverOfferResponse
+// MessageType returns the message type of GatewayDHTDiscoverOfferResponse
+func (GatewayDHTDiscoverOfferResponse) MessageType() int32 {
+	return GatewayDHTDiscoverOfferResponseType
+}
+
+// Validate checks the fields of GatewayDHTDiscoverOfferResponse
+func (m GatewayDHTDiscoverOfferResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offers", len(m.SubCIDOffers)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeGatewayDHTDiscoverOfferResponse is used to get the FCRMessage of GatewayDHTDiscoverOfferResponse
 func EncodeGatewayDHTDiscoverOfferResponse(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -44,7 +59,7 @@ func EncodeGatewayDHTDiscoverOfferResponse(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverOfferResponse{
+	return Encode(GatewayDHTDiscoverOfferResponse{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
@@ -53,10 +68,6 @@ func EncodeGatewayDHTDiscoverOfferResponse(
 		PaymentRequired:      paymentRequired,
 		PaymentChannel:       paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverOfferResponseType, body), nil
 }
 
 // DecodeGatewayDHTDiscoverOfferResponse is used to get the fields from FCRMessage of GatewayDHTDiscoverOfferResponse
@@ -73,11 +84,14 @@ func DecodeGatewayDHTDiscoverOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverOfferResponseType {
 		return nil, 0, false, nil, nil, false, 0, errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverOfferResponse{}
+	msg := GatewayDHTDiscoverOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, false, 0, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, false, 0, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOffers, msg.FundedPaymentChannel, msg.PaymentRequired, msg.PaymentChannel, nil
 }
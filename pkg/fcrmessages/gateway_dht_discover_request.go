@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayDHTDiscoverRequest is the request from gateway to gateway to discover cid offer
-type gatewayDHTDiscoverRequest struct {
+// GatewayDHTDiscoverRequest is the request from gateway to gateway to discover cid offer
+type GatewayDHTDiscoverRequest struct {
 	GatewayID string `json:"gateway_id"`
 	PieceCID  string `json:"piece_cid"`
 	Nonce     int64  `json:"nonce"`
@@ -33,7 +33,22 @@ type gatewayDHTDiscoverRequest struct {
 	Voucher   string `json:"voucher"`
 }
 
-// EncodeGatewayDHTDiscoverRequest is used to get the FCRMessage of gatewayDHTDiscoverRequest
+// MessageType returns the message type of GatewayDHTDiscoverRequest
+func (GatewayDHTDiscoverRequest) MessageType() int32 {
+	return GatewayDHTDiscoverRequestType
+}
+
+// Validate checks the fields of GatewayDHTDiscoverRequest
+func (m GatewayDHTDiscoverRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeGatewayDHTDiscoverRequest is used to get the FCRMessage of GatewayDHTDiscoverRequest
 func EncodeGatewayDHTDiscoverRequest(
 	gatewayID *nodeid.NodeID,
 	pieceCID *cid.ContentID,
@@ -42,7 +57,7 @@ func EncodeGatewayDHTDiscoverRequest(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverRequest{
+	return Encode(GatewayDHTDiscoverRequest{
 		GatewayID: gatewayID.ToString(),
 		PieceCID:  pieceCID.ToString(),
 		Nonce:     nonce,
@@ -50,10 +65,6 @@ func EncodeGatewayDHTDiscoverRequest(
 		PaychAddr: paychAddr,
 		Voucher:   voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverRequestType, body), nil
 }
 
 // DecodeGatewayDHTDiscoverRequest is used to get the fields from FCRMessage of GatewayDHTDiscoverRequest
@@ -69,12 +80,15 @@ func DecodeGatewayDHTDiscoverRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverRequestType {
 		return nil, nil, 0, 0, "", "", errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverRequest{}
+	msg := GatewayDHTDiscoverRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, 0, 0, "", "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, 0, 0, "", "", err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return nodeID, contentID, msg.Nonce, msg.TTL, msg.PaychAddr, msg.Voucher, nil
 }
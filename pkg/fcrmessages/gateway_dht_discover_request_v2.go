@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayDHTDiscoverRequestV2 is the request from gateway to gateway to discover cid offer
-type gatewayDHTDiscoverRequestV2 struct {
+// GatewayDHTDiscoverRequestV2 is the request from gateway to gateway to discover cid offer
+type GatewayDHTDiscoverRequestV2 struct {
 	GatewayID string `json:"gateway_id"`
 	PieceCID  string `json:"piece_cid"`
 	Nonce     int64  `json:"nonce"`
@@ -33,7 +33,22 @@ type gatewayDHTDiscoverRequestV2 struct {
 	Voucher   string `json:"voucher"`
 }
 
-// EncodeGatewayDHTDiscoverRequestV2 is used to get the FCRMessage of gatewayDHTDiscoverRequestV2
+// MessageType returns the message type of GatewayDHTDiscoverRequestV2
+func (GatewayDHTDiscoverRequestV2) MessageType() int32 {
+	return GatewayDHTDiscoverRequestV2Type
+}
+
+// Validate checks the fields of GatewayDHTDiscoverRequestV2
+func (m GatewayDHTDiscoverRequestV2) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeGatewayDHTDiscoverRequestV2 is used to get the FCRMessage of GatewayDHTDiscoverRequestV2
 func EncodeGatewayDHTDiscoverRequestV2(
 	gatewayID *nodeid.NodeID,
 	pieceCID *cid.ContentID,
@@ -42,7 +57,7 @@ func EncodeGatewayDHTDiscoverRequestV2(
 	paychAddr string,
 	voucher string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverRequestV2{
+	return Encode(GatewayDHTDiscoverRequestV2{
 		GatewayID: gatewayID.ToString(),
 		PieceCID:  pieceCID.ToString(),
 		Nonce:     nonce,
@@ -50,10 +65,6 @@ func EncodeGatewayDHTDiscoverRequestV2(
 		PaychAddr: paychAddr,
 		Voucher:   voucher,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverRequestV2Type, body), nil
 }
 
 // DecodeGatewayDHTDiscoverRequestV2 is used to get the fields from FCRMessage of GatewayDHTDiscoverRequestV2
@@ -69,12 +80,15 @@ func DecodeGatewayDHTDiscoverRequestV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverRequestV2Type {
 		return nil, nil, 0, 0, "", "", errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverRequestV2{}
+	msg := GatewayDHTDiscoverRequestV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, 0, 0, "", "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, 0, 0, "", "", err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return nodeID, contentID, msg.Nonce, msg.TTL, msg.PaychAddr, msg.Voucher, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// gatewayDHTDiscoverResponse is the response to gatewayDHTDiscoverRequest
-type gatewayDHTDiscoverResponse struct {
+// GatewayDHTDiscoverResponse is the response to GatewayDHTDiscoverRequest
+type GatewayDHTDiscoverResponse struct {
 	PieceCID             string                 `json:"piece_cid"`
 	Nonce                int64                  `json:"nonce"`
 	Found                bool                   `json:"found"`
@@ -32,7 +32,22 @@ type gatewayDHTDiscoverResponse struct {
 	FundedPaymentChannel []bool                 `json:"funded_payment_channel"`
 }
 
-// EncodeGatewayDHTDiscoverResponse is used to get the FCRMessage of gatewayDHTDiscoverResponse
+// MessageType returns the message type of GatewayDHTDiscoverResponse
+func (GatewayDHTDiscoverResponse) MessageType() int32 {
+	return GatewayDHTDiscoverResponseType
+}
+
+// Validate checks the fields of GatewayDHTDiscoverResponse
+func (m GatewayDHTDiscoverResponse) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offers", len(m.SubCIDOffers)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeGatewayDHTDiscoverResponse is used to get the FCRMessage of GatewayDHTDiscoverResponse
 func EncodeGatewayDHTDiscoverResponse(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -40,17 +55,13 @@ func EncodeGatewayDHTDiscoverResponse(
 	offers []cidoffer.SubCIDOffer,
 	fundedPaymentChannel []bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverResponse{
+	return Encode(GatewayDHTDiscoverResponse{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
 		SubCIDOffers:         offers,
 		FundedPaymentChannel: fundedPaymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverResponseType, body), nil
 }
 
 // DecodeGatewayDHTDiscoverResponse is used to get the fields from FCRMessage of GatewayDHTDiscoverResponse
@@ -65,11 +76,14 @@ func DecodeGatewayDHTDiscoverResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverResponseType {
 		return nil, 0, false, nil, nil, errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverResponse{}
+	msg := GatewayDHTDiscoverResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOffers, msg.FundedPaymentChannel, nil
 }
@@ -23,8 +23,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// gatewayDHTDiscoverResponseV2 is the response to gatewayDHTDiscoverRequest
-type gatewayDHTDiscoverResponseV2 struct {
+// GatewayDHTDiscoverResponseV2 is the response to GatewayDHTDiscoverRequest
+type GatewayDHTDiscoverResponseV2 struct {
 	PieceCID             string                              `json:"piece_cid"`
 	Nonce                int64                               `json:"nonce"`
 	Found                bool                                `json:"found"`
@@ -34,7 +34,22 @@ type gatewayDHTDiscoverResponseV2 struct {
 	PaymentChannel       int64                               `json:"payment_channel"`  // payment channel address used in conjunction with PaymentRequired field
 }
 
-// EncodeGatewayDHTDiscoverResponseV2 is used to get the FCRMessage of gatewayDHTDiscoverResponseV2
+// MessageType returns the message type of GatewayDHTDiscoverResponseV2
+func (GatewayDHTDiscoverResponseV2) MessageType() int32 {
+	return GatewayDHTDiscoverResponseV2Type
+}
+
+// Validate checks the fields of GatewayDHTDiscoverResponseV2
+func (m GatewayDHTDiscoverResponseV2) Validate() error {
+	return firstError(
+		validateCID("piece_cid", m.PieceCID),
+		validateNonNegative("nonce", m.Nonce),
+		validateLength("sub_cid_offer_digest", len(m.SubCIDOfferDigests)),
+		validateLength("funded_payment_channel", len(m.FundedPaymentChannel)),
+	)
+}
+
+// EncodeGatewayDHTDiscoverResponseV2 is used to get the FCRMessage of GatewayDHTDiscoverResponseV2
 func EncodeGatewayDHTDiscoverResponseV2(
 	pieceCID *cid.ContentID,
 	nonce int64,
@@ -44,7 +59,7 @@ func EncodeGatewayDHTDiscoverResponseV2(
 	paymentRequired bool,
 	paymentChannel int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayDHTDiscoverResponseV2{
+	return Encode(GatewayDHTDiscoverResponseV2{
 		PieceCID:             pieceCID.ToString(),
 		Nonce:                nonce,
 		Found:                found,
@@ -53,10 +68,6 @@ func EncodeGatewayDHTDiscoverResponseV2(
 		PaymentRequired:      paymentRequired,
 		PaymentChannel:       paymentChannel,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayDHTDiscoverResponseV2Type, body), nil
 }
 
 // DecodeGatewayDHTDiscoverResponseV2 is used to get the fields from FCRMessage of GatewayDHTDiscoverResponseV2
@@ -73,11 +84,14 @@ func DecodeGatewayDHTDiscoverResponseV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayDHTDiscoverResponseV2Type {
 		return nil, 0, false, nil, nil, false, 0, errors.New("message type mismatch")
 	}
-	msg := gatewayDHTDiscoverResponseV2{}
+	msg := GatewayDHTDiscoverResponseV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, nil, nil, false, 0, err
 	}
-	contentID, _ := cid.NewContentIDFromHexString(msg.PieceCID)
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, nil, nil, false, 0, err
+	}
+	contentID, _ := cid.NewContentIDFromString(msg.PieceCID)
 	return contentID, msg.Nonce, msg.Found, msg.SubCIDOfferDigests, msg.FundedPaymentChannel, msg.PaymentRequired, msg.PaymentChannel, nil
 }
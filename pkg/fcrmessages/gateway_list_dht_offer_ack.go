@@ -20,25 +20,33 @@ import (
 	"errors"
 )
 
-// gatewayListDHTOfferAck is the acknowledgement to gatewayListDHTOfferResponse
-type gatewayListDHTOfferAck struct {
+// GatewayListDHTOfferAck is the acknowledgement to GatewayListDHTOfferResponse
+type GatewayListDHTOfferAck struct {
 	PublishedDHTOffersAck []FCRMessage `json:"published_dht_offers_ack"`
 }
 
-// EncodeGatewayListDHTOfferAck is used to get the FCRMessage of gatewayListDHTOfferAck
+// MessageType returns the message type of GatewayListDHTOfferAck
+func (GatewayListDHTOfferAck) MessageType() int32 {
+	return GatewayListDHTOfferAckType
+}
+
+// Validate checks the fields of GatewayListDHTOfferAck
+func (m GatewayListDHTOfferAck) Validate() error {
+	return firstError(
+		validateLength("published_dht_offers_ack", len(m.PublishedDHTOffersAck)),
+	)
+}
+
+// EncodeGatewayListDHTOfferAck is used to get the FCRMessage of GatewayListDHTOfferAck
 func EncodeGatewayListDHTOfferAck(
 	publishedDHTOffersAck []FCRMessage,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayListDHTOfferAck{
+	return Encode(GatewayListDHTOfferAck{
 		PublishedDHTOffersAck: publishedDHTOffersAck,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayListDHTOfferAckType, body), nil
 }
 
-// DecodeGatewayListDHTOfferAck is used to get the fields from FCRMessage of gatewayListDHTOfferAck
+// DecodeGatewayListDHTOfferAck is used to get the fields from FCRMessage of GatewayListDHTOfferAck
 func DecodeGatewayListDHTOfferAck(fcrMsg *FCRMessage) (
 	[]FCRMessage, // published dht cid offers ack
 	error, // error
@@ -46,10 +54,13 @@ func DecodeGatewayListDHTOfferAck(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayListDHTOfferAckType {
 		return nil, errors.New("message type mismatch")
 	}
-	msg := gatewayListDHTOfferAck{}
+	msg := GatewayListDHTOfferAck{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, err
+	}
 	return msg.PublishedDHTOffersAck, nil
 }
@@ -24,8 +24,8 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayListDHTOfferRequest is the request from gateway to provider during start-up asking for dht offers
-type gatewayListDHTOfferRequest struct {
+// GatewayListDHTOfferRequest is the request from gateway to provider during start-up asking for dht offers
+type GatewayListDHTOfferRequest struct {
 	GatewayID          string                       `json:"gateway_id"`
 	CIDMin             string                       `json:"cid_min"`
 	CIDMax             string                       `json:"cid_max"`
@@ -35,7 +35,21 @@ type gatewayListDHTOfferRequest struct {
 	MerkleProof        fcrmerkletree.FCRMerkleProof `json:"merkle_proof"`
 }
 
-// EncodeGatewayListDHTOfferRequest is used to get the FCRMessage of gatewayListDHTOfferRequest
+// MessageType returns the message type of GatewayListDHTOfferRequest
+func (GatewayListDHTOfferRequest) MessageType() int32 {
+	return GatewayListDHTOfferRequestType
+}
+
+// Validate checks the fields of GatewayListDHTOfferRequest
+func (m GatewayListDHTOfferRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+		validateDHTKey("cid_min", m.CIDMin),
+		validateDHTKey("cid_max", m.CIDMax),
+	)
+}
+
+// EncodeGatewayListDHTOfferRequest is used to get the FCRMessage of GatewayListDHTOfferRequest
 func EncodeGatewayListDHTOfferRequest(
 	gatewayID *nodeid.NodeID,
 	cidMin *cid.ContentID,
@@ -45,22 +59,18 @@ func EncodeGatewayListDHTOfferRequest(
 	merkleRoot string,
 	merkleProof *fcrmerkletree.FCRMerkleProof,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayListDHTOfferRequest{
+	return Encode(GatewayListDHTOfferRequest{
 		GatewayID:          gatewayID.ToString(),
-		CIDMin:             cidMin.ToString(),
-		CIDMax:             cidMax.ToString(),
+		CIDMin:             cidMin.ToDHTKey(),
+		CIDMax:             cidMax.ToDHTKey(),
 		BlockHash:          blockHash,
 		TransactionReceipt: transactionReceipt,
 		MerkleRoot:         merkleRoot,
 		MerkleProof:        *merkleProof,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayListDHTOfferRequestType, body), nil
 }
 
-// DecodeGatewayListDHTOfferRequest is used to get the fields from FCRMessage of gatewayListDHTOfferRequest
+// DecodeGatewayListDHTOfferRequest is used to get the fields from FCRMessage of GatewayListDHTOfferRequest
 func DecodeGatewayListDHTOfferRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // gatewayID
 	*cid.ContentID, // cid min
@@ -74,11 +84,14 @@ func DecodeGatewayListDHTOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayListDHTOfferRequestType {
 		return nil, nil, nil, "", "", "", nil, errors.New("message type mismatch")
 	}
-	msg := gatewayListDHTOfferRequest{}
+	msg := GatewayListDHTOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, "", "", "", nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, "", "", "", nil, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	contentIDMin, _ := cid.NewContentIDFromHexString(msg.CIDMin)
 	contentIDMax, _ := cid.NewContentIDFromHexString(msg.CIDMax)
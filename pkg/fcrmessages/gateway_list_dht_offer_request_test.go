@@ -3,7 +3,6 @@ package fcrmessages
 import (
 	"testing"
 
-	"github.com/cbergoon/merkletree"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
@@ -20,7 +19,7 @@ func TestEncodeGatewayListDHTOfferRequest(t *testing.T) {
 	mockTransactionReceipt := "bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc"
 
 	mockCids := []cid.ContentID{*mockCidMin}
-	list := make([]merkletree.Content, len(mockCids))
+	list := make([]fcrmerkletree.Content, len(mockCids))
 	for i := 0; i < len(mockCids); i++ {
 		list[i] = (mockCids)[i]
 	}
@@ -32,7 +31,7 @@ func TestEncodeGatewayListDHTOfferRequest(t *testing.T) {
 		messageType:       200,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
-		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","cid_min":"0000000000000000000000000000000000000000000000000000000000000001","cid_max":"0000000000000000000000000000000000000000000000000000000000000002","block_hash":"bafy2bzaceabhr6taytcdntpr4poz43dhf3l5jml6z43e5sdv4gdlgsujsg2ze","transaction_receipt":"bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc","merkle_root":"c3c3a46684c07d12a9c238787df3049a6f258e7af203e5ddb66a8bd66637e108","merkle_proof":"AAAAMFsiQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBRT0iXQAAAANbMV0="}`),
+		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","cid_min":"0000000000000000000000000000000000000000000000000000000000000001","cid_max":"0000000000000000000000000000000000000000000000000000000000000002","block_hash":"bafy2bzaceabhr6taytcdntpr4poz43dhf3l5jml6z43e5sdv4gdlgsujsg2ze","transaction_receipt":"bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc","merkle_root":"1fd4247443c9440cb3c48c28851937196bc156032d70a96c98e127ecb347e45f","merkle_proof":{"version":1,"path":[],"index":[]}}`),
 		signature:         "",
 	}
 
@@ -50,7 +49,7 @@ func TestDecodeGatewayListDHTOfferRequest(t *testing.T) {
 	mockTransactionReceipt := "bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc"
 
 	mockCids := []cid.ContentID{*mockCidMin}
-	list := make([]merkletree.Content, len(mockCids))
+	list := make([]fcrmerkletree.Content, len(mockCids))
 	for i := 0; i < len(mockCids); i++ {
 		list[i] = (mockCids)[i]
 	}
@@ -62,7 +61,7 @@ func TestDecodeGatewayListDHTOfferRequest(t *testing.T) {
 		messageType:       200,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
-		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","cid_min":"0000000000000000000000000000000000000000000000000000000000000001","cid_max":"0000000000000000000000000000000000000000000000000000000000000002","block_hash":"bafy2bzaceabhr6taytcdntpr4poz43dhf3l5jml6z43e5sdv4gdlgsujsg2ze","transaction_receipt":"bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc","merkle_root":"c3c3a46684c07d12a9c238787df3049a6f258e7af203e5ddb66a8bd66637e108","merkle_proof":"AAAAMFsiQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBQUFBRT0iXQAAAANbMV0="}`),
+		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","cid_min":"0000000000000000000000000000000000000000000000000000000000000001","cid_max":"0000000000000000000000000000000000000000000000000000000000000002","block_hash":"bafy2bzaceabhr6taytcdntpr4poz43dhf3l5jml6z43e5sdv4gdlgsujsg2ze","transaction_receipt":"bafy2bzacecz3sy5ar4rg73ri5cq3tndmwn4vnxgzt4bw4cpig7q25af6y5cnc","merkle_root":"1fd4247443c9440cb3c48c28851937196bc156032d70a96c98e127ecb347e45f","merkle_proof":{"version":1,"path":[],"index":[]}}`),
 		signature:         "",
 	}
 
@@ -20,22 +20,30 @@ import (
 	"errors"
 )
 
-// gatewayListDHTOfferResponse is the repsonse to gatewayListDHTOfferRequest
-type gatewayListDHTOfferResponse struct {
+// GatewayListDHTOfferResponse is the repsonse to GatewayListDHTOfferRequest
+type GatewayListDHTOfferResponse struct {
 	PublishedDHTOffers []FCRMessage `json:"published_dht_offers"`
 }
 
+// MessageType returns the message type of GatewayListDHTOfferResponse
+func (GatewayListDHTOfferResponse) MessageType() int32 {
+	return GatewayListDHTOfferResponseType
+}
+
+// Validate checks the fields of GatewayListDHTOfferResponse
+func (m GatewayListDHTOfferResponse) Validate() error {
+	return firstError(
+		validateLength("published_dht_offers", len(m.PublishedDHTOffers)),
+	)
+}
+
 // EncodeGatewayListDHTOfferResponse is used to get the FCRMessage of GatewayListDHTOfferResponse
 func EncodeGatewayListDHTOfferResponse(
 	publishedDHTOffers []FCRMessage,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayListDHTOfferResponse{
+	return Encode(GatewayListDHTOfferResponse{
 		PublishedDHTOffers: publishedDHTOffers,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayListDHTOfferResponseType, body), nil
 }
 
 // DecodeGatewayListDHTOfferResponse is used to get the fields from FCRMessage of GatewayListDHTOfferResponse
@@ -46,10 +54,13 @@ func DecodeGatewayListDHTOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayListDHTOfferResponseType {
 		return nil, errors.New("message type mismatch")
 	}
-	msg := gatewayListDHTOfferResponse{}
+	msg := GatewayListDHTOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, err
+	}
 	return msg.PublishedDHTOffers, nil
 }
@@ -22,23 +22,31 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-type gatewayNotifyProviderGroupCIDOfferSupportRequest struct {
+type GatewayNotifyProviderGroupCIDOfferSupportRequest struct {
 	GatewayID              string `json:"gateway_id"`
 	GroupCIDOfferSupported bool   `json:"group_cid_offer_supported"`
 }
 
+// MessageType returns the message type of GatewayNotifyProviderGroupCIDOfferSupportRequest
+func (GatewayNotifyProviderGroupCIDOfferSupportRequest) MessageType() int32 {
+	return GatewayNotifyProviderGroupCIDOfferSupportedRequestType
+}
+
+// Validate checks the fields of GatewayNotifyProviderGroupCIDOfferSupportRequest
+func (m GatewayNotifyProviderGroupCIDOfferSupportRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
 func EncodeGatewayNotifyProviderGroupCIDOfferSupportRequest(
 	gatewayID *nodeid.NodeID,
 	groupCIDOfferSupported bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayNotifyProviderGroupCIDOfferSupportRequest{
+	return Encode(GatewayNotifyProviderGroupCIDOfferSupportRequest{
 		GatewayID:              gatewayID.ToString(),
 		GroupCIDOfferSupported: groupCIDOfferSupported,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayNotifyProviderGroupCIDOfferSupportedRequestType, body), nil
 }
 
 func DecodeGatewayNotifyProviderGroupCIDOfferSupportRequest(fcrMsg *FCRMessage) (
@@ -49,11 +57,14 @@ func DecodeGatewayNotifyProviderGroupCIDOfferSupportRequest(fcrMsg *FCRMessage)
 	if fcrMsg.GetMessageType() != GatewayNotifyProviderGroupCIDOfferSupportedRequestType {
 		return nil, false, errors.New("message type mismatch")
 	}
-	msg := gatewayNotifyProviderGroupCIDOfferSupportRequest{}
+	msg := GatewayNotifyProviderGroupCIDOfferSupportRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, false, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, false, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return nodeID, msg.GroupCIDOfferSupported, nil
 }
@@ -20,20 +20,21 @@ import (
 	"errors"
 )
 
-type gatewayNotifyProviderGroupCIDOfferSupportResponse struct {
+type GatewayNotifyProviderGroupCIDOfferSupportResponse struct {
 	Acknowledged bool `json:"acknowledged"`
 }
 
+// MessageType returns the message type of GatewayNotifyProviderGroupCIDOfferSupportResponse
+func (GatewayNotifyProviderGroupCIDOfferSupportResponse) MessageType() int32 {
+	return GatewayNotifyProviderGroupCIDOfferSupportedResponseType
+}
+
 func EncodeGatewayNotifyProviderGroupCIDOfferSupportResponse(
 	acknowledged bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayNotifyProviderGroupCIDOfferSupportResponse{
+	return Encode(GatewayNotifyProviderGroupCIDOfferSupportResponse{
 		Acknowledged: acknowledged,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayNotifyProviderGroupCIDOfferSupportedResponseType, body), nil
 }
 
 func DecodeGatewayNotifyProviderGroupCIDOfferSupportResponse(fcrMsg *FCRMessage) (
@@ -43,7 +44,7 @@ func DecodeGatewayNotifyProviderGroupCIDOfferSupportResponse(fcrMsg *FCRMessage)
 	if fcrMsg.GetMessageType() != GatewayNotifyProviderGroupCIDOfferSupportedResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayNotifyProviderGroupCIDOfferSupportResponse{}
+	msg := GatewayNotifyProviderGroupCIDOfferSupportResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
@@ -23,28 +23,37 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayPingRequest is the request from gateway to gateway to check if is alive
-type gatewayPingRequest struct {
+// GatewayPingRequest is the request from gateway to gateway to check if is alive
+type GatewayPingRequest struct {
 	GatewayID string `json:"gateway_id"`
 	Nonce     int64  `json:"nonce"`
 	TTL       int64  `json:"ttl"`
 }
 
-// EncodeGatewayPingRequest is used to get the FCRMessage of gatewayPingRequest
+// MessageType returns the message type of GatewayPingRequest
+func (GatewayPingRequest) MessageType() int32 {
+	return GatewayPingRequestType
+}
+
+// Validate checks the fields of GatewayPingRequest
+func (m GatewayPingRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("ttl", m.TTL),
+	)
+}
+
+// EncodeGatewayPingRequest is used to get the FCRMessage of GatewayPingRequest
 func EncodeGatewayPingRequest(gatewayID *nodeid.NodeID, nonce, ttl int64) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayPingRequest{
+	return Encode(GatewayPingRequest{
 		GatewayID: gatewayID.ToString(),
 		Nonce:     nonce,
 		TTL:       ttl,
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	return CreateFCRMessage(GatewayPingRequestType, body), nil
 }
 
-// DecodeGatewayPingRequest is used to get the fields from FCRMessage of gatewayPingRequest
+// DecodeGatewayPingRequest is used to get the fields from FCRMessage of GatewayPingRequest
 func DecodeGatewayPingRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // gateway id
 	int64, // nonce
@@ -55,12 +64,15 @@ func DecodeGatewayPingRequest(fcrMsg *FCRMessage) (
 		return nil, 0, 0, errors.New("message type mismatch")
 	}
 
-	msg := gatewayPingRequest{}
+	msg := GatewayPingRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 
 	if err != nil {
 		return nil, 0, 0, fmt.Errorf("invalid message: %s", err)
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, err
+	}
 
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return nodeID, msg.Nonce, msg.TTL, nil
@@ -14,7 +14,7 @@ func TestEncodeGatewayPingRequest(t *testing.T) {
 	mockTTL := int64(43)
 
 	validMsg := &FCRMessage{
-		messageType:       205,
+		messageType:       211,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","nonce":42,"ttl":43}`),
@@ -33,7 +33,7 @@ func TestDecodeGatewayPingRequest(t *testing.T) {
 	mockTTL := int64(43)
 
 	validMsg := &FCRMessage{
-		messageType:       205,
+		messageType:       211,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000042","nonce":42,"ttl":43}`),
@@ -68,7 +68,7 @@ func TestDecodeGatewayPingRequestWrongMessageType(t *testing.T) {
 // TestDecodeGatewayPingRequest failure test
 func TestDecodeGatewayPingRequestUnmarshalError(t *testing.T) {
 	validMsg := &FCRMessage{
-		messageType:       205,
+		messageType:       211,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`invalid_message`),
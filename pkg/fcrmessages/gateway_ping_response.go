@@ -20,27 +20,33 @@ import (
 	"errors"
 )
 
-// gatewayPingResponse is the response to gatewayPingRequest
-type gatewayPingResponse struct {
+// GatewayPingResponse is the response to GatewayPingRequest
+type GatewayPingResponse struct {
 	Nonce   int64 `json:"nonce"`
 	IsAlive bool  `json:"isAlive"`
 }
 
-// EncodeGatewayPingResponse is used to get the FCRMessage of gatewayPingResponse
+// MessageType returns the message type of GatewayPingResponse
+func (GatewayPingResponse) MessageType() int32 {
+	return GatewayPingResponseType
+}
+
+// Validate checks the fields of GatewayPingResponse
+func (m GatewayPingResponse) Validate() error {
+	return firstError(
+		validateNonNegative("nonce", m.Nonce),
+	)
+}
+
+// EncodeGatewayPingResponse is used to get the FCRMessage of GatewayPingResponse
 func EncodeGatewayPingResponse(
 	nonce int64,
 	alive bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayPingResponse{
+	return Encode(GatewayPingResponse{
 		Nonce:   nonce,
 		IsAlive: alive,
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return CreateFCRMessage(GatewayPingResponseType, body), nil
 }
 
 // DecodeGatewayPingResponse is used to get the fields from FCRMessage of GatewayPingResponse
@@ -53,12 +59,15 @@ func DecodeGatewayPingResponse(fcrMsg *FCRMessage) (
 		return 0, false, errors.New("message type mismatch")
 	}
 
-	msg := gatewayPingResponse{}
+	msg := GatewayPingResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 
 	if err != nil {
 		return 0, false, err
 	}
+	if err = msg.Validate(); err != nil {
+		return 0, false, err
+	}
 
 	return msg.Nonce, true, nil
 }
@@ -12,7 +12,7 @@ func TestEncodeGatewayPingResponse(t *testing.T) {
 	mockNonce := int64(42)
 
 	validMsg := &FCRMessage{
-		messageType:       206,
+		messageType:       212,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"nonce":42,"isAlive":true}`),
@@ -30,7 +30,7 @@ func TestDecodeGatewayPingResponse(t *testing.T) {
 	mockNonce := int64(42)
 
 	validMsg := &FCRMessage{
-		messageType:       206,
+		messageType:       212,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`{"nonce":42,"isAlive":true}`),
@@ -63,7 +63,7 @@ func TestDecodeGatewayPingResponseWrongMessageType(t *testing.T) {
 // TestDecodeGatewayPingRequest failure test
 func TestDecodeGatewayPingResponseUnmarshalError(t *testing.T) {
 	validMsg := &FCRMessage{
-		messageType:       206,
+		messageType:       212,
 		protocolVersion:   1,
 		protocolSupported: []int32{1, 1},
 		messageBody:       []byte(`invalid_message`),
@@ -20,23 +20,24 @@ import (
 	"errors"
 )
 
-// gatewayAdminForceRefreshRequest is the request from an admin client to a gateway to refresh internal register status
-type gatewayAdminForceRefreshRequest struct {
+// GatewayAdminForceRefreshRequest is the request from an admin client to a gateway to refresh internal register status
+type GatewayAdminForceRefreshRequest struct {
 	Refresh bool `json:"refresh"`
 }
 
-// EncodeGatewayAdminForceRefreshRequest is used to get the FCRMessage of gatewayAdminForceRefreshRequest
+// MessageType returns the message type of GatewayAdminForceRefreshRequest
+func (GatewayAdminForceRefreshRequest) MessageType() int32 {
+	return GatewayAdminForceRefreshRequestType
+}
+
+// EncodeGatewayAdminForceRefreshRequest is used to get the FCRMessage of GatewayAdminForceRefreshRequest
 func EncodeGatewayAdminForceRefreshRequest(refresh bool) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminForceRefreshRequest{
+	return Encode(GatewayAdminForceRefreshRequest{
 		Refresh: refresh,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminForceRefreshRequestType, body), nil
 }
 
-// DecodeGatewayAdminForceRefreshRequest is used to get the fields from FCRMessage of gatewayAdminForceRefreshRequest
+// DecodeGatewayAdminForceRefreshRequest is used to get the fields from FCRMessage of GatewayAdminForceRefreshRequest
 func DecodeGatewayAdminForceRefreshRequest(fcrMsg *FCRMessage) (
 	bool, // refresh
 	error, // error
@@ -44,7 +45,7 @@ func DecodeGatewayAdminForceRefreshRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminForceRefreshRequestType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminForceRefreshRequest{}
+	msg := GatewayAdminForceRefreshRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
@@ -20,25 +20,26 @@ import (
 	"errors"
 )
 
-// gatewayAdminForceRefreshResponse is the response to gatewayAdminForceRefreshRequest
-type gatewayAdminForceRefreshResponse struct {
+// GatewayAdminForceRefreshResponse is the response to GatewayAdminForceRefreshRequest
+type GatewayAdminForceRefreshResponse struct {
 	Refreshed bool `json:"refreshed"`
 }
 
-// EncodeGatewayAdminForceRefreshResponse is used to get the FCRMessage of gatewayAdminForceRefreshResponse
+// MessageType returns the message type of GatewayAdminForceRefreshResponse
+func (GatewayAdminForceRefreshResponse) MessageType() int32 {
+	return GatewayAdminForceRefreshResponseType
+}
+
+// EncodeGatewayAdminForceRefreshResponse is used to get the FCRMessage of GatewayAdminForceRefreshResponse
 func EncodeGatewayAdminForceRefreshResponse(
 	refreshed bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminForceRefreshResponse{
+	return Encode(GatewayAdminForceRefreshResponse{
 		Refreshed: refreshed,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminForceRefreshResponseType, body), nil
 }
 
-// DecodeGatewayAdminForceRefreshResponse is used to get the fields from FCRMessage of gatewayAdminForceRefreshResponse
+// DecodeGatewayAdminForceRefreshResponse is used to get the fields from FCRMessage of GatewayAdminForceRefreshResponse
 func DecodeGatewayAdminForceRefreshResponse(fcrMsg *FCRMessage) (
 	bool, // refreshed
 	error, // error
@@ -46,7 +47,7 @@ func DecodeGatewayAdminForceRefreshResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminForceRefreshResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminForceRefreshResponse{}
+	msg := GatewayAdminForceRefreshResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
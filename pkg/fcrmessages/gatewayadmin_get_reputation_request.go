@@ -22,25 +22,33 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminGetReputationRequest is the request from an admin client to a gateway to discover a client's reputation
-type gatewayAdminGetReputationRequest struct {
+// GatewayAdminGetReputationRequest is the request from an admin client to a gateway to discover a client's reputation
+type GatewayAdminGetReputationRequest struct {
 	ClientID string `json:"client_id"`
 }
 
-// EncodeGatewayAdminGetReputationRequest is used to get the FCRMessage of gatewayAdminGetReputationRequest
+// MessageType returns the message type of GatewayAdminGetReputationRequest
+func (GatewayAdminGetReputationRequest) MessageType() int32 {
+	return GatewayAdminGetReputationRequestType
+}
+
+// Validate checks the fields of GatewayAdminGetReputationRequest
+func (m GatewayAdminGetReputationRequest) Validate() error {
+	return firstError(
+		validateNodeID("client_id", m.ClientID),
+	)
+}
+
+// EncodeGatewayAdminGetReputationRequest is used to get the FCRMessage of GatewayAdminGetReputationRequest
 func EncodeGatewayAdminGetReputationRequest(
 	clientID *nodeid.NodeID,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminGetReputationRequest{
+	return Encode(GatewayAdminGetReputationRequest{
 		ClientID: clientID.ToString(),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminGetReputationRequestType, body), nil
 }
 
-// DecodeGatewayAdminGetReputationRequest is used to get the fields from FCRMessage of gatewayAdminGetReputationRequest
+// DecodeGatewayAdminGetReputationRequest is used to get the fields from FCRMessage of GatewayAdminGetReputationRequest
 func DecodeGatewayAdminGetReputationRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // client id
 	error, // error
@@ -48,11 +56,14 @@ func DecodeGatewayAdminGetReputationRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminGetReputationRequestType {
 		return nil, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminGetReputationRequest{}
+	msg := GatewayAdminGetReputationRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.ClientID)
 	return nodeID, nil
 }
@@ -22,31 +22,39 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminGetReputationResponse is the response to gatewayAdminGetReputationRequest
-type gatewayAdminGetReputationResponse struct {
+// GatewayAdminGetReputationResponse is the response to GatewayAdminGetReputationRequest
+type GatewayAdminGetReputationResponse struct {
 	ClientID   string `json:"client_id"`
 	Reputation int64  `json:"reputation"`
 	Exists     bool   `json:"exists"`
 }
 
-// EncodeGatewayAdminGetReputationResponse is used to get the FCRMessage of gatewayAdminGetReputationResponse
+// MessageType returns the message type of GatewayAdminGetReputationResponse
+func (GatewayAdminGetReputationResponse) MessageType() int32 {
+	return GatewayAdminGetReputationResponseType
+}
+
+// Validate checks the fields of GatewayAdminGetReputationResponse
+func (m GatewayAdminGetReputationResponse) Validate() error {
+	return firstError(
+		validateNodeID("client_id", m.ClientID),
+	)
+}
+
+// EncodeGatewayAdminGetReputationResponse is used to get the FCRMessage of GatewayAdminGetReputationResponse
 func EncodeGatewayAdminGetReputationResponse(
 	clientID *nodeid.NodeID,
 	reputation int64,
 	exists bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminGetReputationResponse{
+	return Encode(GatewayAdminGetReputationResponse{
 		ClientID:   clientID.ToString(),
 		Reputation: reputation,
 		Exists:     exists,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminGetReputationResponseType, body), nil
 }
 
-// DecodeGatewayAdminGetReputationResponse is used to get the fields from FCRMessage of gatewayAdminGetReputationResponse
+// DecodeGatewayAdminGetReputationResponse is used to get the fields from FCRMessage of GatewayAdminGetReputationResponse
 func DecodeGatewayAdminGetReputationResponse(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // client id
 	int64, // reputation
@@ -56,11 +64,14 @@ func DecodeGatewayAdminGetReputationResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminGetReputationResponseType {
 		return nil, 0, false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminGetReputationResponse{}
+	msg := GatewayAdminGetReputationResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, err
+	}
 	contentID, _ := nodeid.NewNodeIDFromHexString(msg.ClientID)
 	return contentID, msg.Reputation, msg.Exists, nil
 }
@@ -23,31 +23,39 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminInitialiseKeyRequest is the request from a gateway admin to a gateway to initialise with a key pair.
-type gatewayAdminInitialiseKeyRequest struct {
+// GatewayAdminInitialiseKeyRequest is the request from a gateway admin to a gateway to initialise with a key pair.
+type GatewayAdminInitialiseKeyRequest struct {
 	GatewayID         string `json:"gateway_id"`
 	PrivateKey        string `json:"private_key"`
 	PrivateKeyVersion uint32 `json:"private_key_version"`
 }
 
-// EncodeGatewayAdminInitialiseKeyRequest is used to get the FCRMessage of gatewayAdminInitialiseKeyRequest
+// MessageType returns the message type of GatewayAdminInitialiseKeyRequest
+func (GatewayAdminInitialiseKeyRequest) MessageType() int32 {
+	return GatewayAdminInitialiseKeyRequestType
+}
+
+// Validate checks the fields of GatewayAdminInitialiseKeyRequest
+func (m GatewayAdminInitialiseKeyRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
+// EncodeGatewayAdminInitialiseKeyRequest is used to get the FCRMessage of GatewayAdminInitialiseKeyRequest
 func EncodeGatewayAdminInitialiseKeyRequest(
 	nodeID *nodeid.NodeID,
 	privateKey *fcrcrypto.KeyPair,
 	keyVersion *fcrcrypto.KeyVersion,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminInitialiseKeyRequest{
+	return Encode(GatewayAdminInitialiseKeyRequest{
 		nodeID.ToString(),
 		privateKey.EncodePrivateKey(),
 		keyVersion.EncodeKeyVersion(),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminInitialiseKeyRequestType, body), nil
 }
 
-// DecodeGatewayAdminInitialiseKeyRequest is used to get the fields from FCRMessage of gatewayAdminInitialiseKeyRequest
+// DecodeGatewayAdminInitialiseKeyRequest is used to get the fields from FCRMessage of GatewayAdminInitialiseKeyRequest
 func DecodeGatewayAdminInitialiseKeyRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // gateway id
 	*fcrcrypto.KeyPair, // private key
@@ -57,11 +65,14 @@ func DecodeGatewayAdminInitialiseKeyRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminInitialiseKeyRequestType {
 		return nil, nil, nil, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminInitialiseKeyRequest{}
+	msg := GatewayAdminInitialiseKeyRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
 	privKey, err := fcrcrypto.DecodePrivateKey(msg.PrivateKey)
 	if err != nil {
 		return nil, nil, nil, errors.New("fail to decode private key")
@@ -23,9 +23,9 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminInitialiseKeyRequestV2 is the request from a gateway admin to a gateway to initialise with a key pair,
+// GatewayAdminInitialiseKeyRequestV2 is the request from a gateway admin to a gateway to initialise with a key pair,
 // and lotus access point and lotus auth token
-type gatewayAdminInitialiseKeyRequestV2 struct {
+type GatewayAdminInitialiseKeyRequestV2 struct {
 	GatewayID         string `json:"gateway_id"`
 	PrivateKey        string `json:"private_key"`
 	PrivateKeyVersion uint32 `json:"private_key_version"`
@@ -34,7 +34,19 @@ type gatewayAdminInitialiseKeyRequestV2 struct {
 	LotusAuthToken    string `json:"lotus_auth_token"`
 }
 
-// EncodeGatewayAdminInitialiseKeyRequestV2 is used to get the FCRMessage of gatewayAdminInitialiseKeyRequestV2
+// MessageType returns the message type of GatewayAdminInitialiseKeyRequestV2
+func (GatewayAdminInitialiseKeyRequestV2) MessageType() int32 {
+	return GatewayAdminInitialiseKeyRequestV2Type
+}
+
+// Validate checks the fields of GatewayAdminInitialiseKeyRequestV2
+func (m GatewayAdminInitialiseKeyRequestV2) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+	)
+}
+
+// EncodeGatewayAdminInitialiseKeyRequestV2 is used to get the FCRMessage of GatewayAdminInitialiseKeyRequestV2
 func EncodeGatewayAdminInitialiseKeyRequestV2(
 	nodeID *nodeid.NodeID,
 	privateKey *fcrcrypto.KeyPair,
@@ -43,7 +55,7 @@ func EncodeGatewayAdminInitialiseKeyRequestV2(
 	lotusAP string,
 	lotusAuthToken string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminInitialiseKeyRequestV2{
+	return Encode(GatewayAdminInitialiseKeyRequestV2{
 		nodeID.ToString(),
 		privateKey.EncodePrivateKey(),
 		keyVersion.EncodeKeyVersion(),
@@ -51,13 +63,9 @@ func EncodeGatewayAdminInitialiseKeyRequestV2(
 		lotusAP,
 		lotusAuthToken,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminInitialiseKeyRequestV2Type, body), nil
 }
 
-// DecodeGatewayAdminInitialiseKeyRequestV2 is used to get the fields from FCRMessage of gatewayAdminInitialiseKeyRequestV2
+// DecodeGatewayAdminInitialiseKeyRequestV2 is used to get the fields from FCRMessage of GatewayAdminInitialiseKeyRequestV2
 func DecodeGatewayAdminInitialiseKeyRequestV2(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // gateway id
 	*fcrcrypto.KeyPair, // private key
@@ -70,11 +78,14 @@ func DecodeGatewayAdminInitialiseKeyRequestV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminInitialiseKeyRequestV2Type {
 		return nil, nil, nil, "", "", "", errors.New("message type mismatch")
 	}
-	msg := gatewayAdminInitialiseKeyRequestV2{}
+	msg := GatewayAdminInitialiseKeyRequestV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, "", "", "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, "", "", "", err
+	}
 	privKey, err := fcrcrypto.DecodePrivateKey(msg.PrivateKey)
 	if err != nil {
 		return nil, nil, nil, "", "", "", errors.New("fail to decode private key")
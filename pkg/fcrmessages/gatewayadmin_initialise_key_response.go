@@ -20,22 +20,23 @@ import (
 	"errors"
 )
 
-// gatewayAdminInitialiseKeyResponse is the response to gatewayAdminInitialiseKeyRequest
-type gatewayAdminInitialiseKeyResponse struct {
+// GatewayAdminInitialiseKeyResponse is the response to GatewayAdminInitialiseKeyRequest
+type GatewayAdminInitialiseKeyResponse struct {
 	Success bool `json:"success"`
 }
 
-// EncodeGatewayAdminInitialiseKeyResponse is used to get the FCRMessage of gatewayAdminInitialiseKeyResponse
+// MessageType returns the message type of GatewayAdminInitialiseKeyResponse
+func (GatewayAdminInitialiseKeyResponse) MessageType() int32 {
+	return GatewayAdminInitialiseKeyResponseType
+}
+
+// EncodeGatewayAdminInitialiseKeyResponse is used to get the FCRMessage of GatewayAdminInitialiseKeyResponse
 func EncodeGatewayAdminInitialiseKeyResponse(
 	success bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminInitialiseKeyResponse{
+	return Encode(GatewayAdminInitialiseKeyResponse{
 		Success: success,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminInitialiseKeyResponseType, body), nil
 }
 
 // DecodeGatewayAdminInitialiseKeyResponse is used to get the fields from FCRMessage of GatewayAdminInitialiseKeyResponse
@@ -46,7 +47,7 @@ func DecodeGatewayAdminInitialiseKeyResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminInitialiseKeyResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminInitialiseKeyResponse{}
+	msg := GatewayAdminInitialiseKeyResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
@@ -20,23 +20,24 @@ import (
 	"errors"
 )
 
-// gatewayAdminInitialiseKeyRequest is the request from a gateway admin to a gateway to initialise with a key pair.
-type gatewayAdminListDHTOfferRequest struct {
+// GatewayAdminInitialiseKeyRequest is the request from a gateway admin to a gateway to initialise with a key pair.
+type GatewayAdminListDHTOfferRequest struct {
 	Refresh bool `json:"refresh"`
 }
 
-// EncodeGatewayAdminListDHTOfferRequest is used to get the FCRMessage of gatewayAdminListDHTOfferRequest
+// MessageType returns the message type of GatewayAdminListDHTOfferRequest
+func (GatewayAdminListDHTOfferRequest) MessageType() int32 {
+	return GatewayAdminListDHTOfferRequestType
+}
+
+// EncodeGatewayAdminListDHTOfferRequest is used to get the FCRMessage of GatewayAdminListDHTOfferRequest
 func EncodeGatewayAdminListDHTOfferRequest(refresh bool) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminListDHTOfferRequest{
+	return Encode(GatewayAdminListDHTOfferRequest{
 		Refresh: refresh,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminListDHTOfferRequestType, body), nil
 }
 
-// DecodeGatewayAdminListDHTOfferRequest is used to get the fields from FCRMessage of gatewayAdminListDHTOfferRequest
+// DecodeGatewayAdminListDHTOfferRequest is used to get the fields from FCRMessage of GatewayAdminListDHTOfferRequest
 func DecodeGatewayAdminListDHTOfferRequest(fcrMsg *FCRMessage) (
 	bool, // refresh
 	error, // error
@@ -44,7 +45,7 @@ func DecodeGatewayAdminListDHTOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminListDHTOfferRequestType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminListDHTOfferRequest{}
+	msg := GatewayAdminListDHTOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
@@ -20,23 +20,24 @@ import (
 	"errors"
 )
 
-// gatewayAdminInitialiseKeyResponse is the response to gatewayAdminInitialiseKeyRequest
-type gatewayAdminListDHTOfferResponse struct {
+// GatewayAdminInitialiseKeyResponse is the response to GatewayAdminInitialiseKeyRequest
+type GatewayAdminListDHTOfferResponse struct {
 	Refreshed bool `json:"refreshed"`
 }
 
-// EncodeGatewayAdminListDHTOfferResponse is used to get the FCRMessage of gatewayAdminListDHTOfferResponse
+// MessageType returns the message type of GatewayAdminListDHTOfferResponse
+func (GatewayAdminListDHTOfferResponse) MessageType() int32 {
+	return GatewayAdminListDHTOfferResponseType
+}
+
+// EncodeGatewayAdminListDHTOfferResponse is used to get the FCRMessage of GatewayAdminListDHTOfferResponse
 func EncodeGatewayAdminListDHTOfferResponse(refreshed bool) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminListDHTOfferResponse{
+	return Encode(GatewayAdminListDHTOfferResponse{
 		Refreshed: refreshed,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminListDHTOfferResponseType, body), nil
 }
 
-// DecodeGatewayAdminListDHTOfferResponse is used to get the fields from FCRMessage of gatewayAdminListDHTOfferResponse
+// DecodeGatewayAdminListDHTOfferResponse is used to get the fields from FCRMessage of GatewayAdminListDHTOfferResponse
 func DecodeGatewayAdminListDHTOfferResponse(fcrMsg *FCRMessage) (
 	bool, // refresh
 	error, // error
@@ -44,7 +45,7 @@ func DecodeGatewayAdminListDHTOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminListDHTOfferResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminListDHTOfferResponse{}
+	msg := GatewayAdminListDHTOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
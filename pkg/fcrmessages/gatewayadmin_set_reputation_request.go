@@ -22,28 +22,36 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminSetReputationRequest is the request from an admin client to a gateway to set a client's reputation
-type gatewayAdminSetReputationRequest struct {
+// GatewayAdminSetReputationRequest is the request from an admin client to a gateway to set a client's reputation
+type GatewayAdminSetReputationRequest struct {
 	ClientID   string `json:"client_id"`
 	Reputation int64  `json:"reputation"`
 }
 
-// EncodeGatewayAdminSetReputationRequest is used to get the FCRMessage of gatewayAdminSetReputationRequest
+// MessageType returns the message type of GatewayAdminSetReputationRequest
+func (GatewayAdminSetReputationRequest) MessageType() int32 {
+	return GatewayAdminSetReputationRequestType
+}
+
+// Validate checks the fields of GatewayAdminSetReputationRequest
+func (m GatewayAdminSetReputationRequest) Validate() error {
+	return firstError(
+		validateNodeID("client_id", m.ClientID),
+	)
+}
+
+// EncodeGatewayAdminSetReputationRequest is used to get the FCRMessage of GatewayAdminSetReputationRequest
 func EncodeGatewayAdminSetReputationRequest(
 	clientID *nodeid.NodeID,
 	reputation int64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminSetReputationRequest{
+	return Encode(GatewayAdminSetReputationRequest{
 		ClientID:   clientID.ToString(),
 		Reputation: reputation,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminSetReputationRequestType, body), nil
 }
 
-// DecodeGatewayAdminSetReputationRequest is used to get the fields from FCRMessage of gatewayAdminSetReputationRequest
+// DecodeGatewayAdminSetReputationRequest is used to get the fields from FCRMessage of GatewayAdminSetReputationRequest
 func DecodeGatewayAdminSetReputationRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // client id
 	int64, // reputation
@@ -52,11 +60,14 @@ func DecodeGatewayAdminSetReputationRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminSetReputationRequestType {
 		return nil, 0, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminSetReputationRequest{}
+	msg := GatewayAdminSetReputationRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.ClientID)
 	return nodeID, msg.Reputation, nil
 }
@@ -22,31 +22,39 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// gatewayAdminSetReputationResponse is the response to gatewayAdminSetReputationRequest
-type gatewayAdminSetReputationResponse struct {
+// GatewayAdminSetReputationResponse is the response to GatewayAdminSetReputationRequest
+type GatewayAdminSetReputationResponse struct {
 	ClientID   string `json:"client_id"`
 	Reputation int64  `json:"reputation"`
 	Exists     bool   `json:"exists"`
 }
 
-// EncodeGatewayAdminSetReputationResponse is used to get the FCRMessage of gatewayAdminSetReputationResponse
+// MessageType returns the message type of GatewayAdminSetReputationResponse
+func (GatewayAdminSetReputationResponse) MessageType() int32 {
+	return GatewayAdminSetReputationResponseType
+}
+
+// Validate checks the fields of GatewayAdminSetReputationResponse
+func (m GatewayAdminSetReputationResponse) Validate() error {
+	return firstError(
+		validateNodeID("client_id", m.ClientID),
+	)
+}
+
+// EncodeGatewayAdminSetReputationResponse is used to get the FCRMessage of GatewayAdminSetReputationResponse
 func EncodeGatewayAdminSetReputationResponse(
 	clientID *nodeid.NodeID,
 	reputation int64,
 	exists bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(gatewayAdminSetReputationResponse{
+	return Encode(GatewayAdminSetReputationResponse{
 		ClientID:   clientID.ToString(),
 		Reputation: reputation,
 		Exists:     exists,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminSetReputationResponseType, body), nil
 }
 
-// DecodeGatewayAdminSetReputationResponse is used to get the fields from FCRMessage of gatewayAdminSetReputationResponse
+// DecodeGatewayAdminSetReputationResponse is used to get the fields from FCRMessage of GatewayAdminSetReputationResponse
 func DecodeGatewayAdminSetReputationResponse(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // client id
 	int64, // reputation
@@ -56,11 +64,14 @@ func DecodeGatewayAdminSetReputationResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminSetReputationResponseType {
 		return nil, 0, false, errors.New("message type mismatch")
 	}
-	msg := gatewayAdminSetReputationResponse{}
+	msg := GatewayAdminSetReputationResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, false, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, false, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.ClientID)
 	return nodeID, msg.Reputation, msg.Exists, nil
 }
@@ -22,23 +22,32 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-type updateGatewayGroupCIDOfferSupportRequest struct {
+type UpdateGatewayGroupCIDOfferSupportRequest struct {
 	GatewayID   string   `json:"gateway_id"`
 	ProviderIDs []string `json:"provider_ids"` // the Gateway supports Group CID Offers only from these Providers
 }
 
+// MessageType returns the message type of UpdateGatewayGroupCIDOfferSupportRequest
+func (UpdateGatewayGroupCIDOfferSupportRequest) MessageType() int32 {
+	return GatewayAdminUpdateGatewayGroupCIDOfferSupportRequestType
+}
+
+// Validate checks the fields of UpdateGatewayGroupCIDOfferSupportRequest
+func (m UpdateGatewayGroupCIDOfferSupportRequest) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewayID),
+		validateNodeIDs("provider_ids", m.ProviderIDs),
+	)
+}
+
 func EncodeUpdateGatewayGroupCIDOfferSupportRequest(
 	nodeID *nodeid.NodeID,
 	providerIDs []nodeid.NodeID,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(updateGatewayGroupCIDOfferSupportRequest{
+	return Encode(UpdateGatewayGroupCIDOfferSupportRequest{
 		nodeID.ToString(),
 		nodeid.MapNodeIDToString(providerIDs),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminUpdateGatewayGroupCIDOfferSupportRequestType, body), nil
 }
 
 func DecodeUpdateGatewayGroupCIDOfferSupportRequest(fcrMsg *FCRMessage) (
@@ -49,11 +58,14 @@ func DecodeUpdateGatewayGroupCIDOfferSupportRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminUpdateGatewayGroupCIDOfferSupportRequestType {
 		return nil, nil, errors.New("message type mismatch")
 	}
-	msg := updateGatewayGroupCIDOfferSupportRequest{}
+	msg := UpdateGatewayGroupCIDOfferSupportRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewayID)
 	return nodeID, nodeid.MapStringToNodeID(msg.ProviderIDs), nil
 }
@@ -20,20 +20,21 @@ import (
 	"errors"
 )
 
-type updateGatewayGroupCIDOfferSupportResponse struct {
+type UpdateGatewayGroupCIDOfferSupportResponse struct {
 	Success bool `json:"success"`
 }
 
+// MessageType returns the message type of UpdateGatewayGroupCIDOfferSupportResponse
+func (UpdateGatewayGroupCIDOfferSupportResponse) MessageType() int32 {
+	return GatewayAdminUpdateGatewayGroupCIDOfferSupportResponseType
+}
+
 func EncodeUpdateGatewayGroupCIDOfferSupportResponse(
 	success bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(updateGatewayGroupCIDOfferSupportResponse{
+	return Encode(UpdateGatewayGroupCIDOfferSupportResponse{
 		Success: success,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(GatewayAdminUpdateGatewayGroupCIDOfferSupportResponseType, body), nil
 }
 
 func DecodeUpdateGatewayGroupCIDOfferSupportResponse(fcrMsg *FCRMessage) (
@@ -43,7 +44,7 @@ func DecodeUpdateGatewayGroupCIDOfferSupportResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != GatewayAdminUpdateGatewayGroupCIDOfferSupportResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := updateGatewayGroupCIDOfferSupportResponse{}
+	msg := UpdateGatewayGroupCIDOfferSupportResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
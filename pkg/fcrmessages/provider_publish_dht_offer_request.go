@@ -23,33 +23,44 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerPublishDHTOfferRequest is the request from provider to gateway to publish dht offer
-type providerPublishDHTOfferRequest struct {
+// ProviderPublishDHTOfferRequest is the request from provider to gateway to publish dht offer
+type ProviderPublishDHTOfferRequest struct {
 	ProviderID string              `json:"provider_id"`
 	Nonce      int64               `json:"nonce"`
 	NumOffers  int64               `json:"num_of_offers"`
 	Offers     []cidoffer.CIDOffer `json:"single_offers"`
 }
 
-// EncodeProviderPublishDHTOfferRequest is used to get the FCRMessage of providerPublishDHTOfferRequest
+// MessageType returns the message type of ProviderPublishDHTOfferRequest
+func (ProviderPublishDHTOfferRequest) MessageType() int32 {
+	return ProviderPublishDHTOfferRequestType
+}
+
+// Validate checks the fields of ProviderPublishDHTOfferRequest
+func (m ProviderPublishDHTOfferRequest) Validate() error {
+	return firstError(
+		validateNodeID("provider_id", m.ProviderID),
+		validateNonNegative("nonce", m.Nonce),
+		validateNonNegative("num_of_offers", m.NumOffers),
+		validateLength("single_offers", len(m.Offers)),
+	)
+}
+
+// EncodeProviderPublishDHTOfferRequest is used to get the FCRMessage of ProviderPublishDHTOfferRequest
 func EncodeProviderPublishDHTOfferRequest(
 	providerID *nodeid.NodeID,
 	nonce int64,
 	offers []cidoffer.CIDOffer,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerPublishDHTOfferRequest{
+	return Encode(ProviderPublishDHTOfferRequest{
 		ProviderID: providerID.ToString(),
 		Nonce:      nonce,
 		NumOffers:  int64(len(offers)),
 		Offers:     offers,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderPublishDHTOfferRequestType, body), nil
 }
 
-// DecodeProviderPublishDHTOfferRequest is used to get the fields from FCRMessage of providerPublishDHTOfferRequest
+// DecodeProviderPublishDHTOfferRequest is used to get the fields from FCRMessage of ProviderPublishDHTOfferRequest
 func DecodeProviderPublishDHTOfferRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // provider id
 	int64, // nonce
@@ -59,11 +70,14 @@ func DecodeProviderPublishDHTOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderPublishDHTOfferRequestType {
 		return nil, 0, nil, errors.New("message type mismatch")
 	}
-	msg := providerPublishDHTOfferRequest{}
+	msg := ProviderPublishDHTOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, nil, err
+	}
 	// Check every offer is single offer
 	for _, offer := range msg.Offers {
 		if len(offer.GetCIDs()) != 1 {
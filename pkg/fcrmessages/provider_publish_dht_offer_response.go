@@ -20,25 +20,33 @@ import (
 	"errors"
 )
 
-// providerPublishDHTOfferResponse is the acknowledgement to providerPublishDHTOfferRequest
-type providerPublishDHTOfferResponse struct {
+// ProviderPublishDHTOfferResponse is the acknowledgement to ProviderPublishDHTOfferRequest
+type ProviderPublishDHTOfferResponse struct {
 	Nonce     int64  `json:"nonce"`
 	Signature string `json:"signature"`
 }
 
+// MessageType returns the message type of ProviderPublishDHTOfferResponse
+func (ProviderPublishDHTOfferResponse) MessageType() int32 {
+	return ProviderPublishDHTOfferResponseType
+}
+
+// Validate checks the fields of ProviderPublishDHTOfferResponse
+func (m ProviderPublishDHTOfferResponse) Validate() error {
+	return firstError(
+		validateNonNegative("nonce", m.Nonce),
+	)
+}
+
 // EncodeProviderPublishDHTOfferResponse is used to get the FCRMessage of ProviderPublishDHTOfferResponse
 func EncodeProviderPublishDHTOfferResponse(
 	nonce int64,
 	signature string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerPublishDHTOfferResponse{
+	return Encode(ProviderPublishDHTOfferResponse{
 		Nonce:     nonce,
 		Signature: signature,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderPublishDHTOfferResponseType, body), nil
 }
 
 // DecodeProviderPublishDHTOfferResponse is used to get the fields from FCRMessage of ProviderPublishDHTOfferResponse
@@ -50,10 +58,13 @@ func DecodeProviderPublishDHTOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderPublishDHTOfferResponseType {
 		return 0, "", errors.New("message type mismatch")
 	}
-	msg := providerPublishDHTOfferResponse{}
+	msg := ProviderPublishDHTOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return 0, "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return 0, "", err
+	}
 	return msg.Nonce, msg.Signature, nil
 }
@@ -23,31 +23,40 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerPublishGroupOfferRequest is the request from provider to gateway to publish group cid offer
-type providerPublishGroupOfferRequest struct {
+// ProviderPublishGroupOfferRequest is the request from provider to gateway to publish group cid offer
+type ProviderPublishGroupOfferRequest struct {
 	ProviderID string            `json:"provider_id"`
 	Nonce      int64             `json:"nonce"`
 	Offer      cidoffer.CIDOffer `json:"offer"`
 }
 
+// MessageType returns the message type of ProviderPublishGroupOfferRequest
+func (ProviderPublishGroupOfferRequest) MessageType() int32 {
+	return ProviderPublishGroupOfferRequestType
+}
+
+// Validate checks the fields of ProviderPublishGroupOfferRequest
+func (m ProviderPublishGroupOfferRequest) Validate() error {
+	return firstError(
+		validateNodeID("provider_id", m.ProviderID),
+		validateNonNegative("nonce", m.Nonce),
+	)
+}
+
 // EncodeProviderPublishGroupOfferRequest is used to get the FCRMessage of ProviderPublishGroupCIDRequest
 func EncodeProviderPublishGroupOfferRequest(
 	providerID *nodeid.NodeID,
 	nonce int64,
 	offer *cidoffer.CIDOffer,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerPublishGroupOfferRequest{
+	return Encode(ProviderPublishGroupOfferRequest{
 		ProviderID: providerID.ToString(),
 		Nonce:      nonce,
 		Offer:      *offer,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderPublishGroupOfferRequestType, body), nil
 }
 
-// DecodeProviderPublishGroupOfferRequest is used to get the fields from FCRMessage of providerPublishGroupOfferRequest
+// DecodeProviderPublishGroupOfferRequest is used to get the fields from FCRMessage of ProviderPublishGroupOfferRequest
 func DecodeProviderPublishGroupOfferRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // provider id
 	int64, // nonce
@@ -57,11 +66,14 @@ func DecodeProviderPublishGroupOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderPublishGroupOfferRequestType {
 		return nil, 0, nil, errors.New("message type mismatch")
 	}
-	msg := providerPublishGroupOfferRequest{}
+	msg := ProviderPublishGroupOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, 0, nil, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.ProviderID)
 	return nodeID, msg.Nonce, &msg.Offer, nil
 }
@@ -46,7 +46,7 @@ func TestDecodeProviderPublishGroupOfferRequest(t *testing.T) {
 	var mockQos uint64 = 43
 	mockOffer, err := cidoffer.NewCIDOffer(mockProviderID, mockCids, mockPrice, mockExpiry, mockQos)
 
-	mockOfferRequest, _ := json.Marshal(providerPublishGroupOfferRequest{
+	mockOfferRequest, _ := json.Marshal(ProviderPublishGroupOfferRequest{
 		ProviderID: mockProviderID.ToString(),
 		Nonce:      mockNonce,
 		Offer:      *mockOffer,
@@ -23,25 +23,33 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerPublishGroupOfferResponse is the response to providerPublishGroupOfferRequest
-type providerPublishGroupOfferResponse struct {
+// ProviderPublishGroupOfferResponse is the response to ProviderPublishGroupOfferRequest
+type ProviderPublishGroupOfferResponse struct {
 	GatewaydID string                            `json:"gateway_id"`
 	Digest     [cidoffer.CIDOfferDigestSize]byte `json:"digest"`
 }
 
+// MessageType returns the message type of ProviderPublishGroupOfferResponse
+func (ProviderPublishGroupOfferResponse) MessageType() int32 {
+	return ProviderPublishGroupOfferResponseType
+}
+
+// Validate checks the fields of ProviderPublishGroupOfferResponse
+func (m ProviderPublishGroupOfferResponse) Validate() error {
+	return firstError(
+		validateNodeID("gateway_id", m.GatewaydID),
+	)
+}
+
 // EncodeProviderPublishGroupOfferResponse is used to get the FCRMessage of ProviderPublishGroupOfferResponse
 func EncodeProviderPublishGroupOfferResponse(
 	gatewayID nodeid.NodeID,
 	digest [cidoffer.CIDOfferDigestSize]byte,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerPublishGroupOfferResponse{
+	return Encode(ProviderPublishGroupOfferResponse{
 		GatewaydID: gatewayID.ToString(),
 		Digest:     digest,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderPublishGroupOfferResponseType, body), nil
 }
 
 // DecodeProviderPublishGroupOfferResponse is used to get the fields from FCRMessage of ProviderPublishGroupOfferResponse
@@ -53,11 +61,14 @@ func DecodeProviderPublishGroupOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderPublishGroupOfferResponseType {
 		return nil, [cidoffer.CIDOfferDigestSize]byte{}, errors.New("message type mismatch")
 	}
-	msg := providerPublishGroupOfferResponse{}
+	msg := ProviderPublishGroupOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, [cidoffer.CIDOfferDigestSize]byte{}, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, [cidoffer.CIDOfferDigestSize]byte{}, err
+	}
 	nodeID, _ := nodeid.NewNodeIDFromHexString(msg.GatewaydID)
 	return nodeID, msg.Digest, nil
 }
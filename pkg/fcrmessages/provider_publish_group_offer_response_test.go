@@ -44,7 +44,7 @@ func TestDecodeProviderPublishGroupOfferResponse(t *testing.T) {
 	mockOffer, err := cidoffer.NewCIDOffer(mockProviderID, mockCids, mockPrice, mockExpiry, mockQos)
 	mockMsgDigest := mockOffer.GetMessageDigest()
 
-	mockOfferResponse, _ := json.Marshal(providerPublishGroupOfferResponse{
+	mockOfferResponse, _ := json.Marshal(ProviderPublishGroupOfferResponse{
 		GatewaydID: mockProviderID.ToString(),
 		Digest:     mockMsgDigest,
 	})
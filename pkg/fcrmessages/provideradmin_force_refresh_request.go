@@ -20,23 +20,24 @@ import (
 	"errors"
 )
 
-// providerAdminForceRefreshRequest is the request from an admin client to a provider to refresh internal register status
-type providerAdminForceRefreshRequest struct {
+// ProviderAdminForceRefreshRequest is the request from an admin client to a provider to refresh internal register status
+type ProviderAdminForceRefreshRequest struct {
 	Refresh bool `json:"refresh"`
 }
 
-// EncodeProviderAdminForceRefreshRequest is used to get the FCRMessage of providerAdminForceRefreshRequest
+// MessageType returns the message type of ProviderAdminForceRefreshRequest
+func (ProviderAdminForceRefreshRequest) MessageType() int32 {
+	return ProviderAdminForceRefreshRequestType
+}
+
+// EncodeProviderAdminForceRefreshRequest is used to get the FCRMessage of ProviderAdminForceRefreshRequest
 func EncodeProviderAdminForceRefreshRequest(refresh bool) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminForceRefreshRequest{
+	return Encode(ProviderAdminForceRefreshRequest{
 		Refresh: refresh,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminForceRefreshRequestType, body), nil
 }
 
-// DecodeProviderAdminForceRefreshRequest is used to get the fields from FCRMessage of providerAdminForceRefreshRequest
+// DecodeProviderAdminForceRefreshRequest is used to get the fields from FCRMessage of ProviderAdminForceRefreshRequest
 func DecodeProviderAdminForceRefreshRequest(fcrMsg *FCRMessage) (
 	bool, // refresh
 	error, // error
@@ -44,7 +45,7 @@ func DecodeProviderAdminForceRefreshRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminForceRefreshRequestType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := providerAdminForceRefreshRequest{}
+	msg := ProviderAdminForceRefreshRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
@@ -20,25 +20,26 @@ import (
 	"errors"
 )
 
-// providerAdminForceRefreshResponse is the response to providerAdminForceRefreshRequest
-type providerAdminForceRefreshResponse struct {
+// ProviderAdminForceRefreshResponse is the response to ProviderAdminForceRefreshRequest
+type ProviderAdminForceRefreshResponse struct {
 	Refreshed bool `json:"refreshed"`
 }
 
-// EncodeProviderAdminForceRefreshResponse is used to get the FCRMessage of providerAdminForceRefreshResponse
+// MessageType returns the message type of ProviderAdminForceRefreshResponse
+func (ProviderAdminForceRefreshResponse) MessageType() int32 {
+	return ProviderAdminForceRefreshResponseType
+}
+
+// EncodeProviderAdminForceRefreshResponse is used to get the FCRMessage of ProviderAdminForceRefreshResponse
 func EncodeProviderAdminForceRefreshResponse(
 	refreshed bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminForceRefreshResponse{
+	return Encode(ProviderAdminForceRefreshResponse{
 		Refreshed: refreshed,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminForceRefreshResponseType, body), nil
 }
 
-// DecodeProviderAdminForceRefreshResponse is used to get the fields from FCRMessage of providerAdminForceRefreshResponse
+// DecodeProviderAdminForceRefreshResponse is used to get the fields from FCRMessage of ProviderAdminForceRefreshResponse
 func DecodeProviderAdminForceRefreshResponse(fcrMsg *FCRMessage) (
 	bool, // refreshed
 	error, // error
@@ -46,7 +47,7 @@ func DecodeProviderAdminForceRefreshResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminForceRefreshResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := providerAdminForceRefreshResponse{}
+	msg := ProviderAdminForceRefreshResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
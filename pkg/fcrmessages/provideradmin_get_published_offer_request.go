@@ -22,25 +22,33 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerAdminGetPublishedOfferRequest is the requset from provideradmin to provider to ask for published  offers for given gateway ids
-type providerAdminGetPublishedOfferRequest struct {
+// ProviderAdminGetPublishedOfferRequest is the requset from provideradmin to provider to ask for published  offers for given gateway ids
+type ProviderAdminGetPublishedOfferRequest struct {
 	GatewayIDs []string `json:"gateway_id"`
 }
 
-// EncodeProviderAdminGetPublishedOfferRequest is used to get the FCRMessage of providerAdminGetPublishedOfferRequest
+// MessageType returns the message type of ProviderAdminGetPublishedOfferRequest
+func (ProviderAdminGetPublishedOfferRequest) MessageType() int32 {
+	return ProviderAdminGetPublishedOfferRequestType
+}
+
+// Validate checks the fields of ProviderAdminGetPublishedOfferRequest
+func (m ProviderAdminGetPublishedOfferRequest) Validate() error {
+	return firstError(
+		validateNodeIDs("gateway_id", m.GatewayIDs),
+	)
+}
+
+// EncodeProviderAdminGetPublishedOfferRequest is used to get the FCRMessage of ProviderAdminGetPublishedOfferRequest
 func EncodeProviderAdminGetPublishedOfferRequest(
 	gatewayIDs []nodeid.NodeID,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminGetPublishedOfferRequest{
+	return Encode(ProviderAdminGetPublishedOfferRequest{
 		GatewayIDs: nodeid.MapNodeIDToString(gatewayIDs),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminGetPublishedOfferRequestType, body), nil
 }
 
-// DecodeProviderAdminGetPublishedOfferRequest is used to get the fields from FCRMessage of providerAdminGetPublishedOfferRequest
+// DecodeProviderAdminGetPublishedOfferRequest is used to get the fields from FCRMessage of ProviderAdminGetPublishedOfferRequest
 func DecodeProviderAdminGetPublishedOfferRequest(fcrMsg *FCRMessage) (
 	[]nodeid.NodeID, // piece cids
 	error, // error
@@ -48,10 +56,13 @@ func DecodeProviderAdminGetPublishedOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminGetPublishedOfferRequestType {
 		return nil, errors.New("message type mismatch")
 	}
-	msg := providerAdminGetPublishedOfferRequest{}
+	msg := ProviderAdminGetPublishedOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, err
+	}
 	return nodeid.MapStringToNodeID(msg.GatewayIDs), nil
 }
@@ -22,28 +22,36 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cidoffer"
 )
 
-// providerAdminGetPublishedOfferResponse is the response to providerAdminGetPublishedOfferRequest
-type providerAdminGetPublishedOfferResponse struct {
+// ProviderAdminGetPublishedOfferResponse is the response to ProviderAdminGetPublishedOfferRequest
+type ProviderAdminGetPublishedOfferResponse struct {
 	Exists bool                `json:"exists"`
 	Offers []cidoffer.CIDOffer `json:"cid_offers"`
 }
 
-// EncodeProviderAdminGetPublishedOfferResponse is used to get the FCRMessage of providerAdminGetPublishedOfferResponse
+// MessageType returns the message type of ProviderAdminGetPublishedOfferResponse
+func (ProviderAdminGetPublishedOfferResponse) MessageType() int32 {
+	return ProviderAdminGetPublishedOfferResponseType
+}
+
+// Validate checks the fields of ProviderAdminGetPublishedOfferResponse
+func (m ProviderAdminGetPublishedOfferResponse) Validate() error {
+	return firstError(
+		validateLength("cid_offers", len(m.Offers)),
+	)
+}
+
+// EncodeProviderAdminGetPublishedOfferResponse is used to get the FCRMessage of ProviderAdminGetPublishedOfferResponse
 func EncodeProviderAdminGetPublishedOfferResponse(
 	exists bool,
 	offers []cidoffer.CIDOffer,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminGetPublishedOfferResponse{
+	return Encode(ProviderAdminGetPublishedOfferResponse{
 		Exists: exists,
 		Offers: offers,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminGetPublishedOfferResponseType, body), nil
 }
 
-// DecodeProviderAdminGetPublishedOfferResponse is used to get the fields from FCRMessage of providerAdminGetPublishedOfferResponse
+// DecodeProviderAdminGetPublishedOfferResponse is used to get the fields from FCRMessage of ProviderAdminGetPublishedOfferResponse
 func DecodeProviderAdminGetPublishedOfferResponse(fcrMsg *FCRMessage) (
 	bool, // exists
 	[]cidoffer.CIDOffer, // cid offers
@@ -52,10 +60,13 @@ func DecodeProviderAdminGetPublishedOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminGetPublishedOfferResponseType {
 		return false, nil, errors.New("message type mismatch")
 	}
-	msg := providerAdminGetPublishedOfferResponse{}
+	msg := ProviderAdminGetPublishedOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return false, nil, err
+	}
 	return msg.Exists, msg.Offers, nil
 }
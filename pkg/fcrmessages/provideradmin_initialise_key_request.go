@@ -23,31 +23,39 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerAdminInitialiseKeyRequest is the request from a provider admin to a provider to initialise with a key pair.
-type providerAdminInitialiseKeyRequest struct {
+// ProviderAdminInitialiseKeyRequest is the request from a provider admin to a provider to initialise with a key pair.
+type ProviderAdminInitialiseKeyRequest struct {
 	ProviderID        string `json:"provider_id"`
 	PrivateKey        string `json:"private_key"`
 	PrivateKeyVersion uint32 `json:"private_key_version"`
 }
 
-// EncodeProviderAdminInitialiseKeyRequest is used to get the FCRMessage of providerAdminInitialiseKeyRequest
+// MessageType returns the message type of ProviderAdminInitialiseKeyRequest
+func (ProviderAdminInitialiseKeyRequest) MessageType() int32 {
+	return ProviderAdminInitialiseKeyRequestType
+}
+
+// Validate checks the fields of ProviderAdminInitialiseKeyRequest
+func (m ProviderAdminInitialiseKeyRequest) Validate() error {
+	return firstError(
+		validateNodeID("provider_id", m.ProviderID),
+	)
+}
+
+// EncodeProviderAdminInitialiseKeyRequest is used to get the FCRMessage of ProviderAdminInitialiseKeyRequest
 func EncodeProviderAdminInitialiseKeyRequest(
 	nodeID *nodeid.NodeID,
 	privateKey *fcrcrypto.KeyPair,
 	keyVersion *fcrcrypto.KeyVersion,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminInitialiseKeyRequest{
+	return Encode(ProviderAdminInitialiseKeyRequest{
 		nodeID.ToString(),
 		privateKey.EncodePrivateKey(),
 		keyVersion.EncodeKeyVersion(),
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminInitialiseKeyRequestType, body), nil
 }
 
-// DecodeProviderAdminInitialiseKeyRequest is used to get the fields from FCRMessage of providerAdminInitialiseKeyRequest
+// DecodeProviderAdminInitialiseKeyRequest is used to get the fields from FCRMessage of ProviderAdminInitialiseKeyRequest
 func DecodeProviderAdminInitialiseKeyRequest(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // provider id
 	*fcrcrypto.KeyPair, // private key
@@ -57,11 +65,14 @@ func DecodeProviderAdminInitialiseKeyRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminInitialiseKeyRequestType {
 		return nil, nil, nil, errors.New("message type mismatch")
 	}
-	msg := providerAdminInitialiseKeyRequest{}
+	msg := ProviderAdminInitialiseKeyRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, err
+	}
 	privKey, err := fcrcrypto.DecodePrivateKey(msg.PrivateKey)
 	if err != nil {
 		return nil, nil, nil, errors.New("fail to decode private key")
@@ -23,9 +23,9 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// providerAdminInitialiseKeyRequestV2 is the request from a provider admin to a provider to initialise with a key pair,
+// ProviderAdminInitialiseKeyRequestV2 is the request from a provider admin to a provider to initialise with a key pair,
 // and lotus access point and lotus auth token
-type providerAdminInitialiseKeyRequestV2 struct {
+type ProviderAdminInitialiseKeyRequestV2 struct {
 	ProviderID        string `json:"provider_id"`
 	PrivateKey        string `json:"private_key"`
 	PrivateKeyVersion uint32 `json:"private_key_version"`
@@ -34,7 +34,19 @@ type providerAdminInitialiseKeyRequestV2 struct {
 	LotusAuthToken    string `json:"lotus_auth_token"`
 }
 
-// EncodeProviderAdminInitialiseKeyRequestV2 is used to get the FCRMessage of providerAdminInitialiseKeyRequestV2
+// MessageType returns the message type of ProviderAdminInitialiseKeyRequestV2
+func (ProviderAdminInitialiseKeyRequestV2) MessageType() int32 {
+	return ProviderAdminInitialiseKeyRequestV2Type
+}
+
+// Validate checks the fields of ProviderAdminInitialiseKeyRequestV2
+func (m ProviderAdminInitialiseKeyRequestV2) Validate() error {
+	return firstError(
+		validateNodeID("provider_id", m.ProviderID),
+	)
+}
+
+// EncodeProviderAdminInitialiseKeyRequestV2 is used to get the FCRMessage of ProviderAdminInitialiseKeyRequestV2
 func EncodeProviderAdminInitialiseKeyRequestV2(
 	nodeID *nodeid.NodeID,
 	privateKey *fcrcrypto.KeyPair,
@@ -43,7 +55,7 @@ func EncodeProviderAdminInitialiseKeyRequestV2(
 	lotusAP string,
 	lotusAuthToken string,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminInitialiseKeyRequestV2{
+	return Encode(ProviderAdminInitialiseKeyRequestV2{
 		nodeID.ToString(),
 		privateKey.EncodePrivateKey(),
 		keyVersion.EncodeKeyVersion(),
@@ -51,13 +63,9 @@ func EncodeProviderAdminInitialiseKeyRequestV2(
 		lotusAP,
 		lotusAuthToken,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminInitialiseKeyRequestV2Type, body), nil
 }
 
-// DecodeProviderAdminInitialiseKeyRequestV2 is used to get the fields from FCRMessage of providerAdminInitialiseKeyRequestV2
+// DecodeProviderAdminInitialiseKeyRequestV2 is used to get the fields from FCRMessage of ProviderAdminInitialiseKeyRequestV2
 func DecodeProviderAdminInitialiseKeyRequestV2(fcrMsg *FCRMessage) (
 	*nodeid.NodeID, // provider id
 	*fcrcrypto.KeyPair, // private key
@@ -70,11 +78,14 @@ func DecodeProviderAdminInitialiseKeyRequestV2(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminInitialiseKeyRequestV2Type {
 		return nil, nil, nil, "", "", "", errors.New("message type mismatch")
 	}
-	msg := providerAdminInitialiseKeyRequestV2{}
+	msg := ProviderAdminInitialiseKeyRequestV2{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, "", "", "", err
 	}
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, "", "", "", err
+	}
 	privKey, err := fcrcrypto.DecodePrivateKey(msg.PrivateKey)
 	if err != nil {
 		return nil, nil, nil, "", "", "", errors.New("fail to decode private key")
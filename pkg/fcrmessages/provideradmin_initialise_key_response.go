@@ -20,22 +20,23 @@ import (
 	"errors"
 )
 
-// providerAdminInitialiseKeyResponse is the response to providerAdminInitialiseKeyRequest
-type providerAdminInitialiseKeyResponse struct {
+// ProviderAdminInitialiseKeyResponse is the response to ProviderAdminInitialiseKeyRequest
+type ProviderAdminInitialiseKeyResponse struct {
 	Success bool `json:"success"`
 }
 
-// EncodeProviderAdminInitialiseKeyResponse is used to get the FCRMessage of providerAdminInitialiseKeyResponse
+// MessageType returns the message type of ProviderAdminInitialiseKeyResponse
+func (ProviderAdminInitialiseKeyResponse) MessageType() int32 {
+	return ProviderAdminInitialiseKeyResponseType
+}
+
+// EncodeProviderAdminInitialiseKeyResponse is used to get the FCRMessage of ProviderAdminInitialiseKeyResponse
 func EncodeProviderAdminInitialiseKeyResponse(
 	success bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminInitialiseKeyResponse{
+	return Encode(ProviderAdminInitialiseKeyResponse{
 		Success: success,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminInitialiseKeyResponseType, body), nil
 }
 
 // DecodeProviderAdminInitialiseKeyResponse is used to get the fields from FCRMessage of ProviderAdminInitialiseKeyResponse
@@ -46,7 +47,7 @@ func DecodeProviderAdminInitialiseKeyResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminInitialiseKeyResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := providerAdminInitialiseKeyResponse{}
+	msg := ProviderAdminInitialiseKeyResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
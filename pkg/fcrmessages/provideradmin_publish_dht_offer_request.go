@@ -22,34 +22,45 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// providerAdminPublishDHTOfferRequest is the request to publish dht CID
-type providerAdminPublishDHTOfferRequest struct {
+// ProviderAdminPublishDHTOfferRequest is the request to publish dht CID
+type ProviderAdminPublishDHTOfferRequest struct {
 	CIDs   []string `json:"cids"`
 	Price  []uint64 `json:"price"`
 	Expiry []int64  `json:"expiry"`
 	QoS    []uint64 `json:"qos"`
 }
 
-// EncodeProviderAdminPublishDHTOfferRequest is used to get the FCRMessage of providerAdminPublishDHTOfferRequest
+// MessageType returns the message type of ProviderAdminPublishDHTOfferRequest
+func (ProviderAdminPublishDHTOfferRequest) MessageType() int32 {
+	return ProviderAdminPublishDHTOfferRequestType
+}
+
+// Validate checks the fields of ProviderAdminPublishDHTOfferRequest
+func (m ProviderAdminPublishDHTOfferRequest) Validate() error {
+	return firstError(
+		validateCIDs("cids", m.CIDs),
+		validateLength("price", len(m.Price)),
+		validateLength("expiry", len(m.Expiry)),
+		validateLength("qos", len(m.QoS)),
+	)
+}
+
+// EncodeProviderAdminPublishDHTOfferRequest is used to get the FCRMessage of ProviderAdminPublishDHTOfferRequest
 func EncodeProviderAdminPublishDHTOfferRequest(
 	cids []cid.ContentID,
 	price []uint64,
 	expiry []int64,
 	qos []uint64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminPublishDHTOfferRequest{
+	return Encode(ProviderAdminPublishDHTOfferRequest{
 		CIDs:   cid.MapCIDToString(cids),
 		Price:  price,
 		Expiry: expiry,
 		QoS:    qos,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminPublishDHTOfferRequestType, body), nil
 }
 
-// DecodeProviderAdminPublishDHTOfferRequest is used to get the fields from FCRMessage of providerAdminPublishDHTOfferRequest
+// DecodeProviderAdminPublishDHTOfferRequest is used to get the fields from FCRMessage of ProviderAdminPublishDHTOfferRequest
 func DecodeProviderAdminPublishDHTOfferRequest(fcrMsg *FCRMessage) (
 	[]cid.ContentID, // cids
 	[]uint64, // price
@@ -60,10 +71,17 @@ func DecodeProviderAdminPublishDHTOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminPublishDHTOfferRequestType {
 		return nil, nil, nil, nil, errors.New("message type mismatch")
 	}
-	msg := providerAdminPublishDHTOfferRequest{}
+	msg := ProviderAdminPublishDHTOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	return cid.MapStringToCID(msg.CIDs), msg.Price, msg.Expiry, msg.QoS, nil
+	if err = msg.Validate(); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	cids, err := cid.MapStringToCID(msg.CIDs)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return cids, msg.Price, msg.Expiry, msg.QoS, nil
 }
@@ -20,25 +20,26 @@ import (
 	"errors"
 )
 
-// providerAdminPublishDHTOfferResponse is the response to providerAdminPublishDHTOfferRequest
-type providerAdminPublishDHTOfferResponse struct {
+// ProviderAdminPublishDHTOfferResponse is the response to ProviderAdminPublishDHTOfferRequest
+type ProviderAdminPublishDHTOfferResponse struct {
 	Received bool `json:"received"`
 }
 
-// EncodeProviderAdminPublishDHTOfferResponse is used to get the FCRMessage of providerAdminPublishDHTOfferResponse
+// MessageType returns the message type of ProviderAdminPublishDHTOfferResponse
+func (ProviderAdminPublishDHTOfferResponse) MessageType() int32 {
+	return ProviderAdminPublishDHTOfferResponseType
+}
+
+// EncodeProviderAdminPublishDHTOfferResponse is used to get the FCRMessage of ProviderAdminPublishDHTOfferResponse
 func EncodeProviderAdminPublishDHTOfferResponse(
 	received bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminPublishDHTOfferResponse{
+	return Encode(ProviderAdminPublishDHTOfferResponse{
 		Received: received,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminPublishDHTOfferResponseType, body), nil
 }
 
-// DecodeProviderAdminPublishDHTOfferResponse is used to get the fields from FCRMessage of providerAdminPublishDHTOfferResponse
+// DecodeProviderAdminPublishDHTOfferResponse is used to get the fields from FCRMessage of ProviderAdminPublishDHTOfferResponse
 func DecodeProviderAdminPublishDHTOfferResponse(fcrMsg *FCRMessage) (
 	bool, // received
 	error, // error
@@ -46,7 +47,7 @@ func DecodeProviderAdminPublishDHTOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminPublishDHTOfferResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := providerAdminPublishDHTOfferResponse{}
+	msg := ProviderAdminPublishDHTOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
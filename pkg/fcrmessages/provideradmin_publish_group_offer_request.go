@@ -22,34 +22,42 @@ import (
 	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
 )
 
-// providerAdminPublishGroupOfferRequest is the request to publish group CID
-type providerAdminPublishGroupOfferRequest struct {
+// ProviderAdminPublishGroupOfferRequest is the request to publish group CID
+type ProviderAdminPublishGroupOfferRequest struct {
 	CIDs   []string `json:"cids"`
 	Price  uint64   `json:"price"`
 	Expiry int64    `json:"expiry"`
 	QoS    uint64   `json:"qos"`
 }
 
-// EncodeProviderAdminPublishGroupOfferRequest is used to get the FCRMessage of providerAdminPublishGroupOfferRequest
+// MessageType returns the message type of ProviderAdminPublishGroupOfferRequest
+func (ProviderAdminPublishGroupOfferRequest) MessageType() int32 {
+	return ProviderAdminPublishGroupOfferRequestType
+}
+
+// Validate checks the fields of ProviderAdminPublishGroupOfferRequest
+func (m ProviderAdminPublishGroupOfferRequest) Validate() error {
+	return firstError(
+		validateCIDs("cids", m.CIDs),
+	)
+}
+
+// EncodeProviderAdminPublishGroupOfferRequest is used to get the FCRMessage of ProviderAdminPublishGroupOfferRequest
 func EncodeProviderAdminPublishGroupOfferRequest(
 	cids []cid.ContentID,
 	price uint64,
 	expiry int64,
 	qos uint64,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminPublishGroupOfferRequest{
+	return Encode(ProviderAdminPublishGroupOfferRequest{
 		CIDs:   cid.MapCIDToString(cids),
 		Price:  price,
 		Expiry: expiry,
 		QoS:    qos,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminPublishGroupOfferRequestType, body), nil
 }
 
-// DecodeProviderAdminPublishGroupOfferRequest is used to get the fields from FCRMessage of providerAdminPublishGroupOfferRequest
+// DecodeProviderAdminPublishGroupOfferRequest is used to get the fields from FCRMessage of ProviderAdminPublishGroupOfferRequest
 func DecodeProviderAdminPublishGroupOfferRequest(fcrMsg *FCRMessage) (
 	[]cid.ContentID, // cids
 	uint64, // price
@@ -60,10 +68,17 @@ func DecodeProviderAdminPublishGroupOfferRequest(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminPublishGroupOfferRequestType {
 		return nil, 0, 0, 0, errors.New("message type mismatch")
 	}
-	msg := providerAdminPublishGroupOfferRequest{}
+	msg := ProviderAdminPublishGroupOfferRequest{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return nil, 0, 0, 0, err
 	}
-	return cid.MapStringToCID(msg.CIDs), msg.Price, msg.Expiry, msg.QoS, nil
+	if err = msg.Validate(); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	cids, err := cid.MapStringToCID(msg.CIDs)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return cids, msg.Price, msg.Expiry, msg.QoS, nil
 }
@@ -20,25 +20,26 @@ import (
 	"errors"
 )
 
-// providerAdminPublishGroupOfferResponse is the response to providerAdminPublishGroupOfferRequest
-type providerAdminPublishGroupOfferResponse struct {
+// ProviderAdminPublishGroupOfferResponse is the response to ProviderAdminPublishGroupOfferRequest
+type ProviderAdminPublishGroupOfferResponse struct {
 	Received bool `json:"received"`
 }
 
-// EncodeProviderAdminPublishGroupOfferResponse is used to get the FCRMessage of providerAdminPublishGroupOfferResponse
+// MessageType returns the message type of ProviderAdminPublishGroupOfferResponse
+func (ProviderAdminPublishGroupOfferResponse) MessageType() int32 {
+	return ProviderAdminPublishGroupOfferResponseType
+}
+
+// EncodeProviderAdminPublishGroupOfferResponse is used to get the FCRMessage of ProviderAdminPublishGroupOfferResponse
 func EncodeProviderAdminPublishGroupOfferResponse(
 	received bool,
 ) (*FCRMessage, error) {
-	body, err := json.Marshal(providerAdminPublishGroupOfferResponse{
+	return Encode(ProviderAdminPublishGroupOfferResponse{
 		Received: received,
 	})
-	if err != nil {
-		return nil, err
-	}
-	return CreateFCRMessage(ProviderAdminPublishGroupOfferResponseType, body), nil
 }
 
-// DecodeProviderAdminPublishGroupOfferResponse is used to get the fields from FCRMessage of providerAdminPublishGroupOfferResponse
+// DecodeProviderAdminPublishGroupOfferResponse is used to get the fields from FCRMessage of ProviderAdminPublishGroupOfferResponse
 func DecodeProviderAdminPublishGroupOfferResponse(fcrMsg *FCRMessage) (
 	bool, // received
 	error, // error
@@ -46,7 +47,7 @@ func DecodeProviderAdminPublishGroupOfferResponse(fcrMsg *FCRMessage) (
 	if fcrMsg.GetMessageType() != ProviderAdminPublishGroupOfferResponseType {
 		return false, errors.New("message type mismatch")
 	}
-	msg := providerAdminPublishGroupOfferResponse{}
+	msg := ProviderAdminPublishGroupOfferResponse{}
 	err := json.Unmarshal(fcrMsg.GetMessageBody(), &msg)
 	if err != nil {
 		return false, err
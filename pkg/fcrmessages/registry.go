@@ -0,0 +1,178 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Typed is implemented by the body of every message type.
+type Typed interface {
+	// MessageType returns the message type constant of the message body.
+	MessageType() int32
+}
+
+// registeredMessage holds the name and the constructor of an empty message body for a message type.
+type registeredMessage struct {
+	name        string
+	constructor func() Typed
+}
+
+// registry maps a message type to its registered message.
+var registry = make(map[int32]registeredMessage)
+
+func init() {
+	registerMessage(ClientEstablishmentRequestType, func() Typed { return &ClientEstablishmentRequest{} })
+	registerMessage(ClientEstablishmentResponseType, func() Typed { return &ClientEstablishmentResponse{} })
+	registerMessage(ClientStandardDiscoverRequestType, func() Typed { return &ClientStandardDiscoverRequest{} })
+	registerMessage(ClientStandardDiscoverResponseType, func() Typed { return &ClientStandardDiscoverResponse{} })
+	registerMessage(ClientDHTDiscoverRequestType, func() Typed { return &ClientDHTDiscoverRequest{} })
+	registerMessage(ClientDHTDiscoverResponseType, func() Typed { return &ClientDHTDiscoverResponse{} })
+	registerMessage(ClientDHTOfferAckRequestType, func() Typed { return &ClientDHTOfferAckRequest{} })
+	registerMessage(ClientDHTOfferAckResponseType, func() Typed { return &ClientDHTOfferAckResponse{} })
+	registerMessage(ClientStandardDiscoverRequestV2Type, func() Typed { return &ClientStandardDiscoverRequestV2{} })
+	registerMessage(ClientStandardDiscoverResponseV2Type, func() Typed { return &ClientStandardDiscoverResponseV2{} })
+	registerMessage(ClientStandardDiscoverOfferRequestType, func() Typed { return &ClientStandardDiscoverOfferRequest{} })
+	registerMessage(ClientStandardDiscoverOfferResponseType, func() Typed { return &ClientStandardDiscoverOfferResponse{} })
+	registerMessage(ClientDHTDiscoverRequestV2Type, func() Typed { return &ClientDHTDiscoverRequestV2{} })
+	registerMessage(ClientDHTDiscoverResponseV2Type, func() Typed { return &ClientDHTDiscoverResponseV2{} })
+	registerMessage(ClientDHTDiscoverOfferRequestType, func() Typed { return &ClientDHTDiscoverOfferRequest{} })
+	registerMessage(ClientDHTDiscoverOfferResponseType, func() Typed { return &ClientDHTDiscoverOfferResponse{} })
+	registerMessage(GatewayListDHTOfferRequestType, func() Typed { return &GatewayListDHTOfferRequest{} })
+	registerMessage(GatewayListDHTOfferResponseType, func() Typed { return &GatewayListDHTOfferResponse{} })
+	registerMessage(GatewayListDHTOfferAckType, func() Typed { return &GatewayListDHTOfferAck{} })
+	registerMessage(GatewayDHTDiscoverRequestType, func() Typed { return &GatewayDHTDiscoverRequest{} })
+	registerMessage(GatewayDHTDiscoverResponseType, func() Typed { return &GatewayDHTDiscoverResponse{} })
+	registerMessage(GatewayPingRequestType, func() Typed { return &GatewayPingRequest{} })
+	registerMessage(GatewayPingResponseType, func() Typed { return &GatewayPingResponse{} })
+	registerMessage(GatewayNotifyProviderGroupCIDOfferSupportedRequestType, func() Typed { return &GatewayNotifyProviderGroupCIDOfferSupportRequest{} })
+	registerMessage(GatewayNotifyProviderGroupCIDOfferSupportedResponseType, func() Typed { return &GatewayNotifyProviderGroupCIDOfferSupportResponse{} })
+	registerMessage(GatewayDHTDiscoverRequestV2Type, func() Typed { return &GatewayDHTDiscoverRequestV2{} })
+	registerMessage(GatewayDHTDiscoverResponseV2Type, func() Typed { return &GatewayDHTDiscoverResponseV2{} })
+	registerMessage(GatewayDHTDiscoverOfferRequestType, func() Typed { return &GatewayDHTDiscoverOfferRequest{} })
+	registerMessage(GatewayDHTDiscoverOfferResponseType, func() Typed { return &GatewayDHTDiscoverOfferResponse{} })
+	registerMessage(ProviderPublishGroupOfferRequestType, func() Typed { return &ProviderPublishGroupOfferRequest{} })
+	registerMessage(ProviderPublishGroupOfferResponseType, func() Typed { return &ProviderPublishGroupOfferResponse{} })
+	registerMessage(ProviderPublishDHTOfferRequestType, func() Typed { return &ProviderPublishDHTOfferRequest{} })
+	registerMessage(ProviderPublishDHTOfferResponseType, func() Typed { return &ProviderPublishDHTOfferResponse{} })
+	registerMessage(GatewayAdminInitialiseKeyRequestType, func() Typed { return &GatewayAdminInitialiseKeyRequest{} })
+	registerMessage(GatewayAdminInitialiseKeyResponseType, func() Typed { return &GatewayAdminInitialiseKeyResponse{} })
+	registerMessage(GatewayAdminGetReputationRequestType, func() Typed { return &GatewayAdminGetReputationRequest{} })
+	registerMessage(GatewayAdminGetReputationResponseType, func() Typed { return &GatewayAdminGetReputationResponse{} })
+	registerMessage(GatewayAdminSetReputationRequestType, func() Typed { return &GatewayAdminSetReputationRequest{} })
+	registerMessage(GatewayAdminSetReputationResponseType, func() Typed { return &GatewayAdminSetReputationResponse{} })
+	registerMessage(GatewayAdminForceRefreshRequestType, func() Typed { return &GatewayAdminForceRefreshRequest{} })
+	registerMessage(GatewayAdminForceRefreshResponseType, func() Typed { return &GatewayAdminForceRefreshResponse{} })
+	registerMessage(GatewayAdminUpdateGatewayGroupCIDOfferSupportRequestType, func() Typed { return &UpdateGatewayGroupCIDOfferSupportRequest{} })
+	registerMessage(GatewayAdminUpdateGatewayGroupCIDOfferSupportResponseType, func() Typed { return &UpdateGatewayGroupCIDOfferSupportResponse{} })
+	registerMessage(GatewayAdminListDHTOfferRequestType, func() Typed { return &GatewayAdminListDHTOfferRequest{} })
+	registerMessage(GatewayAdminListDHTOfferResponseType, func() Typed { return &GatewayAdminListDHTOfferResponse{} })
+	registerMessage(GatewayAdminInitialiseKeyRequestV2Type, func() Typed { return &GatewayAdminInitialiseKeyRequestV2{} })
+	registerMessage(ProviderAdminInitialiseKeyRequestType, func() Typed { return &ProviderAdminInitialiseKeyRequest{} })
+	registerMessage(ProviderAdminInitialiseKeyResponseType, func() Typed { return &ProviderAdminInitialiseKeyResponse{} })
+	registerMessage(ProviderAdminPublishGroupOfferRequestType, func() Typed { return &ProviderAdminPublishGroupOfferRequest{} })
+	registerMessage(ProviderAdminPublishGroupOfferResponseType, func() Typed { return &ProviderAdminPublishGroupOfferResponse{} })
+	registerMessage(ProviderAdminPublishDHTOfferRequestType, func() Typed { return &ProviderAdminPublishDHTOfferRequest{} })
+	registerMessage(ProviderAdminPublishDHTOfferResponseType, func() Typed { return &ProviderAdminPublishDHTOfferResponse{} })
+	registerMessage(ProviderAdminGetPublishedOfferRequestType, func() Typed { return &ProviderAdminGetPublishedOfferRequest{} })
+	registerMessage(ProviderAdminGetPublishedOfferResponseType, func() Typed { return &ProviderAdminGetPublishedOfferResponse{} })
+	registerMessage(ProviderAdminForceRefreshRequestType, func() Typed { return &ProviderAdminForceRefreshRequest{} })
+	registerMessage(ProviderAdminForceRefreshResponseType, func() Typed { return &ProviderAdminForceRefreshResponse{} })
+	registerMessage(ProviderAdminInitialiseKeyRequestV2Type, func() Typed { return &ProviderAdminInitialiseKeyRequestV2{} })
+	registerMessage(ProtocolChangeRequestType, func() Typed { return &ProtocolChangeRequest{} })
+	registerMessage(ProtocolChangeResponseType, func() Typed { return &ProtocolChangeResponse{} })
+	registerMessage(InvalidMessageResponseType, func() Typed { return &InvalidMessageResponse{} })
+	registerMessage(AuthenticationRequestType, func() Typed { return &AuthenticationRequest{} })
+	registerMessage(AuthenticationResponseType, func() Typed { return &AuthenticationResponse{} })
+	registerMessage(AuthenticationProofType, func() Typed { return &AuthenticationProof{} })
+	registerMessage(AuthenticationAckType, func() Typed { return &AuthenticationAck{} })
+	registerMessage(StreamEndType, func() Typed { return &StreamEnd{} })
+	registerMessage(StreamWindowUpdateType, func() Typed { return &StreamWindowUpdate{} })
+}
+
+// registerMessage adds a constructor for a given message type to the registry.
+// It panics if the message type is already registered or does not match the message body.
+func registerMessage(msgType int32, constructor func() Typed) {
+	msg := constructor()
+	name := reflect.TypeOf(msg).Elem().Name()
+	if existing, ok := registry[msgType]; ok {
+		panic(fmt.Sprintf("duplicate message type %d: %s and %s", msgType, existing.name, name))
+	}
+	if msg.MessageType() != msgType {
+		panic(fmt.Sprintf("message type mismatch for %s: registered as %d, reports %d", name, msgType, msg.MessageType()))
+	}
+	registry[msgType] = registeredMessage{name: name, constructor: constructor}
+}
+
+// Message type classes, the class of a message type is its hundreds digit.
+const (
+	ClientMessageClass        = 1
+	GatewayMessageClass       = 2
+	ProviderMessageClass      = 3
+	GatewayAdminMessageClass  = 4
+	ProviderAdminMessageClass = 5
+	BasicMessageClass         = 9
+)
+
+// MessageClass returns the class of a given message type.
+func MessageClass(msgType int32) int32 {
+	return msgType / 100
+}
+
+// TypeName returns the name of a given message type, used for logging.
+func TypeName(msgType int32) string {
+	if msg, ok := registry[msgType]; ok {
+		return msg.name
+	}
+	return fmt.Sprintf("UnknownMessageType(%d)", msgType)
+}
+
+// NewTyped returns an empty message body for a given message type.
+func NewTyped(msgType int32) (Typed, error) {
+	msg, ok := registry[msgType]
+	if !ok {
+		return nil, fmt.Errorf("unknown message type: %d", msgType)
+	}
+	return msg.constructor(), nil
+}
+
+// Encode is used to get the FCRMessage of a given message body.
+func Encode(msg Typed) (*FCRMessage, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	return CreateFCRMessage(msg.MessageType(), body), nil
+}
+
+// Decode is used to get the message body of the FCRMessage.
+// The result is a pointer to the typed struct registered for the message type, e.g. *GatewayPingRequest.
+// The body is validated, a malformed field is reported as *ErrMalformedField.
+func (fcrMsg *FCRMessage) Decode() (interface{}, error) {
+	msg, err := NewTyped(fcrMsg.GetMessageType())
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(fcrMsg.GetMessageBody(), msg); err != nil {
+		return nil, err
+	}
+	if err = validate(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,46 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncodeDecodeTyped success test
+func TestEncodeDecodeTyped(t *testing.T) {
+	gatewayID, _ := nodeid.NewNodeIDFromHexString("42")
+	msg, err := Encode(GatewayAdminGetReputationRequest{ClientID: gatewayID.ToString()})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(GatewayAdminGetReputationRequestType), msg.GetMessageType())
+
+	decoded, err := msg.Decode()
+	assert.Empty(t, err)
+	assert.Equal(t, &GatewayAdminGetReputationRequest{ClientID: gatewayID.ToString()}, decoded)
+
+	clientID, err := DecodeGatewayAdminGetReputationRequest(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, gatewayID, clientID)
+}
+
+// TestDecodeTypedFromWrapper success test
+func TestDecodeTypedFromWrapper(t *testing.T) {
+	msg, err := EncodeProtocolChangeResponse(true)
+	assert.Empty(t, err)
+	decoded, err := msg.Decode()
+	assert.Empty(t, err)
+	response, ok := decoded.(*ProtocolChangeResponse)
+	assert.True(t, ok)
+	assert.True(t, response.Success)
+}
+
+// TestDecodeTypedUnknown error test
+func TestDecodeTypedUnknown(t *testing.T) {
+	msg := CreateFCRMessage(-1, []byte(`{}`))
+	_, err := msg.Decode()
+	assert.NotEmpty(t, err)
+
+	msg = CreateFCRMessage(GatewayPingResponseType, []byte(`{`))
+	_, err = msg.Decode()
+	assert.NotEmpty(t, err)
+}
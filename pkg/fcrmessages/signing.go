@@ -0,0 +1,60 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"math"
+)
+
+// Message signing payload.
+//
+// The signature of a message is the signature of the following payload, whatever the codec the message is sent with.
+// Version 1 layout, all integers are big-endian:
+//
+//	domain tag         11 bytes (ASCII "fcr-message")
+//	version            1 byte   (0x01)
+//	message type       4 bytes  (int32)
+//	protocol version   4 bytes  (int32)
+//	supported count    2 bytes  (uint16), followed by count * 4 bytes (int32)
+//	body length        4 bytes  (uint32), followed by body
+const (
+	messageSigningTag          = "fcr-message"
+	messageSigningVersion byte = 0x01
+)
+
+// signingPayload returns the payload signed for the message, the signature is not part of it.
+func (fcrMsg *FCRMessage) signingPayload() ([]byte, error) {
+	if len(fcrMsg.protocolSupported) > math.MaxUint16 {
+		return nil, errors.New("too many supported protocol versions")
+	}
+	if uint64(len(fcrMsg.messageBody)) > math.MaxUint32 {
+		return nil, errors.New("message body too long")
+	}
+	size := len(messageSigningTag) + 1 + 4 + 4 + 2 + 4*len(fcrMsg.protocolSupported) + 4 + len(fcrMsg.messageBody)
+	res := make([]byte, 0, size)
+	res = append(res, messageSigningTag...)
+	res = append(res, messageSigningVersion)
+	res = appendUint32(res, uint32(fcrMsg.messageType))
+	res = appendUint32(res, uint32(fcrMsg.protocolVersion))
+	res = appendUint16(res, uint16(len(fcrMsg.protocolSupported)))
+	for _, ver := range fcrMsg.protocolSupported {
+		res = appendUint32(res, uint32(ver))
+	}
+	res = appendUint32(res, uint32(len(fcrMsg.messageBody)))
+	res = append(res, fcrMsg.messageBody...)
+	return res, nil
+}
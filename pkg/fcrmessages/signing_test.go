@@ -0,0 +1,78 @@
+package fcrmessages
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of the message signing payload, signed with PrivKey and the initial key version.
+var messageSigningVectors = []struct {
+	msg       *FCRMessage
+	payload   string
+	signature string
+}{
+	{
+		msg:       &FCRMessage{messageType: 0, protocolVersion: 1},
+		payload:   "6663722d6d657373616765010000000000000001000000000000",
+		signature: "0000000182db5f368e9fe62e20692be4e6bb7c6c5c90aa33aca74d438570e7aa8fe3570c09f7b8a2ec3bd367f6e5fd61cabf438c2ec33e87919c37eafea114a42623a21a00",
+	},
+	{
+		msg: &FCRMessage{
+			messageType:       203,
+			protocolVersion:   1,
+			protocolSupported: []int32{1, 2},
+			messageBody:       []byte(`{"nonce":42}`),
+		},
+		payload:   "6663722d6d65737361676501000000cb00000001000200000001000000020000000c7b226e6f6e6365223a34327d",
+		signature: "000000010b9e6b23e360c9c8f58f8de0df578c36f2f092384e537a0b9d1d749ff85e702146df56553b61bd329ff5fdca8060251022645ea21e4034143b2c7ec790d80edd01",
+	},
+	{
+		msg: &FCRMessage{
+			messageType:       -1,
+			protocolVersion:   2,
+			protocolSupported: []int32{2},
+			messageBody:       []byte{0x00, 0xff},
+			signature:         "ignored",
+		},
+		payload:   "6663722d6d65737361676501ffffffff000000020001000000020000000200ff",
+		signature: "00000001a5da79bc92f8498416145f3f14d00522b4b443355d010b4fdd5e6d47ca1c72c1309c650e6b8789fc3d26a75f69260892cfe1d0d715092e182bde8923ec9e9f3501",
+	},
+}
+
+// TestSigningVectors success test
+func TestSigningVectors(t *testing.T) {
+	privKey, err := fcrcrypto.DecodePrivateKey(PrivKey)
+	assert.Empty(t, err)
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	for _, v := range messageSigningVectors {
+		payload, err := v.msg.MarshalToSign()
+		assert.Empty(t, err)
+		assert.Equal(t, v.payload, hex.EncodeToString(payload))
+
+		msg := *v.msg
+		assert.Empty(t, msg.Sign(privKey, fcrcrypto.InitialKeyVersion()))
+		assert.Equal(t, v.signature, msg.GetSignature())
+		assert.Empty(t, msg.Verify(pubKey))
+	}
+}
+
+// TestSigningCodecIndependent success test
+func TestSigningCodecIndependent(t *testing.T) {
+	privKey, err := fcrcrypto.DecodePrivateKey(PrivKey)
+	assert.Empty(t, err)
+	pubKey, err := fcrcrypto.DecodePublicKey(PubKey)
+	assert.Empty(t, err)
+	msg := CreateFCRMessage(203, []byte(`{"nonce":42}`))
+	assert.Empty(t, msg.Sign(privKey, fcrcrypto.InitialKeyVersion()))
+	for _, codec := range []Codec{CodecJSON, CodecBinary} {
+		data, err := msg.FCRMsgToBytesWithCodec(codec)
+		assert.Empty(t, err)
+		decoded, err := FCRMsgFromBytes(data)
+		assert.Empty(t, err)
+		assert.Empty(t, decoded.Verify(pubKey))
+	}
+}
@@ -42,14 +42,23 @@ const (
 	GatewayListDHTOfferAckType                              = 202
 	GatewayDHTDiscoverRequestType                           = 203
 	GatewayDHTDiscoverResponseType                          = 204
-	GatewayPingRequestType                                  = 205
-	GatewayPingResponseType                                 = 206
 	GatewayNotifyProviderGroupCIDOfferSupportedRequestType  = 205
 	GatewayNotifyProviderGroupCIDOfferSupportedResponseType = 206
 	GatewayDHTDiscoverRequestV2Type                         = 207
 	GatewayDHTDiscoverResponseV2Type                        = 208
 	GatewayDHTDiscoverOfferRequestType                      = 209
 	GatewayDHTDiscoverOfferResponseType                     = 210
+	GatewayPingRequestType                                  = 211
+	GatewayPingResponseType                                 = 212
+)
+
+// Compatibility aliases for message type codes that have been reassigned.
+// They are not registered and must not be used for new messages.
+const (
+	// Deprecated: GatewayPingRequestTypeLegacy collides with GatewayNotifyProviderGroupCIDOfferSupportedRequestType, use GatewayPingRequestType.
+	GatewayPingRequestTypeLegacy = 205
+	// Deprecated: GatewayPingResponseTypeLegacy collides with GatewayNotifyProviderGroupCIDOfferSupportedResponseType, use GatewayPingResponseType.
+	GatewayPingResponseTypeLegacy = 206
 )
 
 // Message types originating from Retrieval Provider
@@ -97,4 +106,10 @@ const (
 	ProtocolChangeRequestType  = 900
 	ProtocolChangeResponseType = 901
 	InvalidMessageResponseType = 902
+	AuthenticationRequestType  = 903
+	AuthenticationResponseType = 904
+	AuthenticationProofType    = 905
+	AuthenticationAckType      = 906
+	StreamEndType              = 907
+	StreamWindowUpdateType     = 908
 )
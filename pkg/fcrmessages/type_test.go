@@ -0,0 +1,53 @@
+package fcrmessages
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMessageTypesUnique checks every message type constant has a unique code and is registered
+func TestMessageTypesUnique(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "type.go", nil, 0)
+	assert.Empty(t, err)
+
+	seen := make(map[int64]string)
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			name := valueSpec.Names[0].Name
+			if strings.HasSuffix(name, "Legacy") {
+				continue
+			}
+			value, err := strconv.ParseInt(valueSpec.Values[0].(*ast.BasicLit).Value, 10, 32)
+			assert.Empty(t, err)
+			if existing, ok := seen[value]; ok {
+				t.Errorf("message type %d used by both %s and %s", value, existing, name)
+			}
+			seen[value] = name
+			_, err = NewTyped(int32(value))
+			assert.Empty(t, err, "message type %s is not registered", name)
+		}
+	}
+	assert.Equal(t, len(registry), len(seen))
+}
+
+// TestRegisterDuplicate error test
+func TestRegisterDuplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		registerMessage(GatewayPingRequestType, func() Typed { return &GatewayPingRequest{} })
+	})
+	assert.Panics(t, func() {
+		registerMessage(-1, func() Typed { return &GatewayPingRequest{} })
+	})
+	assert.Equal(t, "UnknownMessageType(-1)", TypeName(-1))
+}
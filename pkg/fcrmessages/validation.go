@@ -0,0 +1,159 @@
+package fcrmessages
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/cid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+const defaultMaxSliceLength = 100000
+
+var maxSliceLength = defaultMaxSliceLength
+
+// ErrMalformedField is returned when a field of a message body fails validation.
+type ErrMalformedField struct {
+	Field  string
+	Reason string
+}
+
+// Error returns the error message.
+func (e *ErrMalformedField) Error() string {
+	return fmt.Sprintf("malformed field %s: %s", e.Field, e.Reason)
+}
+
+// Validator is implemented by message bodies that check their fields after decoding.
+type Validator interface {
+	// Validate returns an *ErrMalformedField for the first invalid field.
+	Validate() error
+}
+
+// GetMaxSliceLength gets the maximum number of elements allowed in a slice field of a message.
+func GetMaxSliceLength() int {
+	return maxSliceLength
+}
+
+// SetMaxSliceLength sets the maximum number of elements allowed in a slice field of a message.
+func SetMaxSliceLength(max int) {
+	maxSliceLength = max
+}
+
+// validate runs the validation of a given message body if it has any.
+func validate(msg interface{}) error {
+	if v, ok := msg.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// firstError returns the first non nil error.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateHexID checks a field is a non empty hex string that fits in size bytes.
+func validateHexID(field string, value string, size int) error {
+	if value == "" {
+		return &ErrMalformedField{Field: field, Reason: "required"}
+	}
+	if len(value) > size*2 {
+		return &ErrMalformedField{Field: field, Reason: fmt.Sprintf("longer than %d bytes", size)}
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return &ErrMalformedField{Field: field, Reason: "invalid hex string"}
+	}
+	return nil
+}
+
+// validateCID checks a field holds a multiformat CID or a hex encoded legacy content id.
+func validateCID(field string, value string) error {
+	err := validateHexID(field, value, cid.WordSize)
+	if err == nil || value == "" {
+		return err
+	}
+	if _, cidErr := cid.NewContentIDFromString(value); cidErr == nil {
+		return nil
+	}
+	return err
+}
+
+// validateDHTKey checks a field holds a hex encoded position on the DHT ring.
+func validateDHTKey(field string, value string) error {
+	return validateHexID(field, value, cid.WordSize)
+}
+
+// validateCIDs checks a field holds a bounded list of content ids.
+func validateCIDs(field string, values []string) error {
+	if err := validateLength(field, len(values)); err != nil {
+		return err
+	}
+	for i, value := range values {
+		if err := validateCID(fmt.Sprintf("%s[%d]", field, i), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNodeID checks a field holds a hex encoded node id.
+func validateNodeID(field string, value string) error {
+	return validateHexID(field, value, nodeid.WordSize)
+}
+
+// validateNodeIDs checks a field holds a bounded list of hex encoded node ids.
+func validateNodeIDs(field string, values []string) error {
+	if err := validateLength(field, len(values)); err != nil {
+		return err
+	}
+	for i, value := range values {
+		if err := validateNodeID(fmt.Sprintf("%s[%d]", field, i), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRequired checks a string field is not empty.
+func validateRequired(field string, value string) error {
+	if value == "" {
+		return &ErrMalformedField{Field: field, Reason: "required"}
+	}
+	return nil
+}
+
+// validateNonNegative checks a numeric field is not negative.
+func validateNonNegative(field string, value int64) error {
+	if value < 0 {
+		return &ErrMalformedField{Field: field, Reason: "negative value"}
+	}
+	return nil
+}
+
+// validateLength checks a slice field does not exceed the maximum length.
+func validateLength(field string, length int) error {
+	if length > maxSliceLength {
+		return &ErrMalformedField{Field: field, Reason: fmt.Sprintf("more than %d elements", maxSliceLength)}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package fcrmessages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDecodeValidationError error test
+func TestDecodeValidationError(t *testing.T) {
+	msg := CreateFCRMessage(ClientStandardDiscoverRequestV2Type, []byte(`{"piece_cid":"not hex","nonce":1,"ttl":1}`))
+	_, _, _, _, _, err := DecodeClientStandardDiscoverRequestV2(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "piece_cid", Reason: "invalid hex string"}, err)
+
+	msg = CreateFCRMessage(ClientStandardDiscoverRequestV2Type, []byte(`{"nonce":1,"ttl":1}`))
+	_, err = msg.Decode()
+	assert.Equal(t, &ErrMalformedField{Field: "piece_cid", Reason: "required"}, err)
+
+	msg = CreateFCRMessage(GatewayPingRequestType, []byte(`{"gateway_id":"42","nonce":-1,"ttl":1}`))
+	_, _, _, err = DecodeGatewayPingRequest(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "nonce", Reason: "negative value"}, err)
+
+	msg = CreateFCRMessage(GatewayPingRequestType, []byte(`{"gateway_id":"0000000000000000000000000000000000000000000000000000000000000000ff","nonce":1,"ttl":1}`))
+	_, _, _, err = DecodeGatewayPingRequest(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "gateway_id", Reason: "longer than 32 bytes"}, err)
+}
+
+// TestDecodeValidationPieceCID success test
+func TestDecodeValidationPieceCID(t *testing.T) {
+	pieceCID := "baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq"
+	msg := CreateFCRMessage(ClientStandardDiscoverRequestV2Type, []byte(`{"piece_cid":"`+pieceCID+`","nonce":1,"ttl":1}`))
+	contentID, _, _, _, _, err := DecodeClientStandardDiscoverRequestV2(msg)
+	assert.Empty(t, err)
+	assert.Equal(t, pieceCID, contentID.ToString())
+
+	msg = CreateFCRMessage(GatewayListDHTOfferRequestType, []byte(`{"gateway_id":"01","cid_min":"`+pieceCID+`","cid_max":"ff"}`))
+	_, _, _, _, _, _, _, err = DecodeGatewayListDHTOfferRequest(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "cid_min", Reason: "invalid hex string"}, err)
+}
+
+// TestDecodeValidationSliceLength error test
+func TestDecodeValidationSliceLength(t *testing.T) {
+	defer SetMaxSliceLength(GetMaxSliceLength())
+	SetMaxSliceLength(1)
+
+	msg := CreateFCRMessage(ProviderAdminGetPublishedOfferRequestType, []byte(`{"gateway_id":["01"]}`))
+	_, err := DecodeProviderAdminGetPublishedOfferRequest(msg)
+	assert.Empty(t, err)
+
+	msg = CreateFCRMessage(ProviderAdminGetPublishedOfferRequestType, []byte(`{"gateway_id":["01","02"]}`))
+	_, err = DecodeProviderAdminGetPublishedOfferRequest(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "gateway_id", Reason: "more than 1 elements"}, err)
+
+	msg = CreateFCRMessage(ProviderAdminGetPublishedOfferRequestType, []byte(`{"gateway_id":["xx"]}`))
+	_, err = DecodeProviderAdminGetPublishedOfferRequest(msg)
+	assert.Equal(t, &ErrMalformedField{Field: "gateway_id[0]", Reason: "invalid hex string"}, err)
+}
@@ -0,0 +1,206 @@
+package fcrmiddleware
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// bucketSweepInterval is the interval at which the buckets of peers without recent requests are dropped.
+const bucketSweepInterval = time.Minute
+
+// Limiter limits the number of requests handled concurrently and the rate of requests of every peer.
+// Requests over a limit are rejected with InvalidMessageOverloaded or InvalidMessageRateLimited,
+// the peer connection is kept. A new Limiter has no limit, the same Limiter can be used by several servers
+// to share the limits.
+type Limiter struct {
+	lock sync.Mutex
+
+	// maxConcurrent is the maximum number of requests handled at once, 0 means no limit.
+	// maxConcurrentByType overrides it for given message types.
+	maxConcurrent       int
+	maxConcurrentByType map[int32]int
+	running             int
+	runningByType       map[int32]int
+
+	// rate is the number of requests per second a peer is allowed on average, 0 means no limit.
+	// burst is the number of requests a peer is allowed at once.
+	rate      float64
+	burst     int
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// tokenBucket holds the requests a peer is allowed at a given time.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter creates a Limiter without limit.
+func NewLimiter() *Limiter {
+	return &Limiter{
+		maxConcurrentByType: make(map[int32]int),
+		runningByType:       make(map[int32]int),
+		buckets:             make(map[string]*tokenBucket),
+		now:                 time.Now,
+	}
+}
+
+// SetMaxConcurrent is used to limit the number of requests handled at once, 0 means no limit.
+func (l *Limiter) SetMaxConcurrent(max int) *Limiter {
+	if max < 0 {
+		return l
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.maxConcurrent = max
+	return l
+}
+
+// SetMaxConcurrentForType is used to limit the number of requests of a given message type handled at once.
+// Requests of the type also count towards the global limit, 0 means no limit for the type.
+func (l *Limiter) SetMaxConcurrentForType(msgType int32, max int) *Limiter {
+	if max < 0 {
+		return l
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.maxConcurrentByType[msgType] = max
+	return l
+}
+
+// SetRate is used to limit the requests of every peer to a given number per second on average, allowing bursts of
+// a given number of requests. Peers are identified by their node id once authenticated, by their IP address otherwise.
+// A rate of 0 means no limit.
+func (l *Limiter) SetRate(perSecond float64, burst int) *Limiter {
+	if perSecond < 0 || burst < 1 {
+		return l
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.rate = perSecond
+	l.burst = burst
+	l.buckets = make(map[string]*tokenBucket)
+	return l
+}
+
+// Middleware returns the middleware applying the limits to every request.
+func (l *Limiter) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(request *Request) error {
+			msgType := request.Message.GetMessageType()
+			if !l.allow(PeerKey(request)) {
+				return Reject(fcrmessages.InvalidMessageRateLimited, "too many requests, slow down")
+			}
+			if !l.acquire(msgType) {
+				return Reject(fcrmessages.InvalidMessageOverloaded, "too many requests being handled, retry later")
+			}
+			defer l.release(msgType)
+			return next(request)
+		}
+	}
+}
+
+// PeerKey returns the key identifying the peer of a given request: its node id if it has been authenticated,
+// the IP address it connects from otherwise.
+func PeerKey(request *Request) string {
+	if request.PeerID != nil {
+		return "node:" + request.PeerID.ToString()
+	}
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// allow takes a token from the bucket of a given peer, it returns false if the bucket is empty.
+func (l *Limiter) allow(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.rate == 0 {
+		return true
+	}
+	now := l.now()
+	l.sweep(now)
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// refill adds the tokens earned by a given bucket until a given time. The lock must be held.
+func (l *Limiter) refill(bucket *tokenBucket, now time.Time) {
+	bucket.tokens += now.Sub(bucket.updated).Seconds() * l.rate
+	if bucket.tokens > float64(l.burst) {
+		bucket.tokens = float64(l.burst)
+	}
+	bucket.updated = now
+}
+
+// sweep drops the full buckets, which are the ones of peers without recent requests. The lock must be held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// acquire counts a request of a given type being handled, it returns false if a concurrency limit is reached.
+func (l *Limiter) acquire(msgType int32) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.maxConcurrent > 0 && l.running >= l.maxConcurrent {
+		return false
+	}
+	if max, ok := l.maxConcurrentByType[msgType]; ok && max > 0 && l.runningByType[msgType] >= max {
+		return false
+	}
+	l.running++
+	l.runningByType[msgType]++
+	return true
+}
+
+// release counts a request of a given type handled.
+func (l *Limiter) release(msgType int32) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.running--
+	if l.runningByType[msgType]--; l.runningByType[msgType] == 0 {
+		delete(l.runningByType, msgType)
+	}
+}
@@ -0,0 +1,108 @@
+package fcrmiddleware
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// rejectCode returns the code of a given rejection, -1 if the request has not been rejected.
+func rejectCode(err error) fcrmessages.InvalidMessageCode {
+	if rejected, ok := err.(*RejectError); ok {
+		return rejected.Code
+	}
+	return -1
+}
+
+func TestLimiterRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter := NewLimiter().SetRate(2, 2)
+	limiter.now = func() time.Time { return now }
+	handler := Chain(func(request *Request) error { return nil }, limiter.Middleware())
+	request := func(remoteAddr string) error {
+		return handler(&Request{RemoteAddr: remoteAddr, Message: fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, nil)})
+	}
+
+	// Burst allowed, then rejected.
+	assert.Empty(t, request("10.0.0.1:1000"))
+	assert.Empty(t, request("10.0.0.1:1001"))
+	assert.Equal(t, fcrmessages.InvalidMessageRateLimited, rejectCode(request("10.0.0.1:1002")))
+
+	// Other peers have their own bucket.
+	assert.Empty(t, request("10.0.0.2:1000"))
+
+	// Tokens are earned back over time.
+	now = now.Add(500 * time.Millisecond)
+	assert.Empty(t, request("10.0.0.1:1000"))
+	assert.Equal(t, fcrmessages.InvalidMessageRateLimited, rejectCode(request("10.0.0.1:1000")))
+
+	// Idle peers are forgotten.
+	now = now.Add(bucketSweepInterval)
+	assert.Empty(t, request("10.0.0.1:1000"))
+	assert.Equal(t, 1, len(limiter.buckets))
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	limiter := NewLimiter().SetMaxConcurrent(2).SetMaxConcurrentForType(fcrmessages.GatewayPingRequestType, 1)
+	started := make(chan bool)
+	release := make(chan bool)
+	handler := Chain(func(request *Request) error {
+		if request.Message.GetMessageBody() != nil {
+			// Block until released.
+			started <- true
+			<-release
+		}
+		return nil
+	}, limiter.Middleware())
+	request := func(msgType int32, block bool) error {
+		var body []byte
+		if block {
+			body = []byte(`{}`)
+		}
+		return handler(&Request{Message: fcrmessages.CreateFCRMessage(msgType, body)})
+	}
+	done := make(chan error, 2)
+	go func() { done <- request(fcrmessages.GatewayPingRequestType, true) }()
+	<-started
+
+	// Type limit reached, other types are limited by the global limit only.
+	assert.Equal(t, fcrmessages.InvalidMessageOverloaded, rejectCode(request(fcrmessages.GatewayPingRequestType, false)))
+	go func() { done <- request(fcrmessages.GatewayListDHTOfferAckType, true) }()
+	<-started
+	assert.Equal(t, fcrmessages.InvalidMessageOverloaded, rejectCode(request(fcrmessages.GatewayListDHTOfferAckType, false)))
+
+	// Limits are released once the requests are handled.
+	close(release)
+	assert.Empty(t, <-done)
+	assert.Empty(t, <-done)
+	assert.Empty(t, request(fcrmessages.GatewayPingRequestType, false))
+	assert.Equal(t, 0, limiter.running)
+	assert.Empty(t, limiter.runningByType)
+}
+
+func TestPeerKey(t *testing.T) {
+	id, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	assert.Equal(t, "ip:10.0.0.1", PeerKey(&Request{RemoteAddr: "10.0.0.1:1000"}))
+	assert.Equal(t, "ip:pipe", PeerKey(&Request{RemoteAddr: "pipe"}))
+	assert.Equal(t, "node:"+id.ToString(), PeerKey(&Request{RemoteAddr: "10.0.0.1:1000", PeerID: id}))
+}
@@ -0,0 +1,77 @@
+package fcrmiddleware
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// Transports a request can come from.
+const (
+	TransportP2P  = "p2p"
+	TransportREST = "rest"
+)
+
+// Request is an incoming request passed through the middleware chain of a server.
+type Request struct {
+	// Context of the request, it is cancelled when the request is abandoned.
+	Context context.Context
+	// Transport is the server the request comes from, TransportP2P or TransportREST.
+	Transport string
+	// ListenAddr is the address the request has been received on.
+	ListenAddr string
+	// RemoteAddr is the address of the peer.
+	RemoteAddr string
+	// PeerID is the node id of the peer if it has been authenticated, nil otherwise.
+	PeerID *nodeid.NodeID
+	// Message is the request message.
+	Message *fcrmessages.FCRMessage
+}
+
+// Handler handles a request. The handler registered for the message type is called at the end of the chain.
+type Handler func(request *Request) error
+
+// Middleware wraps a handler to run code before and after it.
+type Middleware func(next Handler) Handler
+
+// Chain wraps a given handler with given middlewares, the first middleware is the outermost one.
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// RejectError is returned by a middleware or a handler to reject a request.
+// The server responds with an invalid message response carrying the code and the reason, and keeps serving the peer.
+type RejectError struct {
+	Code   fcrmessages.InvalidMessageCode
+	Reason string
+}
+
+// Error returns the error message.
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("request rejected (%s): %s", e.Code, e.Reason)
+}
+
+// Reject creates an error rejecting a request with a given code and reason.
+func Reject(code fcrmessages.InvalidMessageCode, reason string) error {
+	return &RejectError{Code: code, Reason: reason}
+}
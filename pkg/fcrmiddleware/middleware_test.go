@@ -0,0 +1,68 @@
+package fcrmiddleware
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+func TestChain(t *testing.T) {
+	calls := make([]string, 0)
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(request *Request) error {
+				calls = append(calls, name+" before")
+				err := next(request)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	handler := Chain(func(request *Request) error {
+		calls = append(calls, "handler")
+		return nil
+	}, record("first"), record("second"))
+	assert.Empty(t, handler(&Request{}))
+	assert.Equal(t, []string{"first before", "second before", "handler", "second after", "first after"}, calls)
+}
+
+func TestChainWithoutMiddleware(t *testing.T) {
+	err := Chain(func(request *Request) error {
+		return errors.New("handler error")
+	})(&Request{})
+	assert.EqualError(t, err, "handler error")
+}
+
+func TestReject(t *testing.T) {
+	handler := Chain(func(request *Request) error {
+		t.Fatal("handler should not be called")
+		return nil
+	}, func(next Handler) Handler {
+		return func(request *Request) error {
+			return Reject(fcrmessages.InvalidMessageSignature, "not allowed")
+		}
+	})
+	err := handler(&Request{})
+	rejected, ok := err.(*RejectError)
+	assert.True(t, ok)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, rejected.Code)
+	assert.Equal(t, "not allowed", rejected.Reason)
+}
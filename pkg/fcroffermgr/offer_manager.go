@@ -59,7 +59,7 @@ func (mgr *FCROfferMgr) AddDHTOffer(offer *cidoffer.CIDOffer) error {
 	if len(offer.GetCIDs()) != 1 {
 		return errors.New("not a DHT offer")
 	}
-	mgr.dhtOfferRing.Insert(offer.GetCIDs()[0].ToString())
+	mgr.dhtOfferRing.Insert(offer.GetCIDs()[0].ToDHTKey())
 	return mgr.dhtOffers.add(offer)
 }
 
@@ -79,7 +79,7 @@ func (mgr *FCROfferMgr) GetDHTOffers(cid *cid.ContentID) ([]cidoffer.CIDOffer, b
 func (mgr *FCROfferMgr) GetDHTOffersWithinRange(cidMin, cidMax *cid.ContentID, maxOffers int) ([]cidoffer.CIDOffer, bool) {
 	offers := make([]cidoffer.CIDOffer, 0)
 
-	entries, err := mgr.dhtOfferRing.GetWithinRange(cidMin.ToString(), cidMax.ToString())
+	entries, err := mgr.dhtOfferRing.GetWithinRange(cidMin.ToDHTKey(), cidMax.ToDHTKey())
 	if err != nil {
 		return offers, false
 	}
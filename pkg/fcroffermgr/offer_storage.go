@@ -31,7 +31,7 @@ type digestOffer struct {
 
 // offerStorage stores all cid offers.
 type offerStorage struct {
-	// map from cid -> (digest, offer)
+	// map from cid dht key -> (digest, offer)
 	cidMap map[string]*digestOffer
 	lock   sync.RWMutex
 }
@@ -51,7 +51,7 @@ func (o *offerStorage) add(newOffer *cidoffer.CIDOffer) error {
 	if len(testCIDs) == 0 {
 		return errors.New("this offer has no cid")
 	}
-	testCIDStr := testCIDs[0].ToString()
+	testCIDStr := testCIDs[0].ToDHTKey()
 	digest := newOffer.GetMessageDigest()
 	o.lock.RLock()
 	digestMap, exists := o.cidMap[testCIDStr]
@@ -71,7 +71,7 @@ func (o *offerStorage) add(newOffer *cidoffer.CIDOffer) error {
 	}
 
 	for _, contentID := range newOffer.GetCIDs() {
-		cidStr := contentID.ToString()
+		cidStr := contentID.ToDHTKey()
 		o.lock.RLock()
 		digestMap, exists = o.cidMap[cidStr]
 		o.lock.RUnlock()
@@ -96,7 +96,7 @@ func (o *offerStorage) add(newOffer *cidoffer.CIDOffer) error {
 // get returns a list of offers that contains piece cid. It only returns offers that are yet expired.
 func (o *offerStorage) get(cid *cid.ContentID) []cidoffer.CIDOffer {
 	res := make([]cidoffer.CIDOffer, 0)
-	cidStr := cid.ToString()
+	cidStr := cid.ToDHTKey()
 
 	o.lock.RLock()
 	digestMap, exists := o.cidMap[cidStr]
@@ -121,7 +121,7 @@ func (o *offerStorage) get(cid *cid.ContentID) []cidoffer.CIDOffer {
 	for _, offer := range toRemove {
 		digest := offer.GetMessageDigest()
 		for _, contentID := range offer.GetCIDs() {
-			digestMap, exists := o.cidMap[contentID.ToString()]
+			digestMap, exists := o.cidMap[contentID.ToDHTKey()]
 			if !exists {
 				// Something is wrong
 				panic("Internal error: Try to remove cid offer that are not existed in cid map")
@@ -0,0 +1,243 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/challenge"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrregistermgr"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// Mutual authentication handshake, run on every connection before any request:
+//
+//	dialer    AuthenticationRequest  (dialer node id, dialer challenge)
+//	listener  AuthenticationResponse (listener node id, listener signature of the dialer challenge, listener challenge)
+//	dialer    AuthenticationProof    (dialer signature of the listener challenge)
+//	listener  AuthenticationAck
+//
+// Signatures are checked against the signing keys registered for the node ids, and cover the challenge and both node ids
+// so that they can not be replayed to another peer. They also cover the role of the signer, so that a signature of
+// a listener can not be relayed as the proof of a dialer. A failed authentication is answered with an invalid message
+// response and the connection is dropped.
+
+// errNotAuthenticated is returned when a request is received before the peer has been authenticated.
+var errNotAuthenticated = errors.New("peer not authenticated")
+
+// authenticator authenticates connections with the node id and the signing key of this node.
+type authenticator struct {
+	nodeID      *nodeid.NodeID
+	keyPair     *fcrcrypto.KeyPair
+	keyVersion  *fcrcrypto.KeyVersion
+	registerMgr *fcrregistermgr.FCRRegisterMgr
+}
+
+// Roles of the signer of a challenge, the listener signs in its response and the dialer signs in its proof.
+const (
+	authRoleResponse = "response"
+	authRoleProof    = "proof"
+)
+
+// authPayload returns the data signed by a given signer in a given role to answer a given challenge of a given peer.
+func authPayload(role string, challenge string, signer *nodeid.NodeID, peer *nodeid.NodeID) []byte {
+	return []byte(fmt.Sprintf("fcr-p2p-auth-%s:%s:%s:%s", role, challenge, signer.ToString(), peer.ToString()))
+}
+
+// sign signs a given challenge of a given peer in a given role.
+func (a *authenticator) sign(role string, challenge string, peer *nodeid.NodeID) (string, error) {
+	return fcrcrypto.SignMessage(a.keyPair, a.keyVersion, authPayload(role, challenge, a.nodeID, peer))
+}
+
+// verify verifies the signature of a given challenge of this node by a given peer in a given role with a given signing key.
+func (a *authenticator) verify(peerKey *fcrcrypto.KeyPair, signature string, role string, challenge string, peer *nodeid.NodeID) error {
+	ok, err := fcrcrypto.VerifyMessage(peerKey, signature, authPayload(role, challenge, peer, a.nodeID))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("authentication signature can not be verified")
+	}
+	return nil
+}
+
+// peerKey returns the signing key registered for a given gateway or provider.
+func (a *authenticator) peerKey(id *nodeid.NodeID) (*fcrcrypto.KeyPair, error) {
+	return registeredSigningKey(a.registerMgr, id)
+}
+
+// registeredSigningKey returns the signing key registered in a given register manager for a given gateway or provider.
+// The registered nodes are searched without refreshing the register, so that unknown peers can not trigger refreshes.
+func registeredSigningKey(registerMgr *fcrregistermgr.FCRRegisterMgr, id *nodeid.NodeID) (*fcrcrypto.KeyPair, error) {
+	if registerMgr == nil {
+		return nil, errors.New("no register to authenticate peers")
+	}
+	for _, gateway := range registerMgr.GetAllGateways() {
+		if sameNodeID(gateway.GetNodeID(), id) {
+			return gateway.GetSigningKey()
+		}
+	}
+	for _, provider := range registerMgr.GetAllProviders() {
+		if sameNodeID(provider.GetNodeID(), id) {
+			return provider.GetSigningKey()
+		}
+	}
+	return nil, fmt.Errorf("node %s is not registered", id.ToString())
+}
+
+// sameNodeID checks if a given hex encoded node id is a given node id.
+func sameNodeID(hexID string, id *nodeid.NodeID) bool {
+	other, err := nodeid.NewNodeIDFromHexString(hexID)
+	return err == nil && other.ToString() == id.ToString()
+}
+
+// authenticate runs the handshake with a given peer having a given signing key, as the dialer.
+func (a *authenticator) authenticate(ctx context.Context, writer *FCRServerWriter, reader *FCRServerReader, peer *nodeid.NodeID, peerKey *fcrcrypto.KeyPair) error {
+	ownChallenge := challenge.NewRandomChallenge()
+	request, err := fcrmessages.EncodeAuthenticationRequest(a.nodeID, ownChallenge)
+	if err != nil {
+		return err
+	}
+	response, err := a.exchange(ctx, writer, reader, request, fcrmessages.AuthenticationResponseType)
+	if err != nil {
+		return err
+	}
+	peerID, signature, peerChallenge, err := fcrmessages.DecodeAuthenticationResponse(response)
+	if err != nil {
+		return err
+	}
+	if peerID.ToString() != peer.ToString() {
+		return fmt.Errorf("peer authenticates as %s instead of %s", peerID.ToString(), peer.ToString())
+	}
+	if err = a.verify(peerKey, signature, authRoleResponse, ownChallenge, peer); err != nil {
+		return err
+	}
+	signature, err = a.sign(authRoleProof, peerChallenge, peer)
+	if err != nil {
+		return err
+	}
+	proof, err := fcrmessages.EncodeAuthenticationProof(signature)
+	if err != nil {
+		return err
+	}
+	_, err = a.exchange(ctx, writer, reader, proof, fcrmessages.AuthenticationAckType)
+	return err
+}
+
+// exchange writes a given handshake message and reads the response of a given type.
+func (a *authenticator) exchange(ctx context.Context, writer *FCRServerWriter, reader *FCRServerReader, request *fcrmessages.FCRMessage, responseType int32) (*fcrmessages.FCRMessage, error) {
+	if err := writer.WriteContext(ctx, request); err != nil {
+		return nil, err
+	}
+	response, err := reader.ReadContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if response.GetMessageType() == fcrmessages.InvalidMessageResponseType {
+		_, reason, _, _, _ := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+		return nil, fmt.Errorf("peer rejected authentication: %s", reason)
+	}
+	if response.GetMessageType() != responseType {
+		return nil, fmt.Errorf("unexpected message %s during authentication", fcrmessages.TypeName(response.GetMessageType()))
+	}
+	return response, nil
+}
+
+// isHandshakeMessage checks if a given message is sent by the dialer during the authentication handshake.
+func isHandshakeMessage(message *fcrmessages.FCRMessage) bool {
+	return message.GetMessageType() == fcrmessages.AuthenticationRequestType ||
+		message.GetMessageType() == fcrmessages.AuthenticationProofType
+}
+
+// handshake holds the state of the authentication of an incoming connection, as the listener.
+// encryptedPeer is the node id of the peer proven by the certificate of an encrypted connection, nil otherwise.
+// A nil handshake means authentication is disabled.
+type handshake struct {
+	auth          *authenticator
+	encryptedPeer *nodeid.NodeID
+	challenge     string
+	peer          *nodeid.NodeID
+	peerKey       *fcrcrypto.KeyPair
+	done          bool
+}
+
+// newHandshake starts the authentication of an incoming connection encrypted for a given peer, which is nil if
+// the connection is not encrypted. It returns nil if authentication is disabled.
+func newHandshake(auth *authenticator, encryptedPeer *nodeid.NodeID) *handshake {
+	if auth == nil {
+		return nil
+	}
+	return &handshake{auth: auth, encryptedPeer: encryptedPeer}
+}
+
+// isDone checks if the peer has been authenticated, it is always true if authentication is disabled.
+func (h *handshake) isDone() bool {
+	return h == nil || h.done
+}
+
+// getPeerID returns the node id of the peer once it has been authenticated, nil otherwise.
+func (h *handshake) getPeerID() *nodeid.NodeID {
+	if h == nil || !h.done {
+		return nil
+	}
+	return h.peer
+}
+
+// handle handles a given handshake message and returns the response to send.
+// It returns an error if the peer fails to authenticate.
+func (h *handshake) handle(message *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	switch message.GetMessageType() {
+	case fcrmessages.AuthenticationRequestType:
+		if h.peer != nil {
+			return nil, errors.New("authentication already started")
+		}
+		peer, peerChallenge, err := fcrmessages.DecodeAuthenticationRequest(message)
+		if err != nil {
+			return nil, err
+		}
+		if h.encryptedPeer != nil && h.encryptedPeer.ToString() != peer.ToString() {
+			return nil, fmt.Errorf("peer authenticates as %s on a connection encrypted for %s", peer.ToString(), h.encryptedPeer.ToString())
+		}
+		if h.peerKey, err = h.auth.peerKey(peer); err != nil {
+			return nil, err
+		}
+		signature, err := h.auth.sign(authRoleResponse, peerChallenge, peer)
+		if err != nil {
+			return nil, err
+		}
+		h.peer = peer
+		h.challenge = challenge.NewRandomChallenge()
+		return fcrmessages.EncodeAuthenticationResponse(h.auth.nodeID, signature, h.challenge)
+	case fcrmessages.AuthenticationProofType:
+		if h.peer == nil {
+			return nil, errors.New("authentication not started")
+		}
+		signature, err := fcrmessages.DecodeAuthenticationProof(message)
+		if err != nil {
+			return nil, err
+		}
+		if err = h.auth.verify(h.peerKey, signature, authRoleProof, h.challenge, h.peer); err != nil {
+			return nil, err
+		}
+		h.done = true
+		return fcrmessages.EncodeAuthenticationAck()
+	}
+	return nil, errNotAuthenticated
+}
@@ -0,0 +1,113 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrCircuitOpen is returned when requesting a peer whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("peer is unavailable, circuit breaker is open")
+
+// Default settings of the circuit breakers.
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerState is the state of the circuit breaker of a peer.
+type BreakerState int
+
+// States of a circuit breaker.
+const (
+	// BreakerClosed lets requests to the peer through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests to the peer immediately with ErrCircuitOpen.
+	BreakerOpen
+	// BreakerHalfOpen lets a single trial request to the peer through once the cooldown is over,
+	// the breaker closes if the trial succeeds and opens again otherwise.
+	BreakerHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// circuitBreaker tracks the consecutive failures of a peer.
+// It opens after threshold failures, and becomes half-open cooldown after opening.
+type circuitBreaker struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// getState returns the state of the breaker at a given time.
+func (b *circuitBreaker) getState(now time.Time, cooldown time.Duration) BreakerState {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow checks if a request can be sent at a given time, a half-open breaker allows a single trial request.
+func (b *circuitBreaker) allow(now time.Time, cooldown time.Duration) bool {
+	b.state = b.getState(now, cooldown)
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+	}
+	return true
+}
+
+// success records a successful request, the breaker closes.
+func (b *circuitBreaker) success() {
+	b.state = BreakerClosed
+	b.failures = 0
+	b.trial = false
+}
+
+// abandon records a request which ended without telling if the peer is available, such as a cancelled request.
+// A half-open breaker then allows another trial request.
+func (b *circuitBreaker) abandon() {
+	b.trial = false
+}
+
+// failure records a failed request at a given time, the breaker opens once threshold consecutive requests failed
+// or if the trial request failed.
+func (b *circuitBreaker) failure(now time.Time, threshold int) {
+	b.failures++
+	b.trial = false
+	if b.state == BreakerHalfOpen || b.failures >= threshold {
+		b.state = BreakerOpen
+		b.openedAt = now
+	}
+}
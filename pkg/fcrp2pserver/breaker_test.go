@@ -0,0 +1,53 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{}
+	now := time.Now()
+	assert.True(t, b.allow(now, time.Second))
+	b.failure(now, 2)
+	assert.Equal(t, BreakerClosed, b.getState(now, time.Second))
+	b.failure(now, 2)
+	assert.Equal(t, BreakerOpen, b.getState(now, time.Second))
+	assert.False(t, b.allow(now, time.Second))
+
+	// A single trial request is allowed after the cooldown.
+	now = now.Add(time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.getState(now, time.Second))
+	assert.True(t, b.allow(now, time.Second))
+	assert.False(t, b.allow(now, time.Second))
+	b.failure(now, 2)
+	assert.Equal(t, BreakerOpen, b.getState(now, time.Second))
+
+	// An abandoned trial request allows another one.
+	now = now.Add(time.Second)
+	assert.True(t, b.allow(now, time.Second))
+	b.abandon()
+	assert.Equal(t, BreakerHalfOpen, b.getState(now, time.Second))
+	assert.True(t, b.allow(now, time.Second))
+	b.success()
+	assert.Equal(t, BreakerClosed, b.getState(now, time.Second))
+	assert.True(t, b.allow(now, time.Second))
+	assert.Equal(t, "closed", BreakerClosed.String())
+}
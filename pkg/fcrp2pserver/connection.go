@@ -0,0 +1,494 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/logging"
+)
+
+// Window of a stream: the sender of a multiplexed request may have up to streamMaxMessages messages and streamMaxBytes
+// bytes of message bodies not yet read from the stream of its peer. The reader gives the credit of the messages read
+// back with window updates once it has read half of the window, so that the sender waits for a reader falling behind
+// without holding the connection. A message is sent while half of the bytes of the window are left, so that a message
+// larger than the credit left does not wait forever. A peer ignoring the window is a whole window ahead before its
+// stream is reset.
+const (
+	streamMaxMessages = 1024
+	streamMaxBytes    = 16 << 20
+)
+
+// errConnectionClosed is returned when reading from a stream whose connection has been closed.
+var errConnectionClosed = errors.New("connection closed")
+
+// errStreamOverflow is returned when reading from a stream reset because its peer ignored the window.
+var errStreamOverflow = errors.New("stream reset, peer ignored the stream window")
+
+// timeoutError is returned when reading from a stream times out, it implements net.Error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout waiting for message" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// connWriter writes frames to a connection shared by concurrent requests.
+type connWriter struct {
+	lock   sync.Mutex
+	conn   net.Conn
+	codec  fcrmessages.Codec
+	signer *messageSigner
+}
+
+// newConnWriter creates a writer for a given connection.
+func newConnWriter(conn net.Conn, codec fcrmessages.Codec, signer *messageSigner) *connWriter {
+	return &connWriter{conn: conn, codec: codec, signer: signer}
+}
+
+// write signs a given message if signing is enabled and writes it with a given request id.
+func (w *connWriter) write(requestID uint32, msg *fcrmessages.FCRMessage, timeout time.Duration) error {
+	if err := w.signer.sign(msg); err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return sendTCPFrame(w.conn, requestID, msg, w.codec, timeout)
+}
+
+// writeContext signs a given message if signing is enabled and writes it with a given request id before the deadline
+// of a given context. Writes of request id 0 own the connection and are aborted if the context is cancelled.
+func (w *connWriter) writeContext(ctx context.Context, requestID uint32, msg *fcrmessages.FCRMessage) error {
+	if err := w.signer.sign(msg); err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return sendTCPFrameContext(ctx, w.conn, requestID, msg, w.codec, requestID == 0)
+}
+
+// getCodec returns the codec used to write messages.
+func (w *connWriter) getCodec() fcrmessages.Codec {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.codec
+}
+
+// setCodec sets the codec used to write messages.
+func (w *connWriter) setCodec(codec fcrmessages.Codec) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.codec = codec
+}
+
+// stream holds the messages received for a request id.
+// The closed channel is closed when the stream is released, reset or the connection is closed. The messages already
+// received can still be read unless the stream has been reset. The messages read but not given back to the sender
+// yet are counted by unackedMessages and unackedBytes.
+type stream struct {
+	owner     *streams
+	requestID uint32
+
+	lock            sync.Mutex
+	queue           []*fcrmessages.FCRMessage
+	size            int
+	unackedMessages int
+	unackedBytes    int
+	err             error
+	ready           chan bool
+	closed          chan bool
+}
+
+// newStream creates an empty stream for a given request id of given streams.
+func newStream(owner *streams, requestID uint32) *stream {
+	return &stream{
+		owner:     owner,
+		requestID: requestID,
+		ready:     make(chan bool, 1),
+		closed:    make(chan bool),
+	}
+}
+
+// push queues a given message, it returns false if the peer is a whole window ahead.
+// Messages of a closed stream are dropped.
+func (st *stream) push(msg *fcrmessages.FCRMessage) bool {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if st.err != nil {
+		return true
+	}
+	messages := len(st.queue) + st.unackedMessages
+	bytes := st.size + st.unackedBytes
+	if messages >= 2*streamMaxMessages || (bytes > streamMaxBytes && bytes+len(msg.GetMessageBody()) > 2*streamMaxBytes) {
+		return false
+	}
+	st.queue = append(st.queue, msg)
+	st.size += len(msg.GetMessageBody())
+	select {
+	case st.ready <- true:
+	default:
+	}
+	return true
+}
+
+// acknowledge records that a given message of the stream has been read, the credit of the messages read is given
+// back to the sender once half of the window has been read.
+func (st *stream) acknowledge(msg *fcrmessages.FCRMessage) {
+	st.lock.Lock()
+	if st.err != nil {
+		st.lock.Unlock()
+		return
+	}
+	st.unackedMessages++
+	st.unackedBytes += len(msg.GetMessageBody())
+	messages, bytes := st.unackedMessages, st.unackedBytes
+	if messages < streamMaxMessages/2 && bytes < streamMaxBytes/2 {
+		st.lock.Unlock()
+		return
+	}
+	st.unackedMessages = 0
+	st.unackedBytes = 0
+	st.lock.Unlock()
+	st.owner.sendWindowUpdate(st.requestID, messages, bytes)
+}
+
+// close closes the stream with a given error returned to the reader, a reset stream drops its messages.
+func (st *stream) close(err error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if st.err != nil {
+		return
+	}
+	st.err = err
+	if err == errStreamOverflow {
+		st.queue = nil
+		st.size = 0
+	}
+	close(st.closed)
+}
+
+// next returns the next message of the stream, or the error of a closed stream.
+// It returns false if the stream is open and empty.
+func (st *stream) next() (*fcrmessages.FCRMessage, bool, error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	if len(st.queue) > 0 {
+		msg := st.queue[0]
+		st.queue[0] = nil
+		st.queue = st.queue[1:]
+		st.size -= len(msg.GetMessageBody())
+		return msg, true, nil
+	}
+	if st.err != nil {
+		return nil, true, st.err
+	}
+	return nil, false, nil
+}
+
+// read reads the next message of the stream.
+func (st *stream) read(timeout time.Duration) (*fcrmessages.FCRMessage, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		if msg, ok, err := st.next(); ok {
+			if msg != nil {
+				st.acknowledge(msg)
+			}
+			return msg, err
+		}
+		select {
+		case <-st.ready:
+		case <-st.closed:
+		case <-timer.C:
+			return nil, timeoutError{}
+		}
+	}
+}
+
+// readContext reads the next message of the stream, it waits until a given context is done.
+func (st *stream) readContext(ctx context.Context) (*fcrmessages.FCRMessage, error) {
+	for {
+		if msg, ok, err := st.next(); ok {
+			if msg != nil {
+				st.acknowledge(msg)
+			}
+			return msg, err
+		}
+		select {
+		case <-st.ready:
+		case <-st.closed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// window is the credit left to send messages of a request to the stream of the peer.
+// The updated channel is closed and replaced whenever credit is given back.
+type window struct {
+	messages int
+	bytes    int
+	updated  chan bool
+}
+
+// streams routes messages to streams by request id, and holds the windows of the requests sending messages.
+// Window updates are written with writer within timeout, no window update is sent if writer is nil.
+type streams struct {
+	writer  *connWriter
+	timeout time.Duration
+
+	lock    sync.Mutex
+	closed  bool
+	done    chan bool
+	streams map[uint32]*stream
+	windows map[uint32]*window
+}
+
+// newStreams creates an empty set of streams writing window updates with a given writer within a given timeout.
+func newStreams(writer *connWriter, timeout time.Duration) *streams {
+	return &streams{
+		writer:  writer,
+		timeout: timeout,
+		done:    make(chan bool),
+		streams: make(map[uint32]*stream),
+		windows: make(map[uint32]*window),
+	}
+}
+
+// open opens a stream for a given request id, it returns nil if the streams are closed or the id is in use.
+func (s *streams) open(requestID uint32) *stream {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed || s.streams[requestID] != nil {
+		return nil
+	}
+	st := newStream(s, requestID)
+	s.streams[requestID] = st
+	return st
+}
+
+// release closes the stream and drops the window of a given request id.
+func (s *streams) release(requestID uint32) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if st := s.streams[requestID]; st != nil {
+		st.close(errConnectionClosed)
+		delete(s.streams, requestID)
+	}
+	delete(s.windows, requestID)
+}
+
+// deliver routes a given message to the stream of a given request id, it returns false if there is no such stream.
+// Window updates are applied to the window of the request and never routed. Delivering never waits: if the peer
+// ignored the window, the stream is reset and further messages are dropped until it is released.
+func (s *streams) deliver(requestID uint32, msg *fcrmessages.FCRMessage) bool {
+	if msg.GetMessageType() == fcrmessages.StreamWindowUpdateType {
+		s.grant(requestID, msg)
+		return true
+	}
+	s.lock.Lock()
+	st := s.streams[requestID]
+	s.lock.Unlock()
+	if st == nil {
+		return false
+	}
+	if !st.push(msg) {
+		logging.Error("P2P server resets stream of request %d, peer ignored the stream window", requestID)
+		st.close(errStreamOverflow)
+	}
+	return true
+}
+
+// acquire takes the credit to send a message of a given size for a given request id. It waits until the peer gives
+// credit back, the streams are closed or a given context is done.
+func (s *streams) acquire(ctx context.Context, requestID uint32, size int) error {
+	for {
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			return errConnectionClosed
+		}
+		w := s.windows[requestID]
+		if w == nil {
+			w = &window{messages: streamMaxMessages, bytes: streamMaxBytes, updated: make(chan bool)}
+			s.windows[requestID] = w
+		}
+		if w.messages > 0 && (w.bytes >= size || w.bytes >= streamMaxBytes/2) {
+			w.messages--
+			w.bytes -= size
+			s.lock.Unlock()
+			return nil
+		}
+		updated := w.updated
+		s.lock.Unlock()
+		select {
+		case <-updated:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// grant gives back the credit of a given window update to the window of a given request id.
+// The credit never exceeds the window, updates of unknown requests are dropped.
+func (s *streams) grant(requestID uint32, msg *fcrmessages.FCRMessage) {
+	messages, bytes, err := fcrmessages.DecodeStreamWindowUpdate(msg)
+	if err != nil {
+		logging.Warn("P2P server drops window update of request %d: %s", requestID, err.Error())
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w := s.windows[requestID]
+	if w == nil {
+		return
+	}
+	w.messages = addCredit(w.messages, messages, streamMaxMessages)
+	w.bytes = addCredit(w.bytes, bytes, streamMaxBytes)
+	close(w.updated)
+	w.updated = make(chan bool)
+}
+
+// addCredit adds a given credit to a given credit left, up to a given window.
+func addCredit(credit int, added int64, window int) int {
+	if added >= int64(window-credit) {
+		return window
+	}
+	return credit + int(added)
+}
+
+// sendWindowUpdate gives back to the peer the credit of a given number of messages and bytes read from the stream
+// of a given request id.
+func (s *streams) sendWindowUpdate(requestID uint32, messages int, bytes int) {
+	if s.writer == nil {
+		return
+	}
+	update, err := fcrmessages.EncodeStreamWindowUpdate(int64(messages), int64(bytes))
+	if err == nil {
+		err = s.writer.write(requestID, update, s.timeout)
+	}
+	if err != nil {
+		logging.Warn("P2P server has error sending window update of request %d: %s", requestID, err.Error())
+	}
+}
+
+// closeAll closes every stream and wakes the requests waiting for credit, no stream can be opened afterwards.
+func (s *streams) closeAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	for requestID, st := range s.streams {
+		st.close(errConnectionClosed)
+		delete(s.streams, requestID)
+	}
+	s.windows = make(map[uint32]*window)
+}
+
+// muxConn multiplexes concurrent requests on an outgoing connection.
+// A reader routine reads every frame and routes it back to the stream of its request id.
+type muxConn struct {
+	writer  *connWriter
+	streams *streams
+	limits  *frameLimits
+
+	lock   sync.Mutex
+	nextID uint32
+	closed bool
+}
+
+// newMuxConn starts multiplexing requests on a given connection.
+func newMuxConn(conn net.Conn, codec fcrmessages.Codec, signer *messageSigner, limits *frameLimits, timeout time.Duration) *muxConn {
+	writer := newConnWriter(conn, codec, signer)
+	m := &muxConn{
+		writer:  writer,
+		streams: newStreams(writer, timeout),
+		limits:  limits,
+	}
+	go m.readLoop(timeout)
+	return m
+}
+
+// open allocates a request id and opens its stream.
+func (m *muxConn) open() (uint32, *stream, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return 0, nil, errConnectionClosed
+	}
+	for {
+		m.nextID = (m.nextID + 1) & frameLengthMask
+		if m.nextID == 0 {
+			continue
+		}
+		// Streams are only closed once the connection is marked closed, nil means the id is still in use.
+		if st := m.streams.open(m.nextID); st != nil {
+			return m.nextID, st, nil
+		}
+	}
+}
+
+// isClosed checks if the connection has been closed.
+func (m *muxConn) isClosed() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.closed
+}
+
+// close closes the connection and every stream, closing a closed connection does nothing.
+func (m *muxConn) close() error {
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return nil
+	}
+	m.closed = true
+	m.lock.Unlock()
+	m.streams.closeAll()
+	return m.writer.conn.Close()
+}
+
+// readLoop reads frames until the connection fails and routes them to the streams.
+func (m *muxConn) readLoop(timeout time.Duration) {
+	for {
+		requestID, msg, err := readTCPFrame(m.writer.conn, m.limits, timeout)
+		if err != nil && isTimeoutError(err) {
+			continue
+		}
+		if sizeErr, ok := err.(*frameSizeError); ok && sizeErr.read {
+			logging.Warn("P2P server drops message of request %d from %s: %s", requestID, m.writer.conn.RemoteAddr(), err.Error())
+			continue
+		}
+		if err != nil {
+			if !m.isClosed() {
+				logging.Error("P2P server has error reading from %s: %s", m.writer.conn.RemoteAddr(), err.Error())
+				m.close()
+			}
+			return
+		}
+		if !m.streams.deliver(requestID, msg) {
+			logging.Warn("P2P server drops message of unknown request %d from %s", requestID, m.writer.conn.RemoteAddr())
+		}
+	}
+}
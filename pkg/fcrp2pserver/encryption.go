@@ -0,0 +1,269 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrregistermgr"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+)
+
+// TLS transport encryption:
+//
+// Every node presents an ephemeral self-signed certificate, created when the server starts, carrying an extension
+// which binds the certificate key to the node: the node id and the signature of the certificate public key by the
+// signing key of the node. Peers check the extension against the signing key registered for the node id instead of
+// relying on a certificate authority, so both the listener and the dialer present a certificate.
+//
+// Incoming connections starting with a TLS handshake record are encrypted, other connections are plaintext and are
+// only accepted if plaintext is allowed. A plaintext frame never starts with the handshake record type, as its length
+// would exceed the frame size limits, which are capped by maxFrameSizeLimit.
+
+// tlsRecordTypeHandshake is the first byte sent by a TLS client.
+const tlsRecordTypeHandshake = 0x16
+
+// certBindingOID identifies the certificate extension binding the certificate key to a node.
+var certBindingOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 59215, 1, 1}
+
+// errPlaintextRefused is returned when a peer connects in plaintext while encryption is required.
+var errPlaintextRefused = errors.New("plaintext connection refused, encryption is required")
+
+// certBinding is the content of the certificate extension.
+type certBinding struct {
+	NodeID    string
+	Signature string
+}
+
+// bindingPayload returns the data signed to bind a given DER encoded certificate public key to a node.
+func bindingPayload(publicKey []byte) []byte {
+	return append([]byte("fcr-p2p-tls:"), publicKey...)
+}
+
+// transportEncryption encrypts connections with the node id and the signing key of this node.
+type transportEncryption struct {
+	nodeID         *nodeid.NodeID
+	keyPair        *fcrcrypto.KeyPair
+	keyVersion     *fcrcrypto.KeyVersion
+	registerMgr    *fcrregistermgr.FCRRegisterMgr
+	allowPlaintext bool
+
+	// cert is the certificate of this node, created by init.
+	cert tls.Certificate
+}
+
+// init creates the ephemeral certificate of this node.
+func (e *transportEncryption) init() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, err := fcrcrypto.SignMessage(e.keyPair, e.keyVersion, bindingPayload(publicKey))
+	if err != nil {
+		return err
+	}
+	binding, err := asn1.Marshal(certBinding{NodeID: e.nodeID.ToString(), Signature: signature})
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:    serial,
+		Subject:         pkix.Name{CommonName: e.nodeID.ToString()},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(100 * 365 * 24 * time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: certBindingOID, Value: binding}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	e.cert = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return nil
+}
+
+// config returns the TLS configuration, the certificate of the peer is checked by a given function.
+func (e *transportEncryption) config(verify func(cert *x509.Certificate) error) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{e.cert},
+		MinVersion:   tls.VersionTLS13,
+		ClientAuth:   tls.RequireAnyClientCert,
+		// The peer certificate is self-signed, it is checked against the register instead.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("peer presents no certificate")
+			}
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			return verify(cert)
+		},
+	}
+}
+
+// client encrypts a given outgoing connection to a given peer having a given signing key.
+// The handshake is aborted at the deadline of a given context.
+func (e *transportEncryption) client(ctx context.Context, conn net.Conn, peer *nodeid.NodeID, peerKey *fcrcrypto.KeyPair) (net.Conn, error) {
+	tlsConn := tls.Client(conn, e.config(func(cert *x509.Certificate) error {
+		_, err := verifyCertBinding(cert, func(id *nodeid.NodeID) (*fcrcrypto.KeyPair, error) {
+			if id.ToString() != peer.ToString() {
+				return nil, fmt.Errorf("peer presents certificate of %s instead of %s", id.ToString(), peer.ToString())
+			}
+			return peerKey, nil
+		})
+		return err
+	}))
+	if err := handshakeTLS(ctx, tlsConn); err != nil {
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// server encrypts a given incoming connection whose peer started a TLS handshake within a given timeout.
+// It returns the encrypted connection and the node id of the peer.
+func (e *transportEncryption) server(conn net.Conn, timeout time.Duration) (net.Conn, *nodeid.NodeID, error) {
+	tlsConn := tls.Server(conn, e.config(func(cert *x509.Certificate) error {
+		_, err := verifyCertBinding(cert, func(id *nodeid.NodeID) (*fcrcrypto.KeyPair, error) {
+			return registeredSigningKey(e.registerMgr, id)
+		})
+		return err
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := handshakeTLS(ctx, tlsConn); err != nil {
+		return nil, nil, err
+	}
+	// The certificate has been verified during the handshake.
+	peer, _ := certNodeID(tlsConn.ConnectionState().PeerCertificates[0])
+	return tlsConn, peer, nil
+}
+
+// handshakeTLS runs the handshake of a given TLS connection before the deadline of a given context.
+func handshakeTLS(ctx context.Context, conn *tls.Conn) error {
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	if err := conn.Handshake(); err != nil {
+		return contextError(err, deadline)
+	}
+	return conn.SetDeadline(time.Time{})
+}
+
+// certNodeID returns the node id a given certificate is bound to, it returns nil if the certificate has no binding.
+func certNodeID(cert *x509.Certificate) (*nodeid.NodeID, *certBinding) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(certBindingOID) {
+			continue
+		}
+		binding := &certBinding{}
+		if rest, err := asn1.Unmarshal(ext.Value, binding); err != nil || len(rest) > 0 {
+			return nil, nil
+		}
+		id, err := nodeid.NewNodeIDFromHexString(binding.NodeID)
+		if err != nil {
+			return nil, nil
+		}
+		return id, binding
+	}
+	return nil, nil
+}
+
+// verifyCertBinding checks a given certificate is bound to a node by the signing key returned by a given function.
+// It returns the node id of the certificate.
+func verifyCertBinding(cert *x509.Certificate, signingKey func(id *nodeid.NodeID) (*fcrcrypto.KeyPair, error)) (*nodeid.NodeID, error) {
+	id, binding := certNodeID(cert)
+	if id == nil {
+		return nil, errors.New("peer certificate is not bound to a node")
+	}
+	key, err := signingKey(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := fcrcrypto.VerifyMessage(key, binding.Signature, bindingPayload(cert.RawSubjectPublicKeyInfo))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("peer certificate is not signed by the signing key of %s", id.ToString())
+	}
+	return id, nil
+}
+
+// peekedConn is a connection whose first bytes have been peeked.
+type peekedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads from the peeked bytes first.
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// secureIncoming waits for the first byte sent on a given incoming connection and encrypts the connection if it
+// starts a TLS handshake. Plaintext connections are returned as is if encryption is disabled or plaintext is allowed.
+// It returns the connection to use and the node id of the peer if the connection is encrypted.
+func (s *FCRP2PServer) secureIncoming(conn net.Conn) (net.Conn, *nodeid.NodeID, error) {
+	if s.encryption == nil {
+		return conn, nil, nil
+	}
+	reader := bufio.NewReader(conn)
+	start := time.Now()
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.timeout)); err != nil {
+			return nil, nil, err
+		}
+		first, err := reader.Peek(1)
+		if err != nil && isTimeoutError(err) && !s.isShutdown() && (s.idleTimeout == 0 || time.Since(start) < s.idleTimeout) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		peeked := &peekedConn{Conn: conn, reader: reader}
+		if first[0] != tlsRecordTypeHandshake {
+			if !s.encryption.allowPlaintext {
+				return nil, nil, errPlaintextRefused
+			}
+			return peeked, nil, nil
+		}
+		return s.encryption.server(peeked, s.timeout)
+	}
+}
@@ -0,0 +1,111 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// Default limits of the frames read from peers.
+const (
+	defaultMaxFrameSize  = 16 << 20
+	defaultMinThroughput = 16 << 10
+)
+
+// maxFrameSizeLimit is the largest frame size limit that can be set. The header of a plaintext frame then never
+// starts with the TLS handshake record type, which tells plaintext and encrypted incoming connections apart.
+const maxFrameSizeLimit uint32 = tlsRecordTypeHandshake<<24 - 1
+
+// errSlowPeer is returned when a frame is not received in time once it has started.
+// The connection can not be used anymore, the rest of the frame is still to be read.
+var errSlowPeer = errors.New("peer is too slow sending frame")
+
+// frameSizeError is returned when a frame exceeds the maximum frame size.
+// If the frame has been read, the message is returned with the error and the connection can still be used,
+// otherwise the frame is left unread and the connection can not be used anymore.
+type frameSizeError struct {
+	size uint32
+	max  uint32
+	read bool
+}
+
+// Error returns the error message.
+func (e *frameSizeError) Error() string {
+	return fmt.Sprintf("frame of %d bytes exceeds maximum frame size of %d bytes", e.size, e.max)
+}
+
+// frameLimits limits the frames read from peers.
+// maxSize is the maximum frame size of message types without class limit, classSizes maps message type classes
+// to their maximum frame size. The message type is only known once the frame has been read, so class limits are
+// checked after reading. The memory taken by a frame grows with the data received, up to the largest of these limits.
+// A frame must be received within the timeout plus its size divided by minThroughput, the minimum number of bytes
+// per second, so that slow peers can not hold a connection. A minThroughput of 0 gives every frame the timeout only.
+// A nil frameLimits uses the default limits.
+type frameLimits struct {
+	maxSize       uint32
+	classSizes    map[int32]uint32
+	minThroughput int
+}
+
+// newFrameLimits creates the default limits.
+func newFrameLimits() *frameLimits {
+	return &frameLimits{
+		maxSize:       defaultMaxFrameSize,
+		classSizes:    make(map[int32]uint32),
+		minThroughput: defaultMinThroughput,
+	}
+}
+
+// maxFrameSize returns the maximum size of any frame, larger frames are rejected before being read.
+func (l *frameLimits) maxFrameSize() uint32 {
+	if l == nil {
+		return defaultMaxFrameSize
+	}
+	res := l.maxSize
+	for _, size := range l.classSizes {
+		if size > res {
+			res = size
+		}
+	}
+	return res
+}
+
+// maxMessageSize returns the maximum frame size of a given message type.
+func (l *frameLimits) maxMessageSize(msgType int32) uint32 {
+	if l == nil {
+		return defaultMaxFrameSize
+	}
+	if size, ok := l.classSizes[fcrmessages.MessageClass(msgType)]; ok {
+		return size
+	}
+	return l.maxSize
+}
+
+// transferTime returns the time a frame of a given size may take on top of the timeout.
+func (l *frameLimits) transferTime(size uint32) time.Duration {
+	minThroughput := defaultMinThroughput
+	if l != nil {
+		minThroughput = l.minThroughput
+	}
+	if minThroughput <= 0 {
+		return 0
+	}
+	return time.Duration(size) * time.Second / time.Duration(minThroughput)
+}
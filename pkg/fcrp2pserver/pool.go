@@ -22,10 +22,17 @@ package fcrp2pserver
  */
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math/rand"
 	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrregistermgr"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/logging"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
@@ -37,137 +44,696 @@ const (
 	accessFromProvider = 1
 )
 
+// Default settings of the connection pool.
+const (
+	defaultMaxConnsPerPeer = 1
+	defaultDialRetries     = 2
+	defaultMinBackoff      = 100 * time.Millisecond
+	defaultMaxBackoff      = 2 * time.Second
+)
+
+// PoolStats holds the statistics of the pool of outgoing connections.
+type PoolStats struct {
+	// Active is the number of connections with requests in flight.
+	Active int
+	// Idle is the number of connections without request in flight.
+	Idle int
+	// Dialed is the number of connections established.
+	Dialed int
+	// Failed is the number of connections that could not be established, failed a health check or failed a request.
+	Failed int
+	// Evicted is the number of connections closed after being idle for too long.
+	Evicted int
+}
+
 // communicationChannel holds the connection for sending outgoing TCP requests.
-// lock is used to ensure only one thread can access the tcp connection at any time.
-// conn is the net connection for sending outgoing TCP requests.
+// lock is used to ensure only one thread can access the tcp connection at any time if the connection is not multiplexed.
+// conn is the net connection for sending outgoing TCP requests, it records whether the connection failed.
+// writer writes to the connection using the codec agreed with the peer.
+// mux is set if the peer agreed to multiplex requests on the connection, requests then share the connection.
+// verifier verifies responses against the signing key of the peer, it is nil if signing is disabled.
+// authenticated is set if the peer has been authenticated on the connection.
+// peerID and provider identify the peer, inUse, lastUsed and lastChecked are protected by the pool lock.
 type communicationChannel struct {
-	lock sync.RWMutex
-	conn net.Conn
+	lock          sync.RWMutex
+	conn          *channelConn
+	writer        *connWriter
+	mux           *muxConn
+	verifier      *messageVerifier
+	limits        *frameLimits
+	authenticated bool
+
+	peerID      *nodeid.NodeID
+	provider    bool
+	inUse       int
+	lastUsed    time.Time
+	lastChecked time.Time
+}
+
+// open returns the writer and the reader for a request on the channel.
+// The returned release function must be called once the request is done.
+func (comm *communicationChannel) open() (*FCRServerWriter, *FCRServerReader, func(), error) {
+	if comm.mux != nil {
+		requestID, st, err := comm.mux.open()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		writer := &FCRServerWriter{writer: comm.mux.writer, requestID: requestID, streams: comm.mux.streams}
+		reader := &FCRServerReader{stream: st, verifier: comm.verifier, limits: comm.limits, peerID: comm.authenticatedPeer()}
+		return writer, reader, func() { comm.mux.streams.release(requestID) }, nil
+	}
+	comm.lock.Lock()
+	writer := &FCRServerWriter{writer: comm.writer}
+	reader := &FCRServerReader{conn: comm.conn, verifier: comm.verifier, limits: comm.limits, peerID: comm.authenticatedPeer()}
+	return writer, reader, comm.lock.Unlock, nil
+}
+
+// authenticatedPeer returns the node id of the peer if it has been authenticated, nil otherwise.
+func (comm *communicationChannel) authenticatedPeer() *nodeid.NodeID {
+	if !comm.authenticated {
+		return nil
+	}
+	return comm.peerID
+}
+
+// isClosed checks if the connection of the channel is known to be closed or failed, the channel must then be removed.
+// An error answered by the peer leaves the connection usable.
+func (comm *communicationChannel) isClosed() bool {
+	if comm.mux != nil {
+		return comm.mux.isClosed()
+	}
+	return comm.conn.hasFailed()
+}
+
+// channelConn is the connection of a channel, it records the failure of a read or a write.
+// Once a read or a write failed, or a frame was left unread, frames can no longer be exchanged on the connection.
+type channelConn struct {
+	net.Conn
+	failed int32
+}
+
+// Read reads from the connection, an error fails the connection.
+func (c *channelConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if err != nil {
+		c.fail()
+	}
+	return n, err
+}
+
+// Write writes to the connection, an error fails the connection.
+func (c *channelConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	if err != nil {
+		c.fail()
+	}
+	return n, err
+}
+
+// fail records the connection failed.
+func (c *channelConn) fail() {
+	atomic.StoreInt32(&c.failed, 1)
+}
+
+// hasFailed checks if the connection failed.
+func (c *channelConn) hasFailed() bool {
+	return atomic.LoadInt32(&c.failed) != 0
+}
+
+// failUnreadFrame fails a given channel connection if a given read error left a frame unread.
+func failUnreadFrame(conn net.Conn, err error) {
+	if sizeErr, ok := err.(*frameSizeError); ok && !sizeErr.read {
+		if c, ok := conn.(*channelConn); ok {
+			c.fail()
+		}
+	}
+}
+
+// close closes the connection of the channel.
+func (comm *communicationChannel) close() error {
+	if comm.mux != nil {
+		return comm.mux.close()
+	}
+	return comm.conn.Close()
+}
+
+// ping checks the peer answers on the channel, any response is accepted.
+func (comm *communicationChannel) ping(timeout time.Duration) error {
+	writer, reader, release, err := comm.open()
+	if err != nil {
+		return err
+	}
+	defer release()
+	request, err := fcrmessages.EncodeGatewayPingRequest(comm.peerID, rand.Int63(), time.Now().Add(timeout).Unix())
+	if err != nil {
+		return err
+	}
+	if err = writer.Write(request, timeout); err != nil {
+		return err
+	}
+	_, err = reader.Read(timeout)
+	return err
 }
 
 // communicationPool holds the node address map and active node connections.
 type communicationPool struct {
 	registerMgr *fcrregistermgr.FCRRegisterMgr
 
-	activeGateways     map[string]*communicationChannel
-	activeGatewaysLock sync.RWMutex
+	// transport dials the connections to peers.
+	transport Transport
+
+	// codec is the preferred codec requested on every new connection.
+	// multiplex is set if multiplexing is requested on every new connection.
+	codec     fcrmessages.Codec
+	multiplex bool
+	timeout   time.Duration
+
+	// signer signs every outgoing message, it is nil if signing is disabled.
+	signer *messageSigner
+
+	// limits limits the frames read from peers.
+	limits *frameLimits
+	// auth authenticates the peers of the dialed connections, it is nil if authentication is disabled.
+	auth *authenticator
+	// encryption encrypts the dialed connections, it is nil if encryption is disabled.
+	encryption *transportEncryption
+
+	// maxConnsPerPeer is the maximum number of connections to a peer, a new connection is only dialed
+	// if every connection to the peer is in use.
+	// maxIdle is the duration after which an unused connection is closed, 0 keeps connections forever.
+	// healthCheckInterval is the duration after which an unused connection is probed, 0 disables probes.
+	maxConnsPerPeer     int
+	maxIdle             time.Duration
+	healthCheckInterval time.Duration
+	stopMaintenance     chan bool
+
+	// dialTimeout bounds every dial attempt, failed attempts are retried dialRetries times
+	// after a jittered exponential backoff between minBackoff and maxBackoff.
+	dialTimeout time.Duration
+	dialRetries int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+
+	// breakerThreshold is the number of consecutive failures opening the circuit breaker of a peer, 0 disables breakers.
+	// breakerCooldown is the duration after which an open breaker lets a trial request through.
+	breakerThreshold int
+	breakerCooldown  time.Duration
 
-	activeProviders     map[string]*communicationChannel
-	activeProvidersLock sync.RWMutex
+	// lock protects the connections and the statistics.
+	lock            sync.Mutex
+	activeGateways  map[string][]*communicationChannel
+	activeProviders map[string][]*communicationChannel
+	breakers        map[peerKey]*circuitBreaker
+	dialed          int
+	failed          int
+	evicted         int
 }
 
-// getGatewayConn gets a connection to a given gateway for sending request
-func (c *communicationPool) getGatewayConn(id *nodeid.NodeID, accessFrom int) (*communicationChannel, error) {
-	c.activeGatewaysLock.RLock()
-	comm := c.activeGateways[id.ToString()]
-	c.activeGatewaysLock.RUnlock()
-	if comm != nil {
-		return comm, nil
+// newCommunicationPool creates an empty pool.
+func newCommunicationPool(registerMgr *fcrregistermgr.FCRRegisterMgr, limits *frameLimits, timeout time.Duration) *communicationPool {
+	return &communicationPool{
+		registerMgr:      registerMgr,
+		transport:        TCPTransport{},
+		limits:           limits,
+		codec:            fcrmessages.CodecJSON,
+		multiplex:        true,
+		timeout:          timeout,
+		maxConnsPerPeer:  defaultMaxConnsPerPeer,
+		dialTimeout:      timeout,
+		dialRetries:      defaultDialRetries,
+		minBackoff:       defaultMinBackoff,
+		maxBackoff:       defaultMaxBackoff,
+		breakerThreshold: defaultBreakerThreshold,
+		breakerCooldown:  defaultBreakerCooldown,
+		activeGateways:   make(map[string][]*communicationChannel),
+		activeProviders:  make(map[string][]*communicationChannel),
+		breakers:         make(map[peerKey]*circuitBreaker),
 	}
-	logging.Info("P2P server has no active connection to gateway %s, attempt connecting", id.ToString())
-	gatewayInfo := c.registerMgr.GetGateway(id)
-	if gatewayInfo == nil {
-		return nil, errors.New("gateway not found")
+}
+
+// peerKey identifies a gateway or a provider.
+type peerKey struct {
+	id       string
+	provider bool
+}
+
+// getGatewayConn gets a connection to a given gateway for sending request, release must be called once the request is done.
+// A new connection is dialed before a given context is done.
+func (c *communicationPool) getGatewayConn(ctx context.Context, id *nodeid.NodeID, accessFrom int) (*communicationChannel, error) {
+	return c.getConn(id, false, func() (*communicationChannel, error) {
+		logging.Info("P2P server attempts connecting to gateway %s", id.ToString())
+		gatewayInfo := c.registerMgr.GetGateway(id)
+		if gatewayInfo == nil {
+			return nil, errors.New("gateway not found")
+		}
+		// Get address
+		var address string
+		switch accessFrom {
+		case accessFromGateway:
+			address = gatewayInfo.GetNetworkInfoGateway()
+		case accessFromProvider:
+			address = gatewayInfo.GetNetworkInfoProvider()
+		}
+		return c.dial(ctx, id, address, gatewayInfo.GetSigningKey)
+	})
+}
+
+// getProviderConn gets a connection to a given provider for sending request, release must be called once the request is done.
+// A new connection is dialed before a given context is done.
+func (c *communicationPool) getProviderConn(ctx context.Context, id *nodeid.NodeID) (*communicationChannel, error) {
+	return c.getConn(id, true, func() (*communicationChannel, error) {
+		logging.Info("P2P server attempts connecting to provider %s", id.ToString())
+		providerInfo := c.registerMgr.GetProvider(id)
+		if providerInfo == nil {
+			return nil, errors.New("provider not found")
+		}
+		// Get address
+		return c.dial(ctx, id, providerInfo.GetNetworkInfoGateway(), providerInfo.GetSigningKey)
+	})
+}
+
+// getConn gets the least used connection to a given peer, a new connection is dialed if all the connections
+// are in use and the maximum number of connections to the peer is not reached.
+// It returns ErrCircuitOpen if the circuit breaker of the peer is open, release or remove must be called
+// on the connection returned to record the outcome of the request.
+func (c *communicationPool) getConn(id *nodeid.NodeID, provider bool, dial func() (*communicationChannel, error)) (*communicationChannel, error) {
+	key := id.ToString()
+	c.lock.Lock()
+	if c.breakerThreshold > 0 && !c.breaker(peerKey{key, provider}).allow(time.Now(), c.breakerCooldown) {
+		c.lock.Unlock()
+		return nil, ErrCircuitOpen
 	}
-	// Get address
-	var address string
-	switch accessFrom {
-	case accessFromGateway:
-		address = gatewayInfo.GetNetworkInfoGateway()
-	case accessFromProvider:
-		address = gatewayInfo.GetNetworkInfoProvider()
+	comm := c.leastUsed(provider, key)
+	if comm != nil && (comm.inUse == 0 || len(c.peers(provider)[key]) >= c.maxConnsPerPeer) {
+		comm.inUse++
+		c.lock.Unlock()
+		return comm, nil
 	}
-	conn, err := net.Dial("tcp", address)
+	c.lock.Unlock()
+
+	newComm, err := dial()
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	if err != nil {
-		return nil, err
+		c.failed++
+		if comm == nil {
+			if errors.Is(err, context.Canceled) {
+				c.recordAbandon(peerKey{key, provider})
+			} else {
+				c.recordFailure(peerKey{key, provider})
+			}
+			return nil, err
+		}
+		// Fall back to a connection in use.
+		logging.Warn("P2P server has error connecting to %s: %s", key, err.Error())
+		comm.inUse++
+		return comm, nil
 	}
-	// Get connection
-	// Store the communication
-	c.activeGatewaysLock.Lock()
-	// It is possible that another thread creates a connection before this thread,
+	c.dialed++
+	newComm.peerID = id
+	newComm.provider = provider
+	newComm.lastUsed = time.Now()
+	peers := c.peers(provider)
+	// It is possible that other threads create connections at the same time as this thread,
 	// so do a final check here.
-	if c.activeGateways[id.ToString()] == nil {
-		comm = &communicationChannel{
-			lock: sync.RWMutex{},
-			conn: conn,
-		}
-		c.activeGateways[id.ToString()] = comm
+	if len(peers[key]) >= c.maxConnsPerPeer {
+		go closeChannel(newComm)
+		comm = c.leastUsed(provider, key)
 	} else {
-		comm = c.activeGateways[id.ToString()]
-		if err := conn.Close(); err != nil {
-			panic(err)
+		peers[key] = append(peers[key], newComm)
+		comm = newComm
+	}
+	comm.inUse++
+	return comm, nil
+}
+
+// release releases a given connection once a request is done, a given error is recorded as a failure of the peer
+// if the connection failed or the request timed out. A cancelled request records nothing, any other error is recorded
+// as a success as the peer answered on a healthy connection.
+func (c *communicationPool) release(comm *communicationChannel, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	comm.inUse--
+	comm.lastUsed = time.Now()
+	key := peerKey{comm.peerID.ToString(), comm.provider}
+	switch {
+	case err == nil:
+		c.recordSuccess(key)
+	case errors.Is(err, context.Canceled):
+		c.recordAbandon(key)
+	case comm.isClosed() || isTimeoutError(err) || errors.Is(err, context.DeadlineExceeded):
+		c.recordFailure(key)
+	default:
+		c.recordSuccess(key)
+	}
+}
+
+// breaker returns the circuit breaker of a given peer. The pool lock must be held.
+func (c *communicationPool) breaker(key peerKey) *circuitBreaker {
+	b := c.breakers[key]
+	if b == nil {
+		b = &circuitBreaker{}
+		c.breakers[key] = b
+	}
+	return b
+}
+
+// recordSuccess records a successful request to a given peer. The pool lock must be held.
+func (c *communicationPool) recordSuccess(key peerKey) {
+	if b := c.breakers[key]; b != nil {
+		b.success()
+		if b.state == BreakerClosed {
+			delete(c.breakers, key)
+		}
+	}
+}
+
+// recordFailure records a failed request to a given peer. The pool lock must be held.
+func (c *communicationPool) recordFailure(key peerKey) {
+	if c.breakerThreshold > 0 {
+		c.breaker(key).failure(time.Now(), c.breakerThreshold)
+	}
+}
+
+// recordAbandon records a request to a given peer which ended without a result. The pool lock must be held.
+func (c *communicationPool) recordAbandon(key peerKey) {
+	if b := c.breakers[key]; b != nil {
+		b.abandon()
+	}
+}
+
+// breakerState returns the state of the circuit breaker of a given peer.
+func (c *communicationPool) breakerState(id *nodeid.NodeID, provider bool) BreakerState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	b := c.breakers[peerKey{id.ToString(), provider}]
+	if b == nil {
+		return BreakerClosed
+	}
+	return b.getState(time.Now(), c.breakerCooldown)
+}
+
+// remove removes a given connection that failed, and closes it.
+func (c *communicationPool) remove(comm *communicationChannel) {
+	c.lock.Lock()
+	if c.removeLocked(comm) {
+		c.failed++
+	}
+	c.lock.Unlock()
+	comm.lock.Lock()
+	defer comm.lock.Unlock()
+	if err := comm.close(); err != nil {
+		logging.Error("P2P server has error closing connection: %s", err.Error())
+	}
+}
+
+// closeChannel closes the connection of a given channel which is not in the pool, errors are logged.
+func closeChannel(comm *communicationChannel) {
+	if err := comm.close(); err != nil {
+		logging.Warn("P2P server has error closing connection to %s: %s", comm.peerID.ToString(), err.Error())
+	}
+}
+
+// removeLocked removes a given connection from the pool, it returns false if the connection is not in the pool.
+// The pool lock must be held.
+func (c *communicationPool) removeLocked(comm *communicationChannel) bool {
+	peers := c.peers(comm.provider)
+	key := comm.peerID.ToString()
+	for i, other := range peers[key] {
+		if other == comm {
+			peers[key] = append(peers[key][:i], peers[key][i+1:]...)
+			if len(peers[key]) == 0 {
+				delete(peers, key)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// peers returns the connections to gateways or to providers. The pool lock must be held.
+func (c *communicationPool) peers(provider bool) map[string][]*communicationChannel {
+	if provider {
+		return c.activeProviders
+	}
+	return c.activeGateways
+}
+
+// leastUsed returns the connection to a given peer with the fewest requests in flight, closed connections are removed.
+// The pool lock must be held.
+func (c *communicationPool) leastUsed(provider bool, key string) *communicationChannel {
+	var res *communicationChannel
+	for _, comm := range append([]*communicationChannel(nil), c.peers(provider)[key]...) {
+		if comm.isClosed() {
+			c.removeLocked(comm)
+			c.failed++
+			continue
+		}
+		if res == nil || comm.inUse < res.inUse {
+			res = comm
+		}
+	}
+	return res
+}
+
+// dial dials a given address and negotiates the connection with a given peer having a given signing key.
+// Dialing and negotiating are aborted once a given context is done, negotiating takes at most the pool timeout.
+func (c *communicationPool) dial(ctx context.Context, id *nodeid.NodeID, address string, signingKey func() (*fcrcrypto.KeyPair, error)) (*communicationChannel, error) {
+	verifier, err := c.peerVerifier(signingKey)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.dialWithRetry(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if c.encryption != nil {
+		if conn, err = c.encrypt(ctx, conn, id, signingKey); err != nil {
+			return nil, err
 		}
 	}
-	c.activeGatewaysLock.Unlock()
+	comm, err := c.newChannel(ctx, conn, verifier, id, signingKey)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
 	return comm, nil
 }
 
-// getProviderConn gets a connection to a given provider for sending request
-func (c *communicationPool) getProviderConn(id *nodeid.NodeID) (*communicationChannel, error) {
-	c.activeProvidersLock.RLock()
-	comm := c.activeProviders[id.ToString()]
-	c.activeProvidersLock.RUnlock()
-	if comm != nil {
-		return comm, nil
+// encrypt encrypts a given dialed connection to a given peer having a given signing key, the connection is closed on failure.
+func (c *communicationPool) encrypt(ctx context.Context, conn net.Conn, id *nodeid.NodeID, signingKey func() (*fcrcrypto.KeyPair, error)) (net.Conn, error) {
+	peerKey, err := signingKey()
+	if err != nil {
+		conn.Close()
+		return nil, err
 	}
-	logging.Info("P2P server has no active connection to provider %s, attempt connecting", id.ToString())
-	providerInfo := c.registerMgr.GetProvider(id)
-	if providerInfo == nil {
-		return nil, errors.New("provider not found")
+	tlsConn, err := c.encryption.client(ctx, conn, id, peerKey)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("encryption with %s failed: %s", id.ToString(), err.Error())
+	}
+	return tlsConn, nil
+}
+
+// dialWithRetry dials a given address, failed attempts are retried after a jittered exponential backoff.
+func (c *communicationPool) dialWithRetry(ctx context.Context, address string) (net.Conn, error) {
+	backoff := c.minBackoff
+	for attempt := 0; ; attempt++ {
+		conn, err := c.dialOnce(ctx, address)
+		if err == nil || attempt >= c.dialRetries || ctx.Err() != nil {
+			return conn, err
+		}
+		logging.Warn("P2P server has error dialing %s, retrying: %s", address, err.Error())
+		// Wait between half and all of the backoff.
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
 	}
-	// Get address
-	address := providerInfo.GetNetworkInfoGateway()
-	conn, err := net.Dial("tcp", address)
+}
+
+// dialOnce makes a single attempt to dial a given address, bounded by the dial timeout.
+func (c *communicationPool) dialOnce(ctx context.Context, address string) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.dialTimeout)
+	defer cancel()
+	return c.transport.Dial(ctx, address)
+}
+
+// newChannel negotiates the codec and multiplexing on a given new connection and creates its channel.
+// If authentication is enabled, the given peer is authenticated with a given signing key before any request.
+func (c *communicationPool) newChannel(ctx context.Context, netConn net.Conn, verifier *messageVerifier, id *nodeid.NodeID, signingKey func() (*fcrcrypto.KeyPair, error)) (*communicationChannel, error) {
+	conn := &channelConn{Conn: netConn}
+	writer := newConnWriter(conn, fcrmessages.CodecJSON, c.signer)
+	reader := &FCRServerReader{conn: conn, verifier: verifier, limits: c.limits}
+	codec, multiplex, err := negotiate(ctx, &FCRServerWriter{writer: writer}, reader, c.codec, c.multiplex)
 	if err != nil {
 		return nil, err
 	}
-	// Get connection
-	// Store the communication
-	c.activeProvidersLock.Lock()
-	// It is possible that another thread creates a connection before this thread,
-	// so do a final check here.
-	if c.activeProviders[id.ToString()] == nil {
-		comm = &communicationChannel{
-			lock: sync.RWMutex{},
-			conn: conn,
+	writer.setCodec(codec)
+	if c.auth != nil {
+		peerKey, err := signingKey()
+		if err != nil {
+			return nil, err
 		}
-		c.activeProviders[id.ToString()] = comm
-	} else {
-		comm = c.activeProviders[id.ToString()]
-		if err := conn.Close(); err != nil {
-			panic(err)
+		// Authenticate before multiplexing, so that the handshake is read directly from the connection.
+		if err = c.auth.authenticate(ctx, &FCRServerWriter{writer: writer}, reader, id, peerKey); err != nil {
+			return nil, fmt.Errorf("authentication of %s failed: %s", id.ToString(), err.Error())
 		}
 	}
-	c.activeProvidersLock.Unlock()
+	comm := &communicationChannel{
+		lock:          sync.RWMutex{},
+		conn:          conn,
+		writer:        writer,
+		verifier:      verifier,
+		limits:        c.limits,
+		authenticated: c.auth != nil || c.encryption != nil,
+	}
+	if multiplex {
+		comm.mux = newMuxConn(conn, codec, c.signer, c.limits, c.timeout)
+	}
 	return comm, nil
 }
 
-// removeActiveGateway remove a given gateway, and close the connection.
-func (c *communicationPool) removeActiveGateway(id *nodeid.NodeID) {
-	c.activeGatewaysLock.Lock()
-	defer c.activeGatewaysLock.Unlock()
-	comm := c.activeGateways[id.ToString()]
-	if comm != nil {
-		comm.lock.Lock()
-		defer comm.lock.Unlock()
-		if err := comm.conn.Close(); err != nil {
-			panic(err)
+// peerVerifier returns the verifier for a peer with a given signing key, it returns nil if signing is disabled.
+func (c *communicationPool) peerVerifier(signingKey func() (*fcrcrypto.KeyPair, error)) (*messageVerifier, error) {
+	if c.signer == nil {
+		return nil, nil
+	}
+	key, err := signingKey()
+	if err != nil {
+		return nil, err
+	}
+	return &messageVerifier{peerKey: key}, nil
+}
+
+// stats returns the statistics of the pool.
+func (c *communicationPool) stats() PoolStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	res := PoolStats{Dialed: c.dialed, Failed: c.failed, Evicted: c.evicted}
+	for _, peers := range []map[string][]*communicationChannel{c.activeGateways, c.activeProviders} {
+		for _, comms := range peers {
+			for _, comm := range comms {
+				if comm.inUse > 0 {
+					res.Active++
+				} else {
+					res.Idle++
+				}
+			}
+		}
+	}
+	return res
+}
+
+// startMaintenance starts a routine evicting idle connections and probing unused connections, if enabled.
+func (c *communicationPool) startMaintenance() {
+	interval := c.healthCheckInterval
+	if c.maxIdle > 0 && (interval == 0 || c.maxIdle < interval) {
+		interval = c.maxIdle
+	}
+	if interval <= 0 {
+		return
+	}
+	c.stopMaintenance = make(chan bool)
+	go func(stop chan bool) {
+		// Check twice per interval so that connections are not kept much longer than configured.
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.checkConnections()
+			case <-stop:
+				return
+			}
 		}
-		delete(c.activeGateways, id.ToString())
+	}(c.stopMaintenance)
+}
+
+// checkConnections evicts connections idle for too long and probes connections unused since the health check interval.
+func (c *communicationPool) checkConnections() {
+	now := time.Now()
+	toClose := make([]*communicationChannel, 0)
+	toProbe := make([]*communicationChannel, 0)
+	c.lock.Lock()
+	for _, peers := range []map[string][]*communicationChannel{c.activeGateways, c.activeProviders} {
+		for _, comms := range peers {
+			for _, comm := range comms {
+				switch {
+				case comm.isClosed():
+					c.failed++
+					toClose = append(toClose, comm)
+				case comm.inUse > 0:
+				case c.maxIdle > 0 && now.Sub(comm.lastUsed) >= c.maxIdle:
+					c.evicted++
+					toClose = append(toClose, comm)
+				case c.healthCheckInterval > 0 && now.Sub(comm.lastUsed) >= c.healthCheckInterval && now.Sub(comm.lastChecked) >= c.healthCheckInterval:
+					// Reserve the connection while it is probed.
+					comm.inUse++
+					toProbe = append(toProbe, comm)
+				}
+			}
+		}
+	}
+	for _, comm := range toClose {
+		c.removeLocked(comm)
+	}
+	c.lock.Unlock()
+
+	for _, comm := range toClose {
+		closeChannel(comm)
+	}
+	var probes sync.WaitGroup
+	for _, comm := range toProbe {
+		probes.Add(1)
+		go func(comm *communicationChannel) {
+			defer probes.Done()
+			err := comm.ping(c.timeout)
+			c.lock.Lock()
+			comm.inUse--
+			comm.lastChecked = time.Now()
+			if err != nil {
+				c.recordFailure(peerKey{comm.peerID.ToString(), comm.provider})
+			}
+			c.lock.Unlock()
+			if err != nil {
+				logging.Warn("P2P server drops connection to %s failing health check: %s", comm.peerID.ToString(), err.Error())
+				c.remove(comm)
+			}
+		}(comm)
 	}
+	probes.Wait()
 }
 
-// removeActiveProvider remove a given provider, and close the connection.
-func (c *communicationPool) removeActiveProvider(id *nodeid.NodeID) {
-	c.activeProvidersLock.Lock()
-	defer c.activeProvidersLock.Unlock()
-	comm := c.activeProviders[id.ToString()]
-	if comm != nil {
+// closeAll stops the maintenance routine and closes all the connections in the pool.
+func (c *communicationPool) closeAll() {
+	if c.stopMaintenance != nil {
+		close(c.stopMaintenance)
+		c.stopMaintenance = nil
+	}
+	c.lock.Lock()
+	comms := make([]*communicationChannel, 0)
+	for _, peers := range []map[string][]*communicationChannel{c.activeGateways, c.activeProviders} {
+		for key, peerComms := range peers {
+			comms = append(comms, peerComms...)
+			delete(peers, key)
+		}
+	}
+	c.lock.Unlock()
+	for _, comm := range comms {
 		comm.lock.Lock()
-		defer comm.lock.Unlock()
-		if err := comm.conn.Close(); err != nil {
-			panic(err)
+		if err := comm.close(); err != nil {
+			logging.Error("P2P server has error closing connection to %s: %s", comm.peerID.ToString(), err.Error())
 		}
-		delete(c.activeProviders, id.ToString())
+		comm.lock.Unlock()
 	}
 }
@@ -16,22 +16,47 @@ package fcrp2pserver
  */
 
 import (
+	"context"
 	"errors"
-  "net"
+	"fmt"
+	"net"
+	"runtime/debug"
 	"sync"
 	"time"
 
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmiddleware"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrregistermgr"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/logging"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// FCRP2PServer represents a server handling p2p connection using tcp.
+// errTooManyConnections is returned when a connection is accepted while the maximum number of connections is reached.
+var errTooManyConnections = errors.New("too many connections")
+
+// FCRP2PServer represents a server handling p2p connection using tcp, or another transport set with SetTransport.
 type FCRP2PServer struct {
 	start       bool
 	listenAddrs []string
 	timeout     time.Duration
+	codec       fcrmessages.Codec
+
+	// limits limits the frames read from peers, idleTimeout is the duration after which
+	// an incoming connection without request is closed, 0 keeps it open.
+	limits      *frameLimits
+	idleTimeout time.Duration
+
+	// signer is set if signing is enabled, every outgoing message is then signed
+	// and every incoming message is verified before it reaches a handler.
+	signer *messageSigner
+
+	// auth is set if authentication is enabled, every connection then starts with a mutual authentication handshake
+	// and incoming requests are rejected until the peer is authenticated.
+	auth *authenticator
+
+	// encryption is set if encryption is enabled, connections are then encrypted with TLS.
+	encryption *transportEncryption
 
 	// Connection pool
 	pool *communicationPool
@@ -39,6 +64,23 @@ type FCRP2PServer struct {
 	// handlers for different message type
 	handlers   map[string]map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error
 	requesters map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error)
+
+	// contextRequesters for different message type, used by the requests taking a context
+	contextRequesters map[int32]func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error)
+
+	// middlewares wrapping every handler, the first one is the outermost
+	middlewares []fcrmiddleware.Middleware
+
+	// maxConns is the maximum number of incoming connections, 0 means no limit.
+	maxConns int
+
+	// lock protects the fields used to shutdown the server.
+	// conns maps every incoming connection to the number of its requests being handled.
+	lock      sync.Mutex
+	shutdown  bool
+	listeners []net.Listener
+	conns     map[net.Conn]int
+	routines  sync.WaitGroup
 }
 
 // NewFCRP2PServer creates an empty FCRP2PServer.
@@ -46,19 +88,17 @@ func NewFCRP2PServer(
 	listenAddrs []string,
 	registerMgr *fcrregistermgr.FCRRegisterMgr,
 	defaultTimeout time.Duration) *FCRP2PServer {
+	limits := newFrameLimits()
 	s := &FCRP2PServer{
-		start:       false,
-		listenAddrs: listenAddrs,
-		timeout:     defaultTimeout,
-		pool: &communicationPool{
-			registerMgr:         registerMgr,
-			activeGateways:      make(map[string]*communicationChannel),
-			activeGatewaysLock:  sync.RWMutex{},
-			activeProviders:     make(map[string]*communicationChannel),
-			activeProvidersLock: sync.RWMutex{},
-		},
-		handlers:   make(map[string]map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error),
-		requesters: make(map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error)),
+		start:             false,
+		limits:            limits,
+		listenAddrs:       listenAddrs,
+		timeout:           defaultTimeout,
+		codec:             fcrmessages.CodecJSON,
+		pool:              newCommunicationPool(registerMgr, limits, defaultTimeout),
+		handlers:          make(map[string]map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error),
+		requesters:        make(map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error)),
+		contextRequesters: make(map[int32]func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error)),
 	}
 	for _, listenAddr := range listenAddrs {
 		s.handlers[listenAddr] = make(map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error)
@@ -88,157 +128,817 @@ func (s *FCRP2PServer) AddRequester(msgType int32, requester func(reader *FCRSer
 	return s
 }
 
+// AddContextRequester is used to add a requester to the server for a given type, used by the requests taking a context.
+// The requester receives the request message built by the caller, types without requester write the request
+// and read a single response.
+func (s *FCRP2PServer) AddContextRequester(msgType int32, requester func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error)) *FCRP2PServer {
+	if s.start {
+		return s
+	}
+	s.contextRequesters[msgType] = requester
+	return s
+}
+
+// Use is used to add a middleware wrapping every handler of the server.
+// Middlewares are called in the order they are added, unknown message types also go through them.
+func (s *FCRP2PServer) Use(middleware fcrmiddleware.Middleware) *FCRP2PServer {
+	if s.start {
+		return s
+	}
+	s.middlewares = append(s.middlewares, middleware)
+	return s
+}
+
+// SetTransport is used to set the transport listening for and dialing connections, it is TCPTransport by default.
+// Servers using a MemoryTransport shared in a single process can run a whole network without opening any port.
+func (s *FCRP2PServer) SetTransport(transport Transport) *FCRP2PServer {
+	if s.start || transport == nil {
+		return s
+	}
+	s.pool.transport = transport
+	return s
+}
+
+// SetMaxConnections is used to limit the number of incoming connections, 0 means no limit.
+// Connections accepted over the limit are closed immediately. The requests handled on the connections are
+// limited with a fcrmiddleware.Limiter.
+func (s *FCRP2PServer) SetMaxConnections(maxConns int) *FCRP2PServer {
+	if s.start || maxConns < 0 {
+		return s
+	}
+	s.maxConns = maxConns
+	return s
+}
+
+// SetCodec is used to set the codec requested on outgoing connections.
+// Incoming connections always start with json and switch once the peer requests a different codec.
+func (s *FCRP2PServer) SetCodec(codec fcrmessages.Codec) *FCRP2PServer {
+	if s.start || !codec.IsSupported() {
+		return s
+	}
+	s.codec = codec
+	s.pool.codec = codec
+	return s
+}
+
+// SetMultiplexing is used to enable or disable multiplexing on outgoing connections, it is enabled by default.
+// Concurrent requests to a peer then share one connection and responses are routed back by request id.
+// Peers that do not support multiplexing are served one request at a time.
+func (s *FCRP2PServer) SetMultiplexing(multiplex bool) *FCRP2PServer {
+	if s.start {
+		return s
+	}
+	s.pool.multiplex = multiplex
+	return s
+}
+
+// SetMaxConnectionsPerPeer is used to set the maximum number of outgoing connections to a peer, it is 1 by default.
+// A new connection is only dialed when every connection to the peer is in use.
+func (s *FCRP2PServer) SetMaxConnectionsPerPeer(maxConns int) *FCRP2PServer {
+	if s.start || maxConns < 1 {
+		return s
+	}
+	s.pool.maxConnsPerPeer = maxConns
+	return s
+}
+
+// SetMaxIdleTime is used to close outgoing connections unused for a given duration, 0 keeps them open.
+func (s *FCRP2PServer) SetMaxIdleTime(maxIdle time.Duration) *FCRP2PServer {
+	if s.start || maxIdle < 0 {
+		return s
+	}
+	s.pool.maxIdle = maxIdle
+	return s
+}
+
+// SetHealthCheckInterval is used to probe outgoing connections unused for a given duration with a gateway ping request,
+// connections failing to get a response are closed. 0 disables health checks.
+func (s *FCRP2PServer) SetHealthCheckInterval(interval time.Duration) *FCRP2PServer {
+	if s.start || interval < 0 {
+		return s
+	}
+	s.pool.healthCheckInterval = interval
+	return s
+}
+
+// SetDialTimeout is used to bound every attempt to dial a peer, it is the default timeout of the server by default.
+func (s *FCRP2PServer) SetDialTimeout(timeout time.Duration) *FCRP2PServer {
+	if s.start || timeout <= 0 {
+		return s
+	}
+	s.pool.dialTimeout = timeout
+	return s
+}
+
+// SetDialRetry is used to retry dialing a peer a given number of times, waiting for a jittered exponential backoff
+// starting at minBackoff and capped at maxBackoff between attempts. It retries twice from 100ms to 2s by default.
+func (s *FCRP2PServer) SetDialRetry(retries int, minBackoff time.Duration, maxBackoff time.Duration) *FCRP2PServer {
+	if s.start || retries < 0 || minBackoff < 0 || maxBackoff < minBackoff {
+		return s
+	}
+	s.pool.dialRetries = retries
+	s.pool.minBackoff = minBackoff
+	s.pool.maxBackoff = maxBackoff
+	return s
+}
+
+// SetCircuitBreaker is used to configure the circuit breaker of every peer. The breaker opens after a given number of
+// consecutive failed requests and fails requests to the peer with ErrCircuitOpen until a given cooldown is over, then
+// lets a trial request through. It opens after 5 failures for 30s by default, a threshold of 0 disables breakers.
+func (s *FCRP2PServer) SetCircuitBreaker(threshold int, cooldown time.Duration) *FCRP2PServer {
+	if s.start || threshold < 0 || cooldown < 0 {
+		return s
+	}
+	s.pool.breakerThreshold = threshold
+	s.pool.breakerCooldown = cooldown
+	return s
+}
+
+// SetMaxFrameSize is used to set the maximum size of the frames read from peers, it is 16 MiB by default
+// and at most 352 MiB - 1. Larger frames are rejected with an invalid message response before being read and
+// the connection is dropped.
+func (s *FCRP2PServer) SetMaxFrameSize(size uint32) *FCRP2PServer {
+	if s.start || size == 0 || size > maxFrameSizeLimit {
+		return s
+	}
+	s.limits.maxSize = size
+	return s
+}
+
+// SetMaxFrameSizeForClass is used to set the maximum size of the frames of a given message type class,
+// see fcrmessages.MessageClass, overriding the maximum frame size. Larger messages are rejected with
+// an invalid message response once read: a class limit does not lower the memory taken by a frame, which grows
+// with the data received up to the largest of the frame size limits.
+func (s *FCRP2PServer) SetMaxFrameSizeForClass(class int32, size uint32) *FCRP2PServer {
+	if s.start || size == 0 || size > maxFrameSizeLimit {
+		return s
+	}
+	s.limits.classSizes[class] = size
+	return s
+}
+
+// SetMinThroughput is used to set the minimum number of bytes per second peers must send frames at, it is 16 KiB/s by default.
+// A frame must be received within the timeout plus its size divided by the minimum throughput, slower peers are dropped.
+// 0 gives every frame the timeout only.
+func (s *FCRP2PServer) SetMinThroughput(bytesPerSecond int) *FCRP2PServer {
+	if s.start || bytesPerSecond < 0 {
+		return s
+	}
+	s.limits.minThroughput = bytesPerSecond
+	return s
+}
+
+// SetIdleTimeout is used to drop incoming connections without request for a given duration, 0 keeps them open.
+// Connections are checked every default timeout, peers keeping connections in a pool should probe them more often.
+func (s *FCRP2PServer) SetIdleTimeout(idleTimeout time.Duration) *FCRP2PServer {
+	if s.start || idleTimeout < 0 {
+		return s
+	}
+	s.idleTimeout = idleTimeout
+	return s
+}
+
+// SetSigningKey is used to enable automatic message signing and verification.
+// Every message written through FCRServerWriter is signed with the given key pair and key version,
+// every message received is verified against the signing key of the peer registered in the register manager
+// and rejected if it can not be verified. The peer of an incoming connection is identified by authentication or
+// encryption, so a server listening for connections fails to start unless one of them is enabled.
+func (s *FCRP2PServer) SetSigningKey(keyPair *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion) *FCRP2PServer {
+	if s.start || keyPair == nil || keyVersion == nil {
+		return s
+	}
+	s.signer = &messageSigner{keyPair: keyPair, keyVersion: keyVersion}
+	s.pool.signer = s.signer
+	return s
+}
+
+// SetAuthentication is used to enable mutual authentication of the peers with a given node id, key pair and key version.
+// Every connection starts with a challenge-response handshake proving that each side holds the signing key
+// registered in the register manager for its node id. Requests received before the handshake are rejected,
+// handlers get the node id of the peer from FCRServerReader.GetPeerID.
+// Both peers must enable authentication.
+func (s *FCRP2PServer) SetAuthentication(nodeID *nodeid.NodeID, keyPair *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion) *FCRP2PServer {
+	if s.start || nodeID == nil || keyPair == nil || keyVersion == nil {
+		return s
+	}
+	s.auth = &authenticator{nodeID: nodeID, keyPair: keyPair, keyVersion: keyVersion, registerMgr: s.pool.registerMgr}
+	s.pool.auth = s.auth
+	return s
+}
+
+// SetEncryption is used to enable TLS encryption of the connections with a given node id, key pair and key version.
+// Each side presents a certificate bound to its node id by the signing key registered in the register manager,
+// outgoing connections are always encrypted. Incoming plaintext connections are still accepted if allowPlaintext
+// is set, which allows peers without encryption during local testing. Handlers get the node id of the peer of an
+// encrypted connection from FCRServerReader.GetPeerID.
+func (s *FCRP2PServer) SetEncryption(nodeID *nodeid.NodeID, keyPair *fcrcrypto.KeyPair, keyVersion *fcrcrypto.KeyVersion, allowPlaintext bool) *FCRP2PServer {
+	if s.start || nodeID == nil || keyPair == nil || keyVersion == nil {
+		return s
+	}
+	s.encryption = &transportEncryption{
+		nodeID:         nodeID,
+		keyPair:        keyPair,
+		keyVersion:     keyVersion,
+		registerMgr:    s.pool.registerMgr,
+		allowPlaintext: allowPlaintext,
+	}
+	s.pool.encryption = s.encryption
+	return s
+}
+
 // Start is used to start the server.
 func (s *FCRP2PServer) Start() error {
 	// Start server
 	if s.start {
 		return errors.New("server already started")
 	}
+	if s.signer != nil && s.auth == nil && s.encryption == nil && len(s.listenAddrs) > 0 {
+		return errors.New("signing requires authentication or encryption to verify incoming requests")
+	}
+	if s.encryption != nil {
+		if err := s.encryption.init(); err != nil {
+			return err
+		}
+	}
+	listeners := make([]net.Listener, 0, len(s.listenAddrs))
 	for _, listenAddr := range s.listenAddrs {
-		ln, err := net.Listen("tcp", ":"+listenAddr)
+		ln, err := s.pool.transport.Listen(listenAddr)
 		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
 			return err
 		}
-		go func(ln net.Listener, listenAddr string) {
-			for {
-				conn, err := ln.Accept()
-				if err != nil {
-					logging.Error("P2P server has error accepting connection: %s", err.Error())
-					continue
-				}
-				logging.Info("P2P server has incoming connection from :%s", conn.RemoteAddr())
-				go s.handleIncomingConnection(conn, s.handlers[listenAddr])
-			}
-		}(ln, listenAddr)
+		listeners = append(listeners, ln)
+	}
+	s.lock.Lock()
+	s.shutdown = false
+	s.listeners = listeners
+	s.conns = make(map[net.Conn]int)
+	s.lock.Unlock()
+	for i, listenAddr := range s.listenAddrs {
+		go s.acceptConnections(listeners[i], listenAddr)
 		logging.Info("P2P server starts listening on %s for connections.", listenAddr)
 	}
+	s.pool.startMaintenance()
 	s.start = true
 	return nil
 }
 
+// Shutdown is used to shutdown the server gracefully.
+// It stops accepting connections, waits for in-flight handlers to return, closes incoming connections
+// and pooled outgoing connections. If the given context expires first, the remaining connections
+// are closed and the context error is returned.
+func (s *FCRP2PServer) Shutdown(ctx context.Context) error {
+	if !s.start {
+		return errors.New("server not started")
+	}
+	s.lock.Lock()
+	s.shutdown = true
+	for _, ln := range s.listeners {
+		if err := ln.Close(); err != nil {
+			logging.Error("P2P server has error closing listener %s: %s", ln.Addr(), err.Error())
+		}
+	}
+	// Idle connections stop reading, busy connections stop once the message being handled is done.
+	for conn, handling := range s.conns {
+		if handling == 0 {
+			closeRead(conn)
+		}
+	}
+	s.lock.Unlock()
+
+	done := make(chan bool)
+	go func() {
+		s.routines.Wait()
+		s.pool.closeAll()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.lock.Lock()
+		for conn := range s.conns {
+			closeConnection(conn)
+		}
+		s.lock.Unlock()
+	}
+	s.start = false
+	return err
+}
+
+// acceptConnections accepts incoming connections on a given listener until the server shuts down.
+func (s *FCRP2PServer) acceptConnections(ln net.Listener, listenAddr string) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isShutdown() {
+				return
+			}
+			logging.Error("P2P server has error accepting connection: %s", err.Error())
+			continue
+		}
+		logging.Info("P2P server has incoming connection from :%s", conn.RemoteAddr())
+		if err = s.trackConnection(conn); err != nil {
+			closeConnection(conn)
+			if err == errTooManyConnections {
+				logging.Warn("P2P server refuses connection from %s: %s", conn.RemoteAddr(), err.Error())
+				continue
+			}
+			return
+		}
+		go s.handleIncomingConnection(conn, listenAddr, s.handlers[listenAddr])
+	}
+}
+
+// isShutdown checks if the server is shutting down.
+func (s *FCRP2PServer) isShutdown() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.shutdown
+}
+
+// trackConnection adds a given incoming connection to the connections waited for on shutdown.
+// It returns an error if the server is shutting down or has too many connections.
+func (s *FCRP2PServer) trackConnection(conn net.Conn) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.shutdown {
+		return errors.New("server is shutting down")
+	}
+	if s.maxConns > 0 && len(s.conns) >= s.maxConns {
+		return errTooManyConnections
+	}
+	s.conns[conn] = 0
+	s.routines.Add(1)
+	return nil
+}
+
+// beginHandling counts a request being handled on a given incoming connection.
+// It returns false if the server is shutting down and the request should be refused.
+func (s *FCRP2PServer) beginHandling(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.shutdown {
+		return false
+	}
+	if _, ok := s.conns[conn]; ok {
+		s.conns[conn]++
+	}
+	return true
+}
+
+// endHandling counts a request handled on a given incoming connection.
+// If the server is shutting down, the connection stops reading once no request is being handled.
+func (s *FCRP2PServer) endHandling(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.conns[conn]; !ok {
+		return
+	}
+	s.conns[conn]--
+	if s.conns[conn] == 0 && s.shutdown {
+		closeRead(conn)
+	}
+}
+
+// isConnectionIdle checks if no request is being handled on a given incoming connection.
+func (s *FCRP2PServer) isConnectionIdle(conn net.Conn) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.conns[conn] == 0
+}
+
+// untrackConnection removes a given incoming connection once it has been handled.
+func (s *FCRP2PServer) untrackConnection(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.conns[conn]; ok {
+		delete(s.conns, conn)
+		s.routines.Done()
+	}
+}
+
+// closeRead stops reading from a given connection, responses can still be written.
+// Connections that can not be half-closed, such as in-memory connections, are closed.
+func closeRead(conn net.Conn) {
+	if halfConn, ok := conn.(interface{ CloseRead() error }); ok {
+		if err := halfConn.CloseRead(); err != nil {
+			logging.Warn("P2P Server has error closing connection from %s for reading: %s", conn.RemoteAddr(), err.Error())
+		}
+		return
+	}
+	closeConnection(conn)
+}
+
+// closeConnection closes a given incoming connection, errors are logged.
+func closeConnection(conn net.Conn) {
+	if err := conn.Close(); err != nil {
+		logging.Warn("P2P Server has error closing connection from %s: %s", conn.RemoteAddr(), err.Error())
+	}
+}
+
 // handleIncomingConnection handles incomming connection using given handlers.
-func (s *FCRP2PServer) handleIncomingConnection(conn net.Conn, handlers map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error) {
-	// Close connection on exit.
+// The connection is tracked as accepted, messages are read from it once it has been encrypted if encryption is enabled.
+// A panic while handling the connection is logged with its stack trace and only drops the connection.
+func (s *FCRP2PServer) handleIncomingConnection(rawConn net.Conn, listenAddr string, handlers map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error) {
 	defer func() {
-		if err := conn.Close(); err != nil {
-			panic(err)
+		if r := recover(); r != nil {
+			logging.Error("P2P Server recovers from panic on connection from %s: %v\n%s", rawConn.RemoteAddr(), r, debug.Stack())
+			closeConnection(rawConn)
+			s.untrackConnection(rawConn)
 		}
 	}()
+	conn, peerID, err := s.secureIncoming(rawConn)
+	if err != nil {
+		logging.Warn("P2P Server drops connection from %s: %s", rawConn.RemoteAddr(), err.Error())
+		closeConnection(rawConn)
+		s.untrackConnection(rawConn)
+		return
+	}
+	writer := newConnWriter(conn, fcrmessages.CodecJSON, s.signer)
+	// Context of the requests, cancelled once the connection is dropped.
+	ctx, cancel := context.WithCancel(context.Background())
+	// Streams and routines of the multiplexed requests being handled.
+	streams := newStreams(writer, s.timeout)
+	var routines sync.WaitGroup
+	var closeOnce sync.Once
+	closeConn := func() {
+		closeOnce.Do(func() {
+			if err := conn.Close(); err != nil && !s.isShutdown() {
+				logging.Error("P2P Server has error closing connection from %s: %s", conn.RemoteAddr(), err.Error())
+			}
+		})
+	}
+	// Wait for the requests being handled and close connection on exit.
+	defer func() {
+		streams.closeAll()
+		routines.Wait()
+		cancel()
+		closeConn()
+		s.untrackConnection(rawConn)
+	}()
 
+	verifier := s.newIncomingVerifier(peerID)
+	auth := newHandshake(s.auth, peerID)
+	// getPeerID returns the node id of the peer once authenticated by the handshake or by encryption, the handshake
+	// only authenticates the peer proven by encryption.
+	getPeerID := func() *nodeid.NodeID {
+		if id := auth.getPeerID(); id != nil {
+			return id
+		}
+		return peerID
+	}
+	lastActive := time.Now()
 	// Loop until error occurs and connection is dropped.
 	for {
-		message, err := readTCPMessage(conn, s.timeout)
-		if err != nil && !isTimeoutError(err) {
-			// Error in tcp communication, drop the connection.
-			logging.Error("P2P Server has error reading message from %s: %s", conn.RemoteAddr(), err.Error())
+		if s.isShutdown() && s.isConnectionIdle(rawConn) {
+			// Server is shutting down.
 			return
 		}
-		// TODO: discard a connection if it doesn’t give a valid response for a really long time
+		requestID, message, err := readTCPFrame(conn, s.limits, s.timeout)
 		if err != nil && isTimeoutError(err) {
+			if s.idleTimeout > 0 && time.Since(lastActive) >= s.idleTimeout && s.isConnectionIdle(rawConn) {
+				// No request for too long, drop the connection.
+				logging.Info("P2P Server drops idle connection from %s", conn.RemoteAddr())
+				return
+			}
 			continue
 		}
-		handler := handlers[message.GetMessageType()]
-		if handler != nil {
-			// Call handler to handle the request
-			writer := &FCRServerWriter{conn: conn}
-			reader := &FCRServerReader{conn: conn}
-			err = handler(reader, writer, message)
-			if err != nil {
-				// Error that couldn't ignore, drop the connection.
-				logging.Error("P2P Server has error handling message from %s: %s", conn.RemoteAddr(), err.Error())
+		requestWriter := &FCRServerWriter{writer: writer, requestID: requestID}
+		if sizeErr, ok := err.(*frameSizeError); ok {
+			// Frame too large, reject it. The connection can only be used further if the frame has been read.
+			logging.Warn("P2P Server rejects message from %s: %s", conn.RemoteAddr(), err.Error())
+			err = requestWriter.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageTooLarge, err.Error(), message, s.timeout)
+			if err != nil || !sizeErr.read {
 				return
 			}
-		} else {
-			// Message is invalid.
-			err = sendInvalidMessage(conn, s.timeout)
+			lastActive = time.Now()
+			continue
+		}
+		if err != nil {
+			if s.isShutdown() {
+				return
+			}
+			// Error in tcp communication, drop the connection.
+			logging.Error("P2P Server has error reading message from %s: %s", conn.RemoteAddr(), err.Error())
+			return
+		}
+		lastActive = time.Now()
+		// Until the peer is identified, the negotiation and the handshake, which carries its own signatures, are
+		// accepted unverified.
+		unverified := !verifier.isPeerKnown() && (isNegotiation(message) || (auth != nil && isHandshakeMessage(message)))
+		if err = verifier.verify(message); err != nil && !unverified {
+			// Message can not be verified, reject it.
+			err = requestWriter.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageSignature, err.Error(), message, s.timeout)
 			if err != nil {
 				// Error in tcp communication, drop the connection.
 				logging.Error("P2P Server has error responding to %s: %s", conn.RemoteAddr(), err.Error())
 				return
 			}
+			continue
+		}
+		if !auth.isDone() && !isNegotiation(message) {
+			// Peer is not authenticated yet, only the negotiation and the handshake are accepted.
+			if err = s.handleHandshake(auth, verifier, requestWriter, message); err != nil {
+				logging.Warn("P2P Server drops connection from %s: %s", conn.RemoteAddr(), err.Error())
+				return
+			}
+			continue
 		}
+		if requestID != 0 && streams.deliver(requestID, message) {
+			// Message of a multiplexed request being handled.
+			continue
+		}
+		if !s.beginHandling(rawConn) {
+			// Server is shutting down, refuse new requests.
+			err = requestWriter.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageUnspecified, "server is shutting down", message, s.timeout)
+			if err != nil {
+				logging.Error("P2P Server has error responding to %s: %s", conn.RemoteAddr(), err.Error())
+				return
+			}
+			continue
+		}
+		if requestID == 0 {
+			// Request is not multiplexed, handle it before reading the next message.
+			err = s.handleMessage(ctx, conn, listenAddr, handlers, &FCRServerReader{conn: conn, verifier: verifier, limits: s.limits, peerID: getPeerID()}, requestWriter, message)
+			s.endHandling(rawConn)
+			lastActive = time.Now()
+			if err != nil {
+				// Error that couldn't ignore, drop the connection.
+				logging.Error("P2P Server has error handling message from %s: %s", conn.RemoteAddr(), err.Error())
+				return
+			}
+			continue
+		}
+		// Request is multiplexed, handle it concurrently, further messages of the request are routed to its stream.
+		// The request is the first message read from the stream, its responses wait for the credit of the stream
+		// of the peer.
+		st := streams.open(requestID)
+		st.acknowledge(message)
+		routines.Add(1)
+		go func(requestID uint32, message *fcrmessages.FCRMessage) {
+			defer routines.Done()
+			defer s.endHandling(rawConn)
+			defer streams.release(requestID)
+			requestWriter := &FCRServerWriter{writer: writer, requestID: requestID, streams: streams}
+			err := s.handleMessage(ctx, conn, listenAddr, handlers, &FCRServerReader{conn: conn, stream: st, limits: s.limits, peerID: getPeerID()}, requestWriter, message)
+			if err != nil {
+				// Error that couldn't ignore, drop the connection.
+				logging.Error("P2P Server has error handling message from %s: %s", conn.RemoteAddr(), err.Error())
+				closeConn()
+			}
+		}(requestID, message)
 	}
 }
 
-// RequestGatewayFromGateway uses a given requester to send a request to a given gateway from gateway.
-func (s *FCRP2PServer) RequestGatewayFromGateway(id *nodeid.NodeID, msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
-	if !s.start {
-		return nil, errors.New("server not started")
+// handleMessage handles a given request message through the middlewares.
+// It returns an error if the connection should be dropped, which is the case if the handler panics.
+func (s *FCRP2PServer) handleMessage(
+	ctx context.Context,
+	conn net.Conn,
+	listenAddr string,
+	handlers map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error,
+	reader *FCRServerReader,
+	writer *FCRServerWriter,
+	message *fcrmessages.FCRMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("P2P Server recovers from panic handling %s from %s: %v\n%s",
+				fcrmessages.TypeName(message.GetMessageType()), conn.RemoteAddr(), r, debug.Stack())
+			err = fmt.Errorf("panic handling message: %v", r)
+		}
+	}()
+	if message.GetMessageType() == fcrmessages.ProtocolChangeRequestType {
+		handled, err := s.handleNegotiation(writer, message)
+		if handled || err != nil {
+			return err
+		}
 	}
-	requester := s.requesters[msgType]
-	if requester == nil {
-		return nil, errors.New("no available requester found for given type")
+	handler := handlers[message.GetMessageType()]
+	// Call handler through the middlewares to handle the request
+	err = fcrmiddleware.Chain(func(request *fcrmiddleware.Request) error {
+		if handler == nil {
+			return fcrmiddleware.Reject(
+				fcrmessages.InvalidMessageUnknownType,
+				"no handler for message type "+fcrmessages.TypeName(request.Message.GetMessageType()))
+		}
+		return handler(reader, writer, request.Message)
+	}, s.middlewares...)(&fcrmiddleware.Request{
+		Context:    ctx,
+		Transport:  fcrmiddleware.TransportP2P,
+		ListenAddr: listenAddr,
+		RemoteAddr: conn.RemoteAddr().String(),
+		PeerID:     reader.GetPeerID(),
+		Message:    message,
+	})
+	if rejected, ok := err.(*fcrmiddleware.RejectError); ok {
+		// Message is rejected, respond and keep the connection.
+		err = writer.WriteInvalidMessageWithReason(rejected.Code, rejected.Reason, message, s.timeout)
 	}
-	comm, err := s.pool.getGatewayConn(id, accessFromGateway)
-	if err != nil {
-		logging.Error("P2P Server has error get gateway connection to %s: %s", id.ToString(), err.Error())
-		return nil, err
+	return err
+}
+
+// handleHandshake handles a given message received before the peer of an incoming connection is authenticated.
+// Requests are rejected and the connection is kept, it returns an error if the connection should be dropped.
+func (s *FCRP2PServer) handleHandshake(auth *handshake, verifier *messageVerifier, writer *FCRServerWriter, message *fcrmessages.FCRMessage) error {
+	response, err := auth.handle(message)
+	if err == errNotAuthenticated {
+		return writer.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageUnauthenticated, err.Error(), message, s.timeout)
 	}
-	comm.lock.Lock()
-	// Call requester to request
-	writer := &FCRServerWriter{conn: comm.conn}
-	reader := &FCRServerReader{conn: comm.conn}
-	response, err := requester(reader, writer, args...)
-	comm.lock.Unlock()
 	if err != nil {
-		// Error that couldn't ignore, remove the connection.
-		s.pool.removeActiveGateway(id)
-		return nil, err
+		// Authentication failed, reject it before dropping the connection.
+		if writeErr := writer.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageUnauthenticated, err.Error(), message, s.timeout); writeErr != nil {
+			logging.Error("P2P Server has error rejecting authentication: %s", writeErr.Error())
+		}
+		return errors.New("authentication failed: " + err.Error())
 	}
-	return response, err
+	if auth.isDone() && verifier != nil {
+		// Only accept messages signed by the authenticated peer.
+		verifier.peerKey = auth.peerKey
+	}
+	return writer.Write(response, s.timeout)
+}
+
+// newIncomingVerifier returns the verifier for an incoming connection with a given peer, which is nil unless the
+// connection is encrypted. It returns nil if signing is disabled.
+func (s *FCRP2PServer) newIncomingVerifier(peerID *nodeid.NodeID) *messageVerifier {
+	if s.signer == nil {
+		return nil
+	}
+	verifier := &messageVerifier{}
+	if peerID != nil {
+		// The certificate of the peer has been verified against this key.
+		verifier.peerKey, _ = registeredSigningKey(s.pool.registerMgr, peerID)
+	}
+	return verifier
+}
+
+// isNegotiation checks if a given message is a protocol change request asking for a codec or for multiplexing.
+func isNegotiation(message *fcrmessages.FCRMessage) bool {
+	if message.GetMessageType() != fcrmessages.ProtocolChangeRequestType {
+		return false
+	}
+	_, codec, multiplex, err := fcrmessages.DecodeProtocolChangeRequestWithOptions(message)
+	return err == nil && (codec != fcrmessages.CodecJSON || multiplex)
+}
+
+// handleNegotiation handles a protocol change request asking for a codec or for multiplexing, it returns false if
+// the request asks for neither and should be passed to the registered handler.
+// Multiplexing is always accepted as every incoming message is routed by its request id.
+func (s *FCRP2PServer) handleNegotiation(writer *FCRServerWriter, message *fcrmessages.FCRMessage) (bool, error) {
+	_, codec, multiplex, err := fcrmessages.DecodeProtocolChangeRequestWithOptions(message)
+	if err != nil || (codec == fcrmessages.CodecJSON && !multiplex) {
+		return false, nil
+	}
+	if !codec.IsSupported() {
+		response, _ := fcrmessages.EncodeProtocolChangeResponseWithOptions(false, writer.GetCodec(), false)
+		return true, writer.Write(response, s.timeout)
+	}
+	// Respond using the current codec, then switch.
+	response, _ := fcrmessages.EncodeProtocolChangeResponseWithOptions(true, codec, multiplex)
+	if err = writer.Write(response, s.timeout); err != nil {
+		return true, err
+	}
+	writer.writer.setCodec(codec)
+	return true, nil
+}
+
+// RequestGatewayFromGateway uses a given requester to send a request to a given gateway from gateway.
+func (s *FCRP2PServer) RequestGatewayFromGateway(id *nodeid.NodeID, msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+	return s.requestWithArgs(s.gatewayConn(id, accessFromGateway), msgType, args...)
 }
 
 // RequestGatewayFromProvider uses a given requester to send a request to a given gateway from provider.
 func (s *FCRP2PServer) RequestGatewayFromProvider(id *nodeid.NodeID, msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
-	if !s.start {
-		return nil, errors.New("server not started")
+	return s.requestWithArgs(s.gatewayConn(id, accessFromProvider), msgType, args...)
+}
+
+// RequestProvider uses a given requester to send a request to a given provider. (Only possible from gateway)
+func (s *FCRP2PServer) RequestProvider(id *nodeid.NodeID, msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+	return s.requestWithArgs(s.providerConn(id), msgType, args...)
+}
+
+// RequestGatewayFromGatewayContext sends a given request to a given gateway from gateway and returns the response.
+// Dialing, writing and reading use the deadline of a given context, or the server timeout if it has none,
+// and are aborted if the context is cancelled.
+func (s *FCRP2PServer) RequestGatewayFromGatewayContext(ctx context.Context, id *nodeid.NodeID, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	return s.requestContext(ctx, s.gatewayConn(id, accessFromGateway), request)
+}
+
+// RequestGatewayFromProviderContext sends a given request to a given gateway from provider and returns the response.
+// Dialing, writing and reading use the deadline of a given context, or the server timeout if it has none,
+// and are aborted if the context is cancelled.
+func (s *FCRP2PServer) RequestGatewayFromProviderContext(ctx context.Context, id *nodeid.NodeID, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	return s.requestContext(ctx, s.gatewayConn(id, accessFromProvider), request)
+}
+
+// RequestProviderContext sends a given request to a given provider and returns the response. (Only possible from gateway)
+// Dialing, writing and reading use the deadline of a given context, or the server timeout if it has none,
+// and are aborted if the context is cancelled.
+func (s *FCRP2PServer) RequestProviderContext(ctx context.Context, id *nodeid.NodeID, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	return s.requestContext(ctx, s.providerConn(id), request)
+}
+
+// GetPoolStats returns the statistics of the pool of outgoing connections.
+func (s *FCRP2PServer) GetPoolStats() PoolStats {
+	return s.pool.stats()
+}
+
+// GetGatewayState returns the state of the circuit breaker of a given gateway.
+// Requests to a gateway whose breaker is open fail immediately with ErrCircuitOpen.
+func (s *FCRP2PServer) GetGatewayState(id *nodeid.NodeID) BreakerState {
+	return s.pool.breakerState(id, false)
+}
+
+// GetProviderState returns the state of the circuit breaker of a given provider.
+// Requests to a provider whose breaker is open fail immediately with ErrCircuitOpen.
+func (s *FCRP2PServer) GetProviderState(id *nodeid.NodeID) BreakerState {
+	return s.pool.breakerState(id, true)
+}
+
+// gatewayConn returns a function getting a connection to a given gateway.
+func (s *FCRP2PServer) gatewayConn(id *nodeid.NodeID, accessFrom int) func(ctx context.Context) (*communicationChannel, error) {
+	return func(ctx context.Context) (*communicationChannel, error) {
+		comm, err := s.pool.getGatewayConn(ctx, id, accessFrom)
+		if err != nil {
+			logging.Error("P2P Server has error get gateway connection to %s: %s", id.ToString(), err.Error())
+		}
+		return comm, err
 	}
+}
+
+// providerConn returns a function getting a connection to a given provider.
+func (s *FCRP2PServer) providerConn(id *nodeid.NodeID) func(ctx context.Context) (*communicationChannel, error) {
+	return func(ctx context.Context) (*communicationChannel, error) {
+		comm, err := s.pool.getProviderConn(ctx, id)
+		if err != nil {
+			logging.Error("P2P Server has error get provider connection to %s: %s", id.ToString(), err.Error())
+		}
+		return comm, err
+	}
+}
+
+// requestWithArgs uses the requester of a given type to send a request with given arguments.
+func (s *FCRP2PServer) requestWithArgs(getConn func(ctx context.Context) (*communicationChannel, error), msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
 	requester := s.requesters[msgType]
 	if requester == nil {
 		return nil, errors.New("no available requester found for given type")
 	}
-	comm, err := s.pool.getGatewayConn(id, accessFromProvider)
-	if err != nil {
-		logging.Error("P2P Server has error get gateway connection to %s: %s", id.ToString(), err.Error())
-		return nil, err
+	return s.request(context.Background(), getConn, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter) (*fcrmessages.FCRMessage, error) {
+		return requester(reader, writer, args...)
+	})
+}
+
+// requestContext sends a given request using the context requester of its type, or writes it and reads the response
+// if there is none.
+func (s *FCRP2PServer) requestContext(ctx context.Context, getConn func(ctx context.Context) (*communicationChannel, error), request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
 	}
-	comm.lock.Lock()
-	// Call requester to request
-	writer := &FCRServerWriter{conn: comm.conn}
-	reader := &FCRServerReader{conn: comm.conn}
-	response, err := requester(reader, writer, args...)
-	comm.lock.Unlock()
-	if err != nil {
-		// Error that couldn't ignore, remove the connection.
-		s.pool.removeActiveGateway(id)
-		return nil, err
+	requester := s.contextRequesters[request.GetMessageType()]
+	if requester == nil {
+		requester = func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+			if err := writer.WriteContext(ctx, request); err != nil {
+				return nil, err
+			}
+			return reader.ReadContext(ctx)
+		}
 	}
-	return response, err
+	return s.request(ctx, getConn, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter) (*fcrmessages.FCRMessage, error) {
+		return requester(ctx, reader, writer, request)
+	})
 }
 
-// RequestProvider uses a given requester to send a request to a given provider. (Only possible from gateway)
-func (s *FCRP2PServer) RequestProvider(id *nodeid.NodeID, msgType int32, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+// request calls a given function to send a request on a connection from the pool.
+func (s *FCRP2PServer) request(ctx context.Context, getConn func(ctx context.Context) (*communicationChannel, error), call func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter) (*fcrmessages.FCRMessage, error)) (*fcrmessages.FCRMessage, error) {
 	if !s.start {
 		return nil, errors.New("server not started")
 	}
-	requester := s.requesters[msgType]
-	if requester == nil {
-		return nil, errors.New("no available requester found for given type")
+	comm, err := getConn(ctx)
+	if err != nil {
+		return nil, err
 	}
-	comm, err := s.pool.getProviderConn(id)
+	writer, reader, release, err := comm.open()
 	if err != nil {
-		logging.Error("P2P Server has error get provider connection to %s: %s", id.ToString(), err.Error())
+		// Connection has been closed, remove it.
+		s.pool.release(comm, err)
+		s.pool.remove(comm)
 		return nil, err
 	}
-	comm.lock.Lock()
 	// Call requester to request
-	writer := &FCRServerWriter{conn: comm.conn}
-	reader := &FCRServerReader{conn: comm.conn}
-	response, err := requester(reader, writer, args...)
-	comm.lock.Unlock()
+	response, err := call(ctx, reader, writer)
+	release()
+	s.pool.release(comm, err)
 	if err != nil {
-		// Error that couldn't ignore, remove the connection.
-		s.pool.removeActiveProvider(id)
+		if comm.isClosed() {
+			// The connection failed, remove it.
+			s.pool.remove(comm)
+		}
 		return nil, err
 	}
 	return response, err
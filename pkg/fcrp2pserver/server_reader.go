@@ -16,19 +16,67 @@ package fcrp2pserver
  */
 
 import (
+	"context"
 	"net"
 	"time"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
 )
 
-// FCRServerReader stores the connection to read from.
+// FCRServerReader reads messages of a request from a connection.
+// If the connection is multiplexed, messages are read from the stream of the request.
+// Messages are verified against the signing key of the peer if signing is enabled.
+// Frames read from the connection are checked against the frame limits.
+// peerID is the node id of the peer if the connection has been authenticated.
 type FCRServerReader struct {
-	conn net.Conn
+	conn     net.Conn
+	stream   *stream
+	verifier *messageVerifier
+	limits   *frameLimits
+	peerID   *nodeid.NodeID
 }
 
-// Write reads a message.
+// GetPeerID returns the node id of the authenticated peer, it returns nil if the connection is not authenticated.
+func (r *FCRServerReader) GetPeerID() *nodeid.NodeID {
+	return r.peerID
+}
+
+// Read reads a message.
 func (r *FCRServerReader) Read(timeout time.Duration) (*fcrmessages.FCRMessage, error) {
-	res, err := readTCPMessage(r.conn, timeout)
-	return res, err
+	var res *fcrmessages.FCRMessage
+	var err error
+	if r.stream != nil {
+		res, err = r.stream.read(timeout)
+	} else {
+		_, res, err = readTCPFrame(r.conn, r.limits, timeout)
+		failUnreadFrame(r.conn, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = r.verifier.verify(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReadContext reads a message before the deadline of a given context, the read is aborted if the context is cancelled.
+// A context without deadline waits until a message arrives.
+func (r *FCRServerReader) ReadContext(ctx context.Context) (*fcrmessages.FCRMessage, error) {
+	var res *fcrmessages.FCRMessage
+	var err error
+	if r.stream != nil {
+		res, err = r.stream.readContext(ctx)
+	} else {
+		_, res, err = readTCPFrameContext(ctx, r.conn, r.limits)
+		failUnreadFrame(r.conn, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = r.verifier.verify(res); err != nil {
+		return nil, err
+	}
+	return res, nil
 }
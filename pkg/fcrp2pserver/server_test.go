@@ -16,13 +16,42 @@ package fcrp2pserver
  */
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/ConsenSys/fc-retrieval-common/pkg/challenge"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmiddleware"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrregistermgr"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/register"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/utest"
 	"github.com/stretchr/testify/assert"
 )
 
+// tcpPipe returns both ends of a loopback tcp connection.
+func tcpPipe(t *testing.T) (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Empty(t, err)
+	defer ln.Close()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	assert.Empty(t, err)
+	server, err := ln.Accept()
+	assert.Empty(t, err)
+	return client, server
+}
+
 func TestNewFCRP2PServer(t *testing.T) {
 	port01 := utest.GetFreePort()
 	s := NewFCRP2PServer([]string{port01}, nil, time.Minute*2)
@@ -30,3 +59,1137 @@ func TestNewFCRP2PServer(t *testing.T) {
 	err := s.Start()
 	assert.Equal(t, nil, err)
 }
+
+func TestCodecNegotiation(t *testing.T) {
+	s := NewFCRP2PServer([]string{}, nil, time.Second)
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go s.handleIncomingConnection(server, "", map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error{
+		fcrmessages.GatewayListDHTOfferAckType: func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			assert.Equal(t, fcrmessages.CodecBinary, writer.GetCodec())
+			return writer.Write(request, time.Second)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	codec, multiplex, err := negotiate(
+		ctx,
+		&FCRServerWriter{writer: newConnWriter(client, fcrmessages.CodecJSON, nil)},
+		&FCRServerReader{conn: client},
+		fcrmessages.CodecBinary,
+		false)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.CodecBinary, codec)
+	assert.False(t, multiplex)
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	err = sendTCPMessage(client, request, codec, time.Second)
+	assert.Empty(t, err)
+	response, err := readTCPMessage(client, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, request, response)
+}
+
+func TestUnknownMessageType(t *testing.T) {
+	s := NewFCRP2PServer([]string{}, nil, time.Second)
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go s.handleIncomingConnection(server, "", map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error{})
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":7}`))
+	err := sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second)
+	assert.Empty(t, err)
+	response, err := readTCPMessage(client, time.Second)
+	assert.Empty(t, err)
+	code, _, msgType, nonce, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageUnknownType, code)
+	assert.Equal(t, int32(fcrmessages.GatewayPingRequestType), msgType)
+	assert.Equal(t, int64(7), nonce)
+}
+
+func TestSigningWithoutPeerIdentification(t *testing.T) {
+	serverID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	serverKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	port := utest.GetFreePort()
+
+	// Incoming requests could not be verified.
+	s := NewFCRP2PServer([]string{port}, nil, time.Second).
+		SetSigningKey(serverKey, fcrcrypto.InitialKeyVersion())
+	assert.NotEmpty(t, s.Start())
+
+	// A server without listener only verifies responses, against the keys of the register.
+	s = NewFCRP2PServer([]string{}, nil, time.Second).
+		SetSigningKey(serverKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, s.Start())
+	assert.Empty(t, s.Shutdown(context.Background()))
+
+	s = NewFCRP2PServer([]string{port}, nil, time.Second).
+		SetSigningKey(serverKey, fcrcrypto.InitialKeyVersion()).
+		SetAuthentication(serverID, serverKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, s.Start())
+	assert.Empty(t, s.Shutdown(context.Background()))
+}
+
+func TestSigning(t *testing.T) {
+	serverID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	peerID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	serverKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	peerKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	otherKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	peerPubKey, err := peerKey.EncodePublicKey()
+	assert.Empty(t, err)
+	otherPubKey, err := otherKey.EncodePublicKey()
+	assert.Empty(t, err)
+
+	// Register knowing the peer and another gateway.
+	registerMgr, stop := newTestRegisterMgr(t,
+		register.GatewayRegister{NodeID: peerID.ToString(), SigningKey: peerPubKey},
+		register.GatewayRegister{NodeID: "03", SigningKey: otherPubKey})
+	defer stop()
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetSigningKey(serverKey, fcrcrypto.InitialKeyVersion()).
+		SetAuthentication(serverID, serverKey, fcrcrypto.InitialKeyVersion())
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go s.handleIncomingConnection(server, "", map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error{
+		fcrmessages.GatewayListDHTOfferAckType: func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(fcrmessages.CreateFCRMessage(request.GetMessageType(), request.GetMessageBody()), time.Second)
+		},
+	})
+	writer := &FCRServerWriter{writer: newConnWriter(client, fcrmessages.CodecJSON, &messageSigner{keyPair: peerKey, keyVersion: fcrcrypto.InitialKeyVersion()})}
+	reader := &FCRServerReader{conn: client, verifier: &messageVerifier{peerKey: serverKey}}
+
+	// Signed before the peer is identified, rejected.
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, writer.Write(request, time.Second))
+	response, err := reader.Read(time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, code)
+
+	peerAuth := &authenticator{nodeID: peerID, keyPair: peerKey, keyVersion: fcrcrypto.InitialKeyVersion(), registerMgr: registerMgr}
+	assert.Empty(t, peerAuth.authenticate(context.Background(), writer, reader, serverID, serverKey))
+
+	// Signed by the authenticated peer, handled and signed by the server.
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, writer.Write(request, time.Second))
+	response, err = reader.Read(time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, int32(fcrmessages.GatewayListDHTOfferAckType), response.GetMessageType())
+
+	// Unsigned, rejected.
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second))
+	response, err = reader.Read(time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err = fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, code)
+
+	// Signed by another registered gateway than the peer, rejected.
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, request.Sign(otherKey, fcrcrypto.InitialKeyVersion()))
+	assert.Empty(t, sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second))
+	response, err = reader.Read(time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err = fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, code)
+}
+
+func TestMiddleware(t *testing.T) {
+	seen := make(chan *fcrmiddleware.Request, 2)
+	s := NewFCRP2PServer([]string{}, nil, time.Second).Use(func(next fcrmiddleware.Handler) fcrmiddleware.Handler {
+		return func(request *fcrmiddleware.Request) error {
+			seen <- request
+			if request.Message.GetMessageType() == fcrmessages.GatewayPingRequestType {
+				return fcrmiddleware.Reject(fcrmessages.InvalidMessageSignature, "ping not allowed")
+			}
+			return next(request)
+		}
+	})
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go s.handleIncomingConnection(server, "9000", map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error{
+		fcrmessages.GatewayListDHTOfferAckType: func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		},
+		fcrmessages.GatewayPingRequestType: func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			t.Error("handler of a rejected request should not be called")
+			return nil
+		},
+	})
+
+	// Passed to the handler.
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second))
+	response, err := readTCPMessage(client, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, request, response)
+	info := <-seen
+	assert.Equal(t, fcrmiddleware.TransportP2P, info.Transport)
+	assert.Equal(t, "9000", info.ListenAddr)
+	assert.Equal(t, client.LocalAddr().String(), info.RemoteAddr)
+
+	// Rejected by the middleware, the connection is kept.
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":1}`))
+	assert.Empty(t, sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(client, time.Second)
+	assert.Empty(t, err)
+	code, reason, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, code)
+	assert.Equal(t, "ping not allowed", reason)
+	<-seen
+}
+
+func TestShutdown(t *testing.T) {
+	port := utest.GetFreePort()
+	started := make(chan bool)
+	s := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			started <- true
+			time.Sleep(100 * time.Millisecond)
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, s.Start())
+	idle, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer idle.Close()
+	busy, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer busy.Close()
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(busy, request, fcrmessages.CodecJSON, time.Second))
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.Empty(t, s.Shutdown(ctx))
+
+	// In-flight request has been handled before the connection was closed.
+	response, err := readTCPMessage(busy, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, request, response)
+	_, err = readTCPMessage(busy, time.Second)
+	assert.NotEmpty(t, err)
+	_, err = readTCPMessage(idle, time.Second)
+	assert.NotEmpty(t, err)
+
+	// Listener is closed, the address can be reused.
+	_, err = net.Dial("tcp", "localhost:"+port)
+	assert.NotEmpty(t, err)
+	s = NewFCRP2PServer([]string{port}, nil, time.Second)
+	assert.Empty(t, s.Start())
+	assert.Empty(t, s.Shutdown(context.Background()))
+}
+
+func TestShutdownContextExpired(t *testing.T) {
+	port := utest.GetFreePort()
+	started := make(chan bool)
+	release := make(chan bool)
+	s := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			started <- true
+			<-release
+			return nil
+		})
+	assert.Empty(t, s.Start())
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer conn.Close()
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Shutdown(ctx))
+	close(release)
+
+	// Connection has been closed.
+	_, err = readTCPMessage(conn, time.Second)
+	assert.NotEmpty(t, err)
+}
+
+// newTestRegisterMgr starts a register manager knowing a given gateway.
+func newTestRegisterMgr(t *testing.T, gateways ...register.GatewayRegister) (*fcrregistermgr.FCRRegisterMgr, func()) {
+	registerSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/registers/gateway/" && r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(gateways)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	registerMgr := fcrregistermgr.NewFCRRegisterMgr(registerSrv.URL, true, true, time.Minute)
+	assert.Empty(t, registerMgr.Start())
+	registerMgr.Refresh()
+	return registerMgr, func() {
+		registerMgr.Shutdown()
+		registerSrv.Close()
+	}
+}
+
+func TestMultiplexing(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	// Gateway answers slow requests after fast ones.
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			if string(request.GetMessageBody()) == `"slow"` {
+				time.Sleep(200 * time.Millisecond)
+			}
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+			request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(args[0].(string)))
+			if err := writer.Write(request, time.Second); err != nil {
+				return nil, err
+			}
+			return reader.Read(time.Second)
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	done := make(chan string, 2)
+	request := func(body string) {
+		response, err := s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType, body)
+		assert.Empty(t, err)
+		done <- string(response.GetMessageBody())
+	}
+	go request(`"slow"`)
+	time.Sleep(50 * time.Millisecond)
+	go request(`"fast"`)
+	assert.Equal(t, `"fast"`, <-done)
+	assert.Equal(t, `"slow"`, <-done)
+
+	// Both requests shared one multiplexed connection.
+	assert.Equal(t, 1, len(s.pool.activeGateways))
+	assert.NotEmpty(t, s.pool.activeGateways[gatewayID.ToString()][0].mux)
+
+	// Without multiplexing, requests are served one at a time.
+	s2 := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetMultiplexing(false).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, s.requesters[fcrmessages.GatewayListDHTOfferAckType])
+	assert.Empty(t, s2.Start())
+	defer s2.Shutdown(context.Background())
+	response, err := s2.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType, `"fast"`)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`"fast"`), response.GetMessageBody())
+	assert.Empty(t, s2.pool.activeGateways[gatewayID.ToString()][0].mux)
+}
+
+func TestConnectionPool(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			time.Sleep(100 * time.Millisecond)
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+
+	requester := func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+		request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+		if err := writer.Write(request, time.Second); err != nil {
+			return nil, err
+		}
+		return reader.Read(time.Second)
+	}
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetMultiplexing(false).
+		SetMaxConnectionsPerPeer(2).
+		SetHealthCheckInterval(50*time.Millisecond).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, requester)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	// Concurrent requests are spread on up to 2 connections.
+	var requests sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		requests.Add(1)
+		go func() {
+			defer requests.Done()
+			_, err := s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+			assert.Empty(t, err)
+		}()
+		time.Sleep(20 * time.Millisecond)
+	}
+	requests.Wait()
+	assert.Equal(t, PoolStats{Idle: 2, Dialed: 2}, s.GetPoolStats())
+
+	// Health checks keep connections to a live peer.
+	time.Sleep(200 * time.Millisecond)
+	stats := s.GetPoolStats()
+	// A connection being probed is active.
+	assert.Equal(t, 2, stats.Active+stats.Idle)
+	assert.Equal(t, 0, stats.Failed)
+
+	// Connections to a peer gone away fail health checks and are dropped.
+	assert.Empty(t, gateway.Shutdown(context.Background()))
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, PoolStats{Dialed: 2, Failed: 2}, s.GetPoolStats())
+}
+
+func TestIdleEviction(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetMaxIdleTime(100*time.Millisecond).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+			request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+			if err := writer.Write(request, time.Second); err != nil {
+				return nil, err
+			}
+			return reader.Read(time.Second)
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	_, err = s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.Empty(t, err)
+	assert.Equal(t, PoolStats{Idle: 1, Dialed: 1}, s.GetPoolStats())
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, PoolStats{Dialed: 1, Evicted: 1}, s.GetPoolStats())
+
+	// A new connection is dialed for the next request.
+	_, err = s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.Empty(t, err)
+	assert.Equal(t, PoolStats{Idle: 1, Dialed: 2, Evicted: 1}, s.GetPoolStats())
+}
+
+func TestRequestContext(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			if string(request.GetMessageBody()) == `"slow"` {
+				time.Sleep(300 * time.Millisecond)
+			}
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	for _, multiplex := range []bool{true, false} {
+		s := NewFCRP2PServer([]string{}, registerMgr, time.Second).SetMultiplexing(multiplex)
+		assert.Empty(t, s.Start())
+
+		// Types without requester write the request and read the response.
+		request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"fast"`))
+		response, err := s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+		assert.Empty(t, err)
+		assert.Equal(t, request.GetMessageBody(), response.GetMessageBody())
+
+		// Requests are aborted at the deadline of the context.
+		slow := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"slow"`))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err = s.RequestGatewayFromGatewayContext(ctx, gatewayID, slow)
+		cancel()
+		assert.Equal(t, context.DeadlineExceeded, err)
+
+		// Requests are aborted once the context is cancelled.
+		ctx, cancel = context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		start := time.Now()
+		_, err = s.RequestGatewayFromGatewayContext(ctx, gatewayID, slow)
+		assert.Equal(t, context.Canceled, err)
+		assert.True(t, time.Since(start) < 250*time.Millisecond)
+
+		// The pool recovers for the next request.
+		time.Sleep(300 * time.Millisecond)
+		response, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+		assert.Empty(t, err)
+		assert.Equal(t, request.GetMessageBody(), response.GetMessageBody())
+		assert.Empty(t, s.Shutdown(context.Background()))
+	}
+
+	// Context requesters are used for their type.
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		AddContextRequester(fcrmessages.GatewayListDHTOfferAckType, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			if err := writer.WriteContext(ctx, request); err != nil {
+				return nil, err
+			}
+			if _, err := reader.ReadContext(ctx); err != nil {
+				return nil, err
+			}
+			return fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"done"`)), nil
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"fast"`))
+	response, err := s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(`"done"`), response.GetMessageBody())
+}
+
+func TestCircuitBreakerRequests(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetDialTimeout(100*time.Millisecond).
+		SetDialRetry(1, 10*time.Millisecond, 20*time.Millisecond).
+		SetCircuitBreaker(2, 200*time.Millisecond)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+
+	// The breaker opens once the gateway failed twice.
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.NotEmpty(t, err)
+	assert.Equal(t, BreakerClosed, s.GetGatewayState(gatewayID))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.NotEmpty(t, err)
+	assert.Equal(t, BreakerOpen, s.GetGatewayState(gatewayID))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, PoolStats{Failed: 2}, s.GetPoolStats())
+
+	// The breaker closes once a trial request succeeds after the cooldown.
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, s.GetGatewayState(gatewayID))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, BreakerClosed, s.GetGatewayState(gatewayID))
+}
+
+func TestCircuitBreakerCancelledTrial(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetDialTimeout(100*time.Millisecond).
+		SetCircuitBreaker(1, 100*time.Millisecond)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`)))
+	assert.NotEmpty(t, err)
+	assert.Equal(t, BreakerOpen, s.GetGatewayState(gatewayID))
+
+	// Gateway answering slow requests late.
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			if string(request.GetMessageBody()) == `"slow"` {
+				time.Sleep(300 * time.Millisecond)
+			}
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, s.GetGatewayState(gatewayID))
+
+	// The trial request is cancelled, another trial request is allowed and closes the breaker.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = s.RequestGatewayFromGatewayContext(ctx, gatewayID, fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"slow"`)))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, BreakerHalfOpen, s.GetGatewayState(gatewayID))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"fast"`)))
+	assert.Empty(t, err)
+	assert.Equal(t, BreakerClosed, s.GetGatewayState(gatewayID))
+}
+
+func TestCircuitBreakerPeerError(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{port}, nil, time.Second).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.WriteInvalidMessage(time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	// Errors answered by the gateway on a connection without multiplexing neither open the breaker nor drop the connection.
+	errAnswered := errors.New("invalid message answered")
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetCircuitBreaker(1, time.Minute).
+		AddContextRequester(fcrmessages.GatewayListDHTOfferAckType, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+			if err := writer.WriteContext(ctx, request); err != nil {
+				return nil, err
+			}
+			if _, err := reader.ReadContext(ctx); err != nil {
+				return nil, err
+			}
+			return nil, errAnswered
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	for i := 0; i < 2; i++ {
+		_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`)))
+		assert.Equal(t, errAnswered, err)
+		assert.Equal(t, BreakerClosed, s.GetGatewayState(gatewayID))
+	}
+	assert.Equal(t, PoolStats{Idle: 1, Dialed: 1}, s.GetPoolStats())
+}
+
+func TestFrameRequestID(t *testing.T) {
+	client, server := tcpPipe(t)
+	defer client.Close()
+	defer server.Close()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPFrame(client, 7, request, fcrmessages.CodecJSON, time.Second))
+	assert.Empty(t, sendTCPFrame(client, 0, request, fcrmessages.CodecBinary, time.Second))
+	requestID, msg, err := readTCPFrame(server, nil, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, uint32(7), requestID)
+	assert.Equal(t, request, msg)
+	requestID, msg, err = readTCPFrame(server, nil, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, uint32(0), requestID)
+	assert.Equal(t, request, msg)
+}
+
+func TestFrameLimits(t *testing.T) {
+	client, server := tcpPipe(t)
+	defer client.Close()
+	defer server.Close()
+	limits := newFrameLimits()
+	limits.maxSize = 1024
+	limits.classSizes[fcrmessages.GatewayMessageClass] = 32
+
+	// Frames larger than any limit are rejected before being read.
+	_, err := client.Write(appendUint32(nil, 1<<30))
+	assert.Empty(t, err)
+	_, _, err = readTCPFrame(server, limits, time.Second)
+	assert.Equal(t, &frameSizeError{size: 1 << 30, max: 1024}, err)
+
+	// Messages larger than the limit of their class are read and rejected.
+	client2, server2 := tcpPipe(t)
+	defer client2.Close()
+	defer server2.Close()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPFrame(client2, 3, request, fcrmessages.CodecJSON, time.Second))
+	requestID, msg, err := readTCPFrame(server2, limits, time.Second)
+	sizeErr, ok := err.(*frameSizeError)
+	assert.True(t, ok)
+	assert.True(t, sizeErr.read)
+	assert.Equal(t, uint32(3), requestID)
+	assert.Equal(t, request, msg)
+
+	// A timeout before a frame starts leaves the connection usable, a timeout in the middle of a frame does not.
+	_, _, err = readTCPFrame(server2, limits, 50*time.Millisecond)
+	assert.True(t, isTimeoutError(err))
+	_, err = client2.Write([]byte{0, 0})
+	assert.Empty(t, err)
+	_, _, err = readTCPFrame(server2, limits, 50*time.Millisecond)
+	assert.Equal(t, errSlowPeer, err)
+
+	// Large frames get more time at the minimum throughput.
+	assert.Equal(t, time.Second, limits.transferTime(defaultMinThroughput))
+	limits.minThroughput = 0
+	assert.Equal(t, time.Duration(0), limits.transferTime(defaultMinThroughput))
+
+	// Frame size limits never let a plaintext frame start with the TLS handshake record type.
+	s := NewFCRP2PServer([]string{}, nil, time.Second).
+		SetMaxFrameSize(maxFrameSizeLimit+1).
+		SetMaxFrameSizeForClass(fcrmessages.GatewayMessageClass, maxFrameSizeLimit+1)
+	assert.Equal(t, uint32(defaultMaxFrameSize), s.limits.maxFrameSize())
+	s.SetMaxFrameSize(maxFrameSizeLimit)
+	assert.Equal(t, maxFrameSizeLimit, s.limits.maxFrameSize())
+	assert.True(t, byte(maxFrameSizeLimit>>24) < tlsRecordTypeHandshake)
+}
+
+func TestFrameMemory(t *testing.T) {
+	client, server := tcpPipe(t)
+	defer client.Close()
+	defer server.Close()
+
+	// Peer announcing a frame of the maximum size and sending only its start.
+	frame := appendUint32(nil, defaultMaxFrameSize)
+	frame = append(frame, make([]byte, 1024)...)
+	_, err := client.Write(frame)
+	assert.Empty(t, err)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, _, err = readFrame(server, nil, func(uint32) time.Time { return time.Now().Add(100 * time.Millisecond) })
+	runtime.ReadMemStats(&after)
+	assert.Equal(t, errSlowPeer, err)
+	assert.True(t, after.TotalAlloc-before.TotalAlloc < 1<<20)
+}
+
+func TestServerFrameLimits(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRP2PServer([]string{port}, nil, 50*time.Millisecond).
+		SetMaxFrameSize(1024).
+		SetMaxFrameSizeForClass(fcrmessages.GatewayMessageClass, 128).
+		SetIdleTimeout(100*time.Millisecond).
+		AddHandler(port, fcrmessages.GatewayPingRequestType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer conn.Close()
+
+	// Messages too large for their class are rejected, the connection is kept.
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, bytes.Repeat([]byte("0"), 64))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err := readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageTooLarge, code)
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, request, response)
+
+	// Frames too large are rejected and the connection is dropped.
+	_, err = conn.Write(appendUint32(nil, 1<<30))
+	assert.Empty(t, err)
+	response, err = readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err = fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageTooLarge, code)
+	_, err = readTCPMessage(conn, time.Second)
+	assert.Equal(t, io.EOF, err)
+
+	// Connections without request are dropped after the idle timeout.
+	conn2, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer conn2.Close()
+	start := time.Now()
+	_, err = readTCPMessage(conn2, time.Second)
+	assert.Equal(t, io.EOF, err)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+func TestAuthentication(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	clientID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	gatewayKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	clientKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	otherKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	gatewayPubKey, err := gatewayKey.EncodePublicKey()
+	assert.Empty(t, err)
+	clientPubKey, err := clientKey.EncodePublicKey()
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t,
+		register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port, SigningKey: gatewayPubKey},
+		register.GatewayRegister{NodeID: clientID.ToString(), SigningKey: clientPubKey})
+	defer stop()
+
+	// Gateway answers with the node id of the authenticated peer.
+	gateway := NewFCRP2PServer([]string{port}, registerMgr, time.Second).
+		SetAuthentication(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion()).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			body := []byte("")
+			if peerID := reader.GetPeerID(); peerID != nil {
+				body = []byte(peerID.ToString())
+			}
+			return writer.Write(fcrmessages.CreateFCRMessage(request.GetMessageType(), body), time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	requester := func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+		if err := writer.Write(fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`)), time.Second); err != nil {
+			return nil, err
+		}
+		return reader.Read(time.Second)
+	}
+
+	// Authenticated client, the gateway knows who it is.
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetAuthentication(clientID, clientKey, fcrcrypto.InitialKeyVersion()).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, requester)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	response, err := s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(clientID.ToString()), response.GetMessageBody())
+
+	// Client claiming the node id with another key, authentication fails.
+	s2 := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetAuthentication(clientID, otherKey, fcrcrypto.InitialKeyVersion()).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, requester)
+	assert.Empty(t, s2.Start())
+	defer s2.Shutdown(context.Background())
+	_, err = s2.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.NotEmpty(t, err)
+	assert.Equal(t, 1, s2.GetPoolStats().Failed)
+
+	// Gateway answering with another key, authentication fails.
+	impostor := &authenticator{nodeID: gatewayID, keyPair: otherKey, keyVersion: fcrcrypto.InitialKeyVersion(), registerMgr: registerMgr}
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go func() {
+		auth := newHandshake(impostor, nil)
+		for !auth.isDone() {
+			request, err := readTCPMessage(server, time.Second)
+			if err != nil {
+				return
+			}
+			response, err := auth.handle(request)
+			if err != nil {
+				return
+			}
+			sendTCPMessage(server, response, fcrmessages.CodecJSON, time.Second)
+		}
+	}()
+	err = s.auth.authenticate(
+		context.Background(),
+		&FCRServerWriter{writer: newConnWriter(client, fcrmessages.CodecJSON, nil)},
+		&FCRServerReader{conn: client},
+		gatewayID,
+		gatewayKey)
+	assert.NotEmpty(t, err)
+
+	// Unauthenticated request, rejected.
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer conn.Close()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageUnauthenticated, code)
+
+	// Unauthenticated protocol change request other than a negotiation, rejected.
+	request, err = fcrmessages.EncodeProtocolChangeRequest(1)
+	assert.Empty(t, err)
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err = fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageUnauthenticated, code)
+}
+
+func TestAuthenticationRelay(t *testing.T) {
+	gatewayPort := utest.GetFreePort()
+	otherPort := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	otherID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	gatewayKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	otherKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	gatewayPubKey, err := gatewayKey.EncodePublicKey()
+	assert.Empty(t, err)
+	otherPubKey, err := otherKey.EncodePublicKey()
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t,
+		register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + gatewayPort, SigningKey: gatewayPubKey},
+		register.GatewayRegister{NodeID: otherID.ToString(), NetworkInfoGateway: "localhost:" + otherPort, SigningKey: otherPubKey})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{gatewayPort}, registerMgr, time.Second).
+		SetAuthentication(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+	other := NewFCRP2PServer([]string{otherPort}, registerMgr, time.Second).
+		SetAuthentication(otherID, otherKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, other.Start())
+	defer other.Shutdown(context.Background())
+
+	exchange := func(conn net.Conn, request *fcrmessages.FCRMessage) *fcrmessages.FCRMessage {
+		assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+		response, err := readTCPMessage(conn, time.Second)
+		assert.Empty(t, err)
+		return response
+	}
+	toGateway, err := net.Dial("tcp", "localhost:"+gatewayPort)
+	assert.Empty(t, err)
+	defer toGateway.Close()
+	toOther, err := net.Dial("tcp", "localhost:"+otherPort)
+	assert.Empty(t, err)
+	defer toOther.Close()
+
+	// Man in the middle claiming to be the other gateway to the gateway, and the gateway to the other gateway.
+	request, err := fcrmessages.EncodeAuthenticationRequest(otherID, challenge.NewRandomChallenge())
+	assert.Empty(t, err)
+	_, _, gatewayChallenge, err := fcrmessages.DecodeAuthenticationResponse(exchange(toGateway, request))
+	assert.Empty(t, err)
+	request, err = fcrmessages.EncodeAuthenticationRequest(gatewayID, gatewayChallenge)
+	assert.Empty(t, err)
+	_, otherSignature, _, err := fcrmessages.DecodeAuthenticationResponse(exchange(toOther, request))
+	assert.Empty(t, err)
+
+	// The signature of the other gateway answering the challenge of the gateway is not a proof.
+	proof, err := fcrmessages.EncodeAuthenticationProof(otherSignature)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(exchange(toGateway, proof))
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageUnauthenticated, code)
+}
+
+func TestEncryption(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	clientID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	gatewayKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	clientKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	otherKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	gatewayPubKey, err := gatewayKey.EncodePublicKey()
+	assert.Empty(t, err)
+	clientPubKey, err := clientKey.EncodePublicKey()
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t,
+		register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port, SigningKey: gatewayPubKey},
+		register.GatewayRegister{NodeID: clientID.ToString(), SigningKey: clientPubKey})
+	defer stop()
+
+	// Gateway requires encryption and answers with the node id of the peer.
+	gateway := NewFCRP2PServer([]string{port}, registerMgr, time.Second).
+		SetEncryption(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion(), false).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			body := []byte("")
+			if peerID := reader.GetPeerID(); peerID != nil {
+				body = []byte(peerID.ToString())
+			}
+			return writer.Write(fcrmessages.CreateFCRMessage(request.GetMessageType(), body), time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	requester := func(reader *FCRServerReader, writer *FCRServerWriter, args ...interface{}) (*fcrmessages.FCRMessage, error) {
+		if err := writer.Write(fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`)), time.Second); err != nil {
+			return nil, err
+		}
+		return reader.Read(time.Second)
+	}
+
+	// Encrypted client, the gateway knows who it is.
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetEncryption(clientID, clientKey, fcrcrypto.InitialKeyVersion(), false).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, requester)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	response, err := s.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(clientID.ToString()), response.GetMessageBody())
+
+	// Client claiming the node id with another key, the handshake fails.
+	s2 := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetEncryption(clientID, otherKey, fcrcrypto.InitialKeyVersion(), false).
+		AddRequester(fcrmessages.GatewayListDHTOfferAckType, requester)
+	assert.Empty(t, s2.Start())
+	defer s2.Shutdown(context.Background())
+	_, err = s2.RequestGatewayFromGateway(gatewayID, fcrmessages.GatewayListDHTOfferAckType)
+	assert.NotEmpty(t, err)
+
+	// Plaintext client, the connection is dropped.
+	conn, err := net.Dial("tcp", "localhost:"+port)
+	assert.Empty(t, err)
+	defer conn.Close()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	_, err = readTCPMessage(conn, time.Second)
+	assert.Equal(t, io.EOF, err)
+
+	// Plaintext client of a gateway allowing plaintext, the request is handled without peer id.
+	s3 := NewFCRP2PServer([]string{}, nil, time.Second).SetEncryption(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion(), true)
+	client, server := tcpPipe(t)
+	defer client.Close()
+	go s3.handleIncomingConnection(server, "", gateway.handlers[port])
+	assert.Empty(t, sendTCPMessage(client, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(client, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(""), response.GetMessageBody())
+}
+
+func TestEncryptionWithAuthentication(t *testing.T) {
+	port := utest.GetFreePort()
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	clientID, err := nodeid.NewNodeIDFromHexString("02")
+	assert.Empty(t, err)
+	otherID, err := nodeid.NewNodeIDFromHexString("03")
+	assert.Empty(t, err)
+	gatewayKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	clientKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	otherKey, err := fcrcrypto.GenerateRetrievalV1KeyPair()
+	assert.Empty(t, err)
+	gatewayPubKey, err := gatewayKey.EncodePublicKey()
+	assert.Empty(t, err)
+	clientPubKey, err := clientKey.EncodePublicKey()
+	assert.Empty(t, err)
+	otherPubKey, err := otherKey.EncodePublicKey()
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t,
+		register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "localhost:" + port, SigningKey: gatewayPubKey},
+		register.GatewayRegister{NodeID: clientID.ToString(), SigningKey: clientPubKey},
+		register.GatewayRegister{NodeID: otherID.ToString(), SigningKey: otherPubKey})
+	defer stop()
+
+	// Gateway requires encryption and authentication and answers with the node id of the peer.
+	gateway := NewFCRP2PServer([]string{port}, registerMgr, time.Second).
+		SetEncryption(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion(), false).
+		SetAuthentication(gatewayID, gatewayKey, fcrcrypto.InitialKeyVersion()).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(fcrmessages.CreateFCRMessage(request.GetMessageType(), []byte(reader.GetPeerID().ToString())), time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+
+	// Client authenticating as the node of its certificate.
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetEncryption(clientID, clientKey, fcrcrypto.InitialKeyVersion(), false).
+		SetAuthentication(clientID, clientKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	response, err := s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, []byte(clientID.ToString()), response.GetMessageBody())
+
+	// Client authenticating as another node than the node of its certificate, the handshake fails.
+	s2 := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetEncryption(clientID, clientKey, fcrcrypto.InitialKeyVersion(), false).
+		SetAuthentication(otherID, otherKey, fcrcrypto.InitialKeyVersion())
+	assert.Empty(t, s2.Start())
+	defer s2.Shutdown(context.Background())
+	_, err = s2.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.NotEmpty(t, err)
+	assert.Equal(t, 1, s2.GetPoolStats().Failed)
+}
+
+func TestRequestLimits(t *testing.T) {
+	transport := NewMemoryTransport()
+	s := NewFCRP2PServer([]string{"gateway"}, nil, time.Second).
+		SetTransport(transport).
+		SetMaxConnections(1).
+		Use(fcrmiddleware.NewLimiter().SetRate(0.001, 1).Middleware()).
+		AddHandler("gateway", fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	conn, err := transport.Dial(context.Background(), "gateway")
+	assert.Empty(t, err)
+	defer conn.Close()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err := readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, request, response)
+
+	// Over quota, rejected and the connection is kept.
+	assert.Empty(t, sendTCPMessage(conn, request, fcrmessages.CodecJSON, time.Second))
+	response, err = readTCPMessage(conn, time.Second)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageRateLimited, code)
+
+	// Too many connections, the new connection is closed.
+	conn2, err := transport.Dial(context.Background(), "gateway")
+	assert.Empty(t, err)
+	defer conn2.Close()
+	_, err = readTCPMessage(conn2, time.Second)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestHandlerPanic(t *testing.T) {
+	s := NewFCRP2PServer([]string{}, nil, time.Second)
+	handlers := map[int32]func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error{
+		fcrmessages.GatewayListDHTOfferAckType: func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			if string(request.GetMessageBody()) == `"panic"` {
+				panic("handler failure")
+			}
+			return writer.Write(request, time.Second)
+		},
+	}
+	for _, requestID := range []uint32{0, 1} {
+		client, server := tcpPipe(t)
+		defer client.Close()
+		other, otherServer := tcpPipe(t)
+		defer other.Close()
+		go s.handleIncomingConnection(server, "", handlers)
+		go s.handleIncomingConnection(otherServer, "", handlers)
+
+		// The connection of the panicking handler is dropped.
+		request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"panic"`))
+		assert.Empty(t, sendTCPFrame(client, requestID, request, fcrmessages.CodecJSON, time.Second))
+		_, _, err := readTCPFrame(client, nil, time.Second)
+		assert.Equal(t, io.EOF, err)
+
+		// Other connections are still served.
+		request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`"ok"`))
+		assert.Empty(t, sendTCPFrame(other, requestID, request, fcrmessages.CodecJSON, time.Second))
+		id, response, err := readTCPFrame(other, nil, time.Second)
+		assert.Empty(t, err)
+		assert.Equal(t, requestID, id)
+		assert.Equal(t, request, response)
+	}
+}
@@ -16,36 +16,76 @@ package fcrp2pserver
  */
 
 import (
-	"net"
+	"context"
 	"time"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
 )
 
-// FCRServerWriter stores the connection to write to.
+// FCRServerWriter writes messages of a request to a connection.
+// Messages are signed if signing is enabled and carry the request id if the connection is multiplexed.
+// Messages of a multiplexed request wait for the credit of the stream window held by streams.
 type FCRServerWriter struct {
-	conn net.Conn
+	writer    *connWriter
+	requestID uint32
+	streams   *streams
 }
 
-// Write writes a given message.
+// GetCodec returns the codec used to write messages.
+func (w *FCRServerWriter) GetCodec() fcrmessages.Codec {
+	return w.writer.getCodec()
+}
+
+// Write writes a given message, the message is signed first if signing is enabled.
+// On a multiplexed connection, the timeout also bounds the wait for the peer to read previous messages.
 func (w *FCRServerWriter) Write(msg *fcrmessages.FCRMessage, timeout time.Duration) error {
-	return sendTCPMessage(w.conn, msg, timeout)
+	if w.streams != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := w.streams.acquire(ctx, w.requestID, len(msg.GetMessageBody())); err != nil {
+			return err
+		}
+	}
+	return w.writer.write(w.requestID, msg, timeout)
+}
+
+// WriteContext writes a given message before the deadline of a given context, the message is signed first if signing is enabled.
+// The write is aborted if the context is cancelled, unless the connection is multiplexed. On a multiplexed connection,
+// the message first waits for the peer to read previous messages.
+func (w *FCRServerWriter) WriteContext(ctx context.Context, msg *fcrmessages.FCRMessage) error {
+	if w.streams != nil {
+		if err := w.streams.acquire(ctx, w.requestID, len(msg.GetMessageBody())); err != nil {
+			return err
+		}
+	}
+	return w.writer.writeContext(ctx, w.requestID, msg)
 }
 
 // WriteProtocolChanged writes a protocol changed message.
 func (w *FCRServerWriter) WriteProtocolChanged(timeout time.Duration) error {
 	fcrMsg, _ := fcrmessages.EncodeProtocolChangeResponse(true)
-	return sendTCPMessage(w.conn, fcrMsg, timeout)
+	return w.Write(fcrMsg, timeout)
 }
 
 // WriteProtocolMismatch sends a protocol mistmatch message.
 func (w *FCRServerWriter) WriteProtocolMismatch(timeout time.Duration) error {
 	fcrMsg, _ := fcrmessages.EncodeProtocolChangeResponse(false)
-	return sendTCPMessage(w.conn, fcrMsg, timeout)
+	return w.Write(fcrMsg, timeout)
 }
 
 // WriteInvalidMessage sends an invalid message.
 func (w *FCRServerWriter) WriteInvalidMessage(timeout time.Duration) error {
 	fcrMsg, _ := fcrmessages.EncodeInvalidMessageResponse()
-	return sendTCPMessage(w.conn, fcrMsg, timeout)
+	return w.Write(fcrMsg, timeout)
+}
+
+// WriteInvalidMessageWithReason sends an invalid message explaining why a given request has been rejected.
+func (w *FCRServerWriter) WriteInvalidMessageWithReason(code fcrmessages.InvalidMessageCode, reason string, request *fcrmessages.FCRMessage, timeout time.Duration) error {
+	fcrMsg, _ := fcrmessages.EncodeInvalidMessageResponseWithReason(code, reason, request)
+	return w.Write(fcrMsg, timeout)
+}
+
+// WriteInvalidMessageFromError sends an invalid message for a given request that could not be decoded.
+func (w *FCRServerWriter) WriteInvalidMessageFromError(err error, request *fcrmessages.FCRMessage, timeout time.Duration) error {
+	return w.WriteInvalidMessageWithReason(fcrmessages.InvalidMessageCodeFromError(err), err.Error(), request, timeout)
 }
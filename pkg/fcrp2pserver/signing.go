@@ -0,0 +1,66 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"errors"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrcrypto"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// messageSigner signs outgoing messages with the signing key of this node.
+type messageSigner struct {
+	keyPair    *fcrcrypto.KeyPair
+	keyVersion *fcrcrypto.KeyVersion
+}
+
+// sign signs a given message, a nil signer leaves the message unsigned.
+func (s *messageSigner) sign(msg *fcrmessages.FCRMessage) error {
+	if s == nil {
+		return nil
+	}
+	return msg.Sign(s.keyPair, s.keyVersion)
+}
+
+// errPeerUnknown is returned when a message is received before the signing key of the peer is known.
+var errPeerUnknown = errors.New("peer unknown, messages are only verified once the peer is identified")
+
+// messageVerifier verifies incoming messages against the registered signing key of the peer.
+// The key is known when the connection is dialed. For incoming connections it is set once the peer is identified
+// by its TLS certificate or by the authentication handshake, messages received before are rejected.
+type messageVerifier struct {
+	peerKey *fcrcrypto.KeyPair
+}
+
+// verify verifies a given message, a nil verifier accepts every message.
+func (v *messageVerifier) verify(msg *fcrmessages.FCRMessage) error {
+	if v == nil {
+		return nil
+	}
+	if msg.GetSignature() == "" {
+		return errors.New("message is not signed")
+	}
+	if v.peerKey == nil {
+		return errPeerUnknown
+	}
+	return msg.Verify(v.peerKey)
+}
+
+// isPeerKnown checks if the signing key of the peer is known, it is always true if signing is disabled.
+func (v *messageVerifier) isPeerKnown() bool {
+	return v == nil || v.peerKey != nil
+}
@@ -0,0 +1,156 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+)
+
+// Streamed responses:
+//
+// A handler streams a response by writing any number of chunk messages, of any type, followed by a StreamEnd message
+// carrying the number of chunks sent. Each chunk is a frame of its own, checked against the frame limits, so that
+// neither side holds the whole response in memory. On multiplexed connections the chunks are queued to the stream of
+// the request without holding the connection, and the sender waits once the reader falls behind by the stream window
+// of streamMaxMessages chunks or streamMaxBytes. On other connections the sender waits once the connection is full.
+
+// StreamWriter writes a response as a sequence of chunks terminated by an end of stream message.
+type StreamWriter struct {
+	writer *FCRServerWriter
+	chunks int64
+	closed bool
+}
+
+// Stream starts writing a streamed response.
+func (w *FCRServerWriter) Stream() *StreamWriter {
+	return &StreamWriter{writer: w}
+}
+
+// Write writes a given chunk.
+func (s *StreamWriter) Write(chunk *fcrmessages.FCRMessage, timeout time.Duration) error {
+	if s.closed {
+		return errors.New("stream already closed")
+	}
+	if err := s.writer.Write(chunk, timeout); err != nil {
+		return err
+	}
+	s.chunks++
+	return nil
+}
+
+// Close ends the stream once every chunk has been written.
+func (s *StreamWriter) Close(timeout time.Duration) error {
+	return s.end("", timeout)
+}
+
+// Abort ends the stream before every chunk has been written because of a given error, which is passed to the reader.
+func (s *StreamWriter) Abort(reason error, timeout time.Duration) error {
+	return s.end(reason.Error(), timeout)
+}
+
+// end writes the end of stream message with a given error.
+func (s *StreamWriter) end(streamErr string, timeout time.Duration) error {
+	if s.closed {
+		return errors.New("stream already closed")
+	}
+	s.closed = true
+	end, err := fcrmessages.EncodeStreamEnd(s.chunks, streamErr)
+	if err != nil {
+		return err
+	}
+	return s.writer.Write(end, timeout)
+}
+
+// StreamReader iterates over the chunks of a streamed response:
+//
+//	chunks := reader.Stream(timeout)
+//	for chunks.Next() {
+//		process(chunks.Message())
+//	}
+//	if err := chunks.Err(); err != nil {
+//		return err
+//	}
+type StreamReader struct {
+	read   func() (*fcrmessages.FCRMessage, error)
+	chunk  *fcrmessages.FCRMessage
+	chunks int64
+	done   bool
+	err    error
+}
+
+// Stream returns an iterator over the chunks of a streamed response, each chunk must arrive within a given timeout.
+func (r *FCRServerReader) Stream(timeout time.Duration) *StreamReader {
+	return &StreamReader{read: func() (*fcrmessages.FCRMessage, error) { return r.Read(timeout) }}
+}
+
+// StreamContext returns an iterator over the chunks of a streamed response, reading stops once a given context is done.
+func (r *FCRServerReader) StreamContext(ctx context.Context) *StreamReader {
+	return &StreamReader{read: func() (*fcrmessages.FCRMessage, error) { return r.ReadContext(ctx) }}
+}
+
+// Next reads the next chunk, it returns false once the stream has ended or has failed.
+func (s *StreamReader) Next() bool {
+	if s.done {
+		return false
+	}
+	s.chunk = nil
+	msg, err := s.read()
+	if err != nil {
+		return s.fail(err)
+	}
+	switch msg.GetMessageType() {
+	case fcrmessages.StreamEndType:
+		chunks, streamErr, err := fcrmessages.DecodeStreamEnd(msg)
+		switch {
+		case err != nil:
+			return s.fail(err)
+		case streamErr != "":
+			return s.fail(fmt.Errorf("stream aborted by peer: %s", streamErr))
+		case chunks != s.chunks:
+			return s.fail(fmt.Errorf("stream truncated, received %d chunks out of %d", s.chunks, chunks))
+		}
+		s.done = true
+		return false
+	case fcrmessages.InvalidMessageResponseType:
+		_, reason, _, _, _ := fcrmessages.DecodeInvalidMessageResponseWithReason(msg)
+		return s.fail(fmt.Errorf("stream rejected by peer: %s", reason))
+	}
+	s.chunk = msg
+	s.chunks++
+	return true
+}
+
+// fail ends the iteration with a given error.
+func (s *StreamReader) fail(err error) bool {
+	s.done = true
+	s.err = err
+	return false
+}
+
+// Message returns the chunk read by the last call to Next.
+func (s *StreamReader) Message() *fcrmessages.FCRMessage {
+	return s.chunk
+}
+
+// Err returns the error that ended the iteration, it returns nil if the stream ended normally.
+func (s *StreamReader) Err() error {
+	return s.err
+}
@@ -0,0 +1,247 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/register"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreaming(t *testing.T) {
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "gateway"})
+	defer stop()
+	transport := NewMemoryTransport()
+
+	// Gateway streams as many chunks as requested, and aborts after the first chunk if asked to.
+	gateway := NewFCRP2PServer([]string{"gateway"}, nil, time.Second).
+		SetTransport(transport).
+		AddHandler("gateway", fcrmessages.GatewayListDHTOfferRequestType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			count, err := strconv.Atoi(string(request.GetMessageBody()))
+			chunks := writer.Stream()
+			for i := 0; i < count || (err != nil && i == 0); i++ {
+				chunk := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferResponseType, []byte(strconv.Itoa(i)))
+				if err := chunks.Write(chunk, time.Second); err != nil {
+					return err
+				}
+			}
+			if err != nil {
+				return chunks.Abort(errors.New("offers unavailable"), time.Second)
+			}
+			return chunks.Close(time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	// Client reads the chunks slower than they are sent.
+	received := make([]string, 0)
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetTransport(transport).
+		AddContextRequester(fcrmessages.GatewayListDHTOfferRequestType, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+			if err := writer.WriteContext(ctx, request); err != nil {
+				return nil, err
+			}
+			chunks := reader.StreamContext(ctx)
+			for chunks.Next() {
+				received = append(received, string(chunks.Message().GetMessageBody()))
+				time.Sleep(time.Millisecond)
+			}
+			return nil, chunks.Err()
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	// More chunks than a multiplexed stream buffers, none is dropped.
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferRequestType, []byte("50"))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, 50, len(received))
+	assert.Equal(t, "49", received[49])
+
+	// Empty stream.
+	received = received[:0]
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferRequestType, []byte("0"))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Empty(t, received)
+
+	// Aborted stream, the chunks sent are received.
+	request = fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferRequestType, []byte("abort"))
+	_, err = s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.EqualError(t, err, "stream aborted by peer: offers unavailable")
+	assert.Equal(t, []string{"0"}, received)
+}
+
+func TestStreamReaderErrors(t *testing.T) {
+	client, server := tcpPipe(t)
+	defer client.Close()
+	defer server.Close()
+	reader := &FCRServerReader{conn: client}
+
+	// Chunks missing.
+	chunk := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferResponseType, []byte(`{}`))
+	assert.Empty(t, sendTCPMessage(server, chunk, fcrmessages.CodecJSON, time.Second))
+	end, err := fcrmessages.EncodeStreamEnd(2, "")
+	assert.Empty(t, err)
+	assert.Empty(t, sendTCPMessage(server, end, fcrmessages.CodecJSON, time.Second))
+	chunks := reader.Stream(time.Second)
+	assert.True(t, chunks.Next())
+	assert.Equal(t, chunk, chunks.Message())
+	assert.False(t, chunks.Next())
+	assert.EqualError(t, chunks.Err(), "stream truncated, received 1 chunks out of 2")
+	assert.False(t, chunks.Next())
+
+	// Request rejected.
+	rejected, err := fcrmessages.EncodeInvalidMessageResponseWithReason(fcrmessages.InvalidMessageUnknownType, "no handler", nil)
+	assert.Empty(t, err)
+	assert.Empty(t, sendTCPMessage(server, rejected, fcrmessages.CodecJSON, time.Second))
+	chunks = reader.Stream(time.Second)
+	assert.False(t, chunks.Next())
+	assert.EqualError(t, chunks.Err(), "stream rejected by peer: no handler")
+}
+
+func TestStreamOverflow(t *testing.T) {
+	s := newStreams(nil, time.Second)
+	st := s.open(1)
+	other := s.open(2)
+	chunk := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferResponseType, []byte(`{}`))
+
+	// Delivering to the stream of a peer a whole window ahead does not wait, the stream is reset and the other
+	// streams are kept.
+	for i := 0; i < 2*streamMaxMessages; i++ {
+		assert.True(t, s.deliver(1, chunk))
+	}
+	assert.True(t, s.deliver(1, chunk))
+	assert.True(t, s.deliver(2, chunk))
+	_, err := st.read(time.Second)
+	assert.Equal(t, errStreamOverflow, err)
+	msg, err := other.read(time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, chunk, msg)
+
+	// Messages of a reset stream are dropped until it is released.
+	assert.True(t, s.deliver(1, chunk))
+	_, err = st.read(time.Second)
+	assert.Equal(t, errStreamOverflow, err)
+	s.release(1)
+	assert.False(t, s.deliver(1, chunk))
+
+	// Messages received before the stream is released can still be read.
+	assert.True(t, s.deliver(2, chunk))
+	s.release(2)
+	msg, err = other.read(time.Second)
+	assert.Empty(t, err)
+	assert.Equal(t, chunk, msg)
+	_, err = other.read(time.Second)
+	assert.Equal(t, errConnectionClosed, err)
+}
+
+func TestStreamWindow(t *testing.T) {
+	s := newStreams(nil, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The sender waits once the window is used.
+	for i := 0; i < streamMaxMessages; i++ {
+		assert.Empty(t, s.acquire(ctx, 1, 1))
+	}
+	assert.Equal(t, context.DeadlineExceeded, s.acquire(ctx, 1, 1))
+
+	// Credit given back wakes the sender, it never exceeds the window.
+	done := make(chan error)
+	go func() { done <- s.acquire(context.Background(), 1, 1) }()
+	update, err := fcrmessages.EncodeStreamWindowUpdate(2*streamMaxMessages, 2*streamMaxBytes)
+	assert.Empty(t, err)
+	assert.True(t, s.deliver(1, update))
+	assert.Empty(t, <-done)
+	assert.Equal(t, streamMaxMessages-1, s.windows[1].messages)
+	assert.Equal(t, streamMaxBytes-1, s.windows[1].bytes)
+
+	// Messages larger than the credit left are sent while half of the window is left.
+	assert.Empty(t, s.acquire(context.Background(), 2, streamMaxBytes/2))
+	assert.Empty(t, s.acquire(context.Background(), 2, streamMaxBytes))
+	assert.Equal(t, -streamMaxBytes/2, s.windows[2].bytes)
+
+	// Closing the streams wakes the senders waiting for credit.
+	go func() { done <- s.acquire(context.Background(), 2, 1) }()
+	s.closeAll()
+	assert.Equal(t, errConnectionClosed, <-done)
+}
+
+func TestStreamBackPressure(t *testing.T) {
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: "gateway"})
+	defer stop()
+	transport := NewMemoryTransport()
+
+	// Gateway streams three windows of chunks.
+	var written int64
+	gateway := NewFCRP2PServer([]string{"gateway"}, nil, time.Second).
+		SetTransport(transport).
+		AddHandler("gateway", fcrmessages.GatewayListDHTOfferRequestType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			chunks := writer.Stream()
+			for i := 0; i < 3*streamMaxMessages; i++ {
+				chunk := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferResponseType, []byte(strconv.Itoa(i)))
+				if err := chunks.Write(chunk, 5*time.Second); err != nil {
+					return err
+				}
+				atomic.AddInt64(&written, 1)
+			}
+			return chunks.Close(5 * time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	// Client stalls after the first chunk, the gateway waits for it instead of overflowing the stream.
+	received := 0
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).
+		SetTransport(transport).
+		AddContextRequester(fcrmessages.GatewayListDHTOfferRequestType, func(ctx context.Context, reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) (*fcrmessages.FCRMessage, error) {
+			if err := writer.WriteContext(ctx, request); err != nil {
+				return nil, err
+			}
+			chunks := reader.StreamContext(ctx)
+			for chunks.Next() {
+				if received++; received == 1 {
+					for start := time.Now(); atomic.LoadInt64(&written) < streamMaxMessages && time.Since(start) < 2*time.Second; {
+						time.Sleep(10 * time.Millisecond)
+					}
+					time.Sleep(100 * time.Millisecond)
+					assert.Equal(t, int64(streamMaxMessages), atomic.LoadInt64(&written))
+				}
+			}
+			return nil, chunks.Err()
+		})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferRequestType, []byte(`{}`))
+	_, err = s.RequestGatewayFromGatewayContext(ctx, gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, 3*streamMaxMessages, received)
+}
@@ -16,8 +16,10 @@ package fcrp2pserver
  */
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"io"
 	"net"
 	"time"
@@ -31,73 +33,265 @@ func isTimeoutError(err error) bool {
 	return ok && neterr.Timeout()
 }
 
+// Frame layout, all integers are big-endian:
+//
+//	length       4 bytes (uint32), the highest bit is set if a request id follows
+//	request id   4 bytes (uint32), only present if the flag is set
+//	data         length bytes, the message encoded with the codec of the connection
+//
+// Frames without request id have request id 0.
+const (
+	frameRequestIDFlag uint32 = 1 << 31
+	frameLengthMask    uint32 = frameRequestIDFlag - 1
+)
+
+// frameReadChunk is the size of the buffer first allocated for the data of a frame, the buffer then grows with the
+// data received so that a peer announcing a large frame does not take the memory for it before sending it.
+const frameReadChunk = 64 << 10
+
 // readTCPMessage read the tcp message from a given connection.
 func readTCPMessage(conn net.Conn, timeout time.Duration) (*fcrmessages.FCRMessage, error) {
-	// Initialise a reader
-	reader := bufio.NewReader(conn)
-	// Read the length
-	length := make([]byte, 4)
+	_, msg, err := readTCPFrame(conn, nil, timeout)
+	return msg, err
+}
+
+// readTCPFrame reads a frame from a given connection within given limits, it returns the request id and the message of the frame.
+// The frame must start within a given timeout, and be received within the timeout plus the transfer time allowed by the limits.
+func readTCPFrame(conn net.Conn, limits *frameLimits, timeout time.Duration) (uint32, *fcrmessages.FCRMessage, error) {
+	return readFrame(conn, limits, func(size uint32) time.Time { return time.Now().Add(timeout + limits.transferTime(size)) })
+}
+
+// readTCPFrameContext reads a frame from a given connection within given limits before the deadline of a given context.
+// The read is aborted if the context is cancelled, a context without deadline waits until a frame arrives.
+func readTCPFrameContext(ctx context.Context, conn net.Conn, limits *frameLimits) (uint32, *fcrmessages.FCRMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	stop := interruptOnDone(ctx, conn)
+	requestID, msg, err := readFrame(conn, limits, func(uint32) time.Time { return deadline })
+	if stop() {
+		return 0, nil, ctx.Err()
+	}
+	return requestID, msg, contextError(err, deadline)
+}
+
+// readFrame reads a frame from a given connection within given limits.
+// The read deadline of the frame, depending on its size, is set before reading the header and the data.
+// A timeout before the frame starts is returned as is, the connection can still be used. Once the frame
+// has started, a timeout returns errSlowPeer.
+func readFrame(conn net.Conn, limits *frameLimits, deadline func(size uint32) time.Time) (uint32, *fcrmessages.FCRMessage, error) {
 	// Set timeout
-	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
-		panic(err)
+	if err := conn.SetReadDeadline(deadline(0)); err != nil {
+		return 0, nil, err
 	}
-	_, err := io.ReadFull(reader, length)
-	if err != nil {
-		return nil, err
+	// Read the length
+	header := make([]byte, 4)
+	if n, err := io.ReadFull(conn, header); err != nil {
+		if n > 0 && isTimeoutError(err) {
+			return 0, nil, errSlowPeer
+		}
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	var requestID uint32
+	if length&frameRequestIDFlag != 0 {
+		// Read the request id
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return 0, nil, slowPeerError(err)
+		}
+		requestID = binary.BigEndian.Uint32(header)
+		length &= frameLengthMask
+	}
+	// Reject frames too large before reading them.
+	if max := limits.maxFrameSize(); length > max {
+		return requestID, nil, &frameSizeError{size: length, max: max}
 	}
-	// Read the data
-	data := make([]byte, int(binary.BigEndian.Uint32(length)))
 	// Set timeout
-	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
-		panic(err)
+	if err := conn.SetReadDeadline(deadline(length)); err != nil {
+		return 0, nil, err
+	}
+	// Read the data
+	capacity := int(length)
+	if capacity > frameReadChunk {
+		capacity = frameReadChunk
+	}
+	data := bytes.NewBuffer(make([]byte, 0, capacity))
+	if _, err := io.CopyN(data, conn, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, slowPeerError(err)
 	}
-	_, err = io.ReadFull(reader, data)
+	msg, err := fcrmessages.FCRMsgFromBytes(data.Bytes())
 	if err != nil {
-		return nil, err
+		return requestID, nil, err
+	}
+	if max := limits.maxMessageSize(msg.GetMessageType()); length > max {
+		return requestID, msg, &frameSizeError{size: length, max: max, read: true}
 	}
-	return fcrmessages.FCRMsgFromBytes(data)
+	return requestID, msg, nil
 }
 
-// sendTCPMessage sends a tcp message to a given connection.
-func sendTCPMessage(conn net.Conn, fcrMsg *fcrmessages.FCRMessage, timeout time.Duration) error {
-	// Get data
-	data, err := fcrMsg.FCRMsgToBytes()
+// slowPeerError returns errSlowPeer if a given error is a timeout in the middle of a frame.
+func slowPeerError(err error) error {
+	if isTimeoutError(err) {
+		return errSlowPeer
+	}
+	return err
+}
+
+// sendTCPMessage sends a tcp message to a given connection using a given codec.
+func sendTCPMessage(conn net.Conn, fcrMsg *fcrmessages.FCRMessage, codec fcrmessages.Codec, timeout time.Duration) error {
+	return sendTCPFrame(conn, 0, fcrMsg, codec, timeout)
+}
+
+// sendTCPFrame sends a frame with a given request id to a given connection using a given codec.
+// The request id is omitted from the frame if it is 0.
+func sendTCPFrame(conn net.Conn, requestID uint32, fcrMsg *fcrmessages.FCRMessage, codec fcrmessages.Codec, timeout time.Duration) error {
+	frame, err := encodeFrame(requestID, fcrMsg, codec)
 	if err != nil {
 		return err
 	}
-	// Initialise a writer
-	writer := bufio.NewWriter(conn)
-	length := make([]byte, 4)
-	binary.BigEndian.PutUint32(length, uint32(len(data)))
 	// Set timeout
-	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
-		panic(err)
+	if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(frame)
+	return err
+}
+
+// sendTCPFrameContext sends a frame with a given request id to a given connection before the deadline of a given context.
+// If interruptible is set, the write is aborted if the context is cancelled. Writes on connections shared by
+// several requests must not be interrupted, a partly written frame would break the connection for all of them.
+func sendTCPFrameContext(ctx context.Context, conn net.Conn, requestID uint32, fcrMsg *fcrmessages.FCRMessage, codec fcrmessages.Codec, interruptible bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	_, err = writer.Write(append(length, data...))
+	frame, err := encodeFrame(requestID, fcrMsg, codec)
 	if err != nil {
 		return err
 	}
-	// Set timeout
-	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
-		panic(err)
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	if !interruptible {
+		_, err = conn.Write(frame)
+		return contextError(err, deadline)
+	}
+	stop := interruptOnDone(ctx, conn)
+	_, err = conn.Write(frame)
+	if stop() {
+		return ctx.Err()
 	}
-	return writer.Flush()
+	return contextError(err, deadline)
+}
+
+// contextError returns context.DeadlineExceeded if a given error is caused by the connection reaching a given context deadline.
+// The connection deadline may expire just before the context does.
+func contextError(err error, deadline time.Time) error {
+	if err != nil && !deadline.IsZero() && (isTimeoutError(err) || err == errSlowPeer) && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// encodeFrame encodes a frame with a given request id using a given codec.
+func encodeFrame(requestID uint32, fcrMsg *fcrmessages.FCRMessage, codec fcrmessages.Codec) ([]byte, error) {
+	// Get data
+	data, err := fcrMsg.FCRMsgToBytesWithCodec(codec)
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(data)) > frameLengthMask {
+		return nil, errors.New("message too long")
+	}
+	frame := make([]byte, 0, 8+len(data))
+	if requestID == 0 {
+		frame = appendUint32(frame, uint32(len(data)))
+	} else {
+		frame = appendUint32(frame, uint32(len(data))|frameRequestIDFlag)
+		frame = appendUint32(frame, requestID)
+	}
+	return append(frame, data...), nil
+}
+
+// interruptOnDone aborts the pending reads and writes on a given connection once a given context is done.
+// The returned function must be called once the reads or writes are over, it returns true if they have been aborted.
+func interruptOnDone(ctx context.Context, conn net.Conn) func() bool {
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+	over := make(chan bool)
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetDeadline(time.Now())
+			interrupted <- true
+		case <-over:
+			interrupted <- false
+		}
+	}()
+	return func() bool {
+		close(over)
+		return <-interrupted
+	}
+}
+
+// appendUint32 appends a big-endian uint32 to a given slice.
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
 }
 
 // sendProtocolChanged sends a protocol changed message to a given connection.
-func sendProtocolChanged(conn net.Conn, timeout time.Duration) error {
+func sendProtocolChanged(conn net.Conn, codec fcrmessages.Codec, timeout time.Duration) error {
 	fcrMsg, _ := fcrmessages.EncodeProtocolChangeResponse(true)
-	return sendTCPMessage(conn, fcrMsg, timeout)
+	return sendTCPMessage(conn, fcrMsg, codec, timeout)
 }
 
 // sendProtocolMismatch sends a protocol mistmatch message to a given connection.
-func sendProtocolMismatch(conn net.Conn, timeout time.Duration) error {
+func sendProtocolMismatch(conn net.Conn, codec fcrmessages.Codec, timeout time.Duration) error {
 	fcrMsg, _ := fcrmessages.EncodeProtocolChangeResponse(false)
-	return sendTCPMessage(conn, fcrMsg, timeout)
+	return sendTCPMessage(conn, fcrMsg, codec, timeout)
+}
+
+// sendInvalidMessage sends an invalid message with a given code and reason for a given request to a given connection.
+func sendInvalidMessage(conn net.Conn, codec fcrmessages.Codec, code fcrmessages.InvalidMessageCode, reason string, request *fcrmessages.FCRMessage, timeout time.Duration) error {
+	fcrMsg, _ := fcrmessages.EncodeInvalidMessageResponseWithReason(code, reason, request)
+	return sendTCPMessage(conn, fcrMsg, codec, timeout)
 }
 
-// sendInvalidMessage sends an invalid message to a given connection.
-func sendInvalidMessage(conn net.Conn, timeout time.Duration) error {
-	fcrMsg, _ := fcrmessages.EncodeInvalidMessageResponse()
-	return sendTCPMessage(conn, fcrMsg, timeout)
+// negotiate asks the peer on a given connection to switch to a given codec and to multiplex requests.
+// It returns the codec to use on the connection, which falls back to json if the peer refuses,
+// and whether the peer agreed to multiplex requests. The negotiation is aborted once a given context is done.
+func negotiate(ctx context.Context, writer *FCRServerWriter, reader *FCRServerReader, codec fcrmessages.Codec, multiplex bool) (fcrmessages.Codec, bool, error) {
+	if codec == fcrmessages.CodecJSON && !multiplex {
+		return fcrmessages.CodecJSON, false, nil
+	}
+	protocolVersion, _ := fcrmessages.GetCurrentProtocolVersion()
+	request, err := fcrmessages.EncodeProtocolChangeRequestWithOptions(protocolVersion, codec, multiplex)
+	if err != nil {
+		return fcrmessages.CodecJSON, false, err
+	}
+	if err = writer.WriteContext(ctx, request); err != nil {
+		return fcrmessages.CodecJSON, false, err
+	}
+	response, err := reader.ReadContext(ctx)
+	if err != nil {
+		return fcrmessages.CodecJSON, false, err
+	}
+	if response.GetMessageType() != fcrmessages.ProtocolChangeResponseType {
+		// Peer does not understand negotiation.
+		return fcrmessages.CodecJSON, false, nil
+	}
+	success, agreedCodec, agreedMultiplex, err := fcrmessages.DecodeProtocolChangeResponseWithOptions(response)
+	if err != nil || !success {
+		return fcrmessages.CodecJSON, false, nil
+	}
+	if agreedCodec != codec {
+		agreedCodec = fcrmessages.CodecJSON
+	}
+	return agreedCodec, multiplex && agreedMultiplex, nil
 }
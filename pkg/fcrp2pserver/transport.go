@@ -0,0 +1,162 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Transport listens for and dials the connections between nodes.
+// The listen addresses are the ones given to NewFCRP2PServer, the dial addresses are the network info of
+// the gateways and providers in the register.
+type Transport interface {
+	// Listen listens for connections on a given address.
+	Listen(address string) (net.Listener, error)
+	// Dial dials a given address, dialing is aborted once a given context is done.
+	Dial(ctx context.Context, address string) (net.Conn, error)
+}
+
+// TCPTransport is the transport over tcp, it is used by default.
+// Listen addresses are ports, listened on every interface. Dial addresses are host:port.
+type TCPTransport struct{}
+
+// Listen listens for connections on a given port.
+func (TCPTransport) Listen(port string) (net.Listener, error) {
+	return net.Listen("tcp", ":"+port)
+}
+
+// Dial dials a given host:port address.
+func (TCPTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+// UnixTransport is the transport over unix domain sockets, addresses are socket paths.
+type UnixTransport struct{}
+
+// Listen listens for connections on a given socket path.
+func (UnixTransport) Listen(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// Dial dials a given socket path.
+func (UnixTransport) Dial(ctx context.Context, path string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", path)
+}
+
+// MemoryTransport is an in-memory transport connecting the servers of a single process, addresses are arbitrary names.
+// Every server using the same MemoryTransport is on the same network, connections are net.Pipe connections.
+type MemoryTransport struct {
+	lock      sync.Mutex
+	listeners map[string]*memoryListener
+}
+
+// NewMemoryTransport creates an empty in-memory network.
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{listeners: make(map[string]*memoryListener)}
+}
+
+// Listen listens for connections on a given name, the name must not be in use.
+func (t *MemoryTransport) Listen(address string) (net.Listener, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.listeners[address] != nil {
+		return nil, fmt.Errorf("address %s already in use", address)
+	}
+	ln := &memoryListener{
+		transport: t,
+		addr:      memoryAddr(address),
+		conns:     make(chan net.Conn),
+		closed:    make(chan bool),
+	}
+	t.listeners[address] = ln
+	return ln, nil
+}
+
+// Dial dials a given name, it waits until the listener accepts the connection.
+func (t *MemoryTransport) Dial(ctx context.Context, address string) (net.Conn, error) {
+	t.lock.Lock()
+	ln := t.listeners[address]
+	t.lock.Unlock()
+	if ln == nil {
+		return nil, fmt.Errorf("connection refused by %s", address)
+	}
+	client, server := net.Pipe()
+	var err error
+	select {
+	case ln.conns <- server:
+		return client, nil
+	case <-ln.closed:
+		err = fmt.Errorf("connection refused by %s", address)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	// The connection was not accepted, close both ends of the pipe.
+	client.Close()
+	server.Close()
+	return nil, err
+}
+
+// memoryAddr is the address of an in-memory listener.
+type memoryAddr string
+
+// Network returns the name of the network.
+func (memoryAddr) Network() string { return "memory" }
+
+// String returns the name of the address.
+func (a memoryAddr) String() string { return string(a) }
+
+// memoryListener accepts the connections dialed to its address.
+type memoryListener struct {
+	transport *MemoryTransport
+	addr      memoryAddr
+	conns     chan net.Conn
+	closeOnce sync.Once
+	closed    chan bool
+}
+
+// Accept waits for the next connection.
+func (ln *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-ln.conns:
+		return conn, nil
+	case <-ln.closed:
+		return nil, errors.New("listener closed")
+	}
+}
+
+// Close stops listening, the address can be listened on again.
+func (ln *memoryListener) Close() error {
+	ln.closeOnce.Do(func() {
+		close(ln.closed)
+		ln.transport.lock.Lock()
+		defer ln.transport.lock.Unlock()
+		if ln.transport.listeners[string(ln.addr)] == ln {
+			delete(ln.transport.listeners, string(ln.addr))
+		}
+	})
+	return nil
+}
+
+// Addr returns the address of the listener.
+func (ln *memoryListener) Addr() net.Addr {
+	return ln.addr
+}
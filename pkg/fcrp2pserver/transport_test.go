@@ -0,0 +1,78 @@
+package fcrp2pserver
+
+/*
+ * Copyright 2020 ConsenSys Software Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with
+ * the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License is distributed on
+ * an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the License for the
+ * specific language governing permissions and limitations under the License.
+ *
+ * SPDX-License-Identifier: Apache-2.0
+ */
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/nodeid"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/register"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTransportRequest starts a gateway listening on a given address with a given transport,
+// and checks a request from another server using the same transport is handled.
+func testTransportRequest(t *testing.T, transport Transport, address string) {
+	gatewayID, err := nodeid.NewNodeIDFromHexString("01")
+	assert.Empty(t, err)
+	registerMgr, stop := newTestRegisterMgr(t, register.GatewayRegister{NodeID: gatewayID.ToString(), NetworkInfoGateway: address})
+	defer stop()
+
+	gateway := NewFCRP2PServer([]string{address}, nil, time.Second).
+		SetTransport(transport).
+		AddHandler(address, fcrmessages.GatewayListDHTOfferAckType, func(reader *FCRServerReader, writer *FCRServerWriter, request *fcrmessages.FCRMessage) error {
+			return writer.Write(request, time.Second)
+		})
+	assert.Empty(t, gateway.Start())
+	defer gateway.Shutdown(context.Background())
+
+	s := NewFCRP2PServer([]string{}, registerMgr, time.Second).SetTransport(transport)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	response, err := s.RequestGatewayFromGatewayContext(context.Background(), gatewayID, request)
+	assert.Empty(t, err)
+	assert.Equal(t, request.GetMessageBody(), response.GetMessageBody())
+}
+
+func TestMemoryTransport(t *testing.T) {
+	transport := NewMemoryTransport()
+	testTransportRequest(t, transport, "gateway")
+
+	// Address is free again once the gateway is shut down.
+	ln, err := transport.Listen("gateway")
+	assert.Empty(t, err)
+	_, err = transport.Listen("gateway")
+	assert.NotEmpty(t, err)
+	assert.Empty(t, ln.Close())
+
+	// Nothing listens anymore.
+	_, err = transport.Dial(context.Background(), "gateway")
+	assert.NotEmpty(t, err)
+}
+
+func TestUnixTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fcrp2pserver")
+	assert.Empty(t, err)
+	defer os.RemoveAll(dir)
+	testTransportRequest(t, UnixTransport{}, filepath.Join(dir, "gateway.sock"))
+}
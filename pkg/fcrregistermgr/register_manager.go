@@ -335,9 +335,9 @@ func (mgr *FCRRegisterMgr) GetGatewaysNearCID(cID *cid.ContentID, numDHT int, no
 	var ids []string
 	var err error
 	if notAllowed == nil {
-		ids, err = mgr.closestGatewaysIDs.GetClosest(cID.ToString(), numDHT, "")
+		ids, err = mgr.closestGatewaysIDs.GetClosest(cID.ToDHTKey(), numDHT, "")
 	} else {
-		ids, err = mgr.closestGatewaysIDs.GetClosest(cID.ToString(), numDHT, notAllowed.ToString())
+		ids, err = mgr.closestGatewaysIDs.GetClosest(cID.ToDHTKey(), numDHT, notAllowed.ToString())
 	}
 	if err != nil {
 		return nil, err
@@ -19,23 +19,59 @@ package fcrrestserver
  */
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"io/ioutil"
+	"mime"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/ant0ine/go-json-rest/rest"
 
 	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmiddleware"
 	"github.com/ConsenSys/fc-retrieval-common/pkg/logging"
 )
 
+// Default settings of a FCRRESTServer.
+const (
+	defaultMessagePath  = "/v1"
+	defaultReadTimeout  = 30 * time.Second
+	defaultWriteTimeout = 30 * time.Second
+	defaultMaxBodySize  = 10 << 20
+)
+
 // FCRRESTServer represents a REST server handling http requests.
 type FCRRESTServer struct {
 	start       bool
 	listenAddrs []string
 
+	// messagePath is the path messages are posted to, routes are extra routes served on a given address
+	messagePath string
+	routes      map[string][]*rest.Route
+
+	// http server settings
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	maxBodySize  int64
+	devStack     bool
+
+	// tls settings, tls is enabled if tlsConfig is set or if both certFile and keyFile are set
+	tlsConfig *tls.Config
+	certFile  string
+	keyFile   string
+
+	// running http servers
+	servers []*http.Server
+
 	// handlers for different message type
 	handlers map[string]map[int32]func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage)
+
+	// middlewares wrapping every handler, the first one is the outermost
+	middlewares []fcrmiddleware.Middleware
 }
 
 // NewFCRRESTServer creates an empty FCRRESTServer
@@ -43,9 +79,14 @@ func NewFCRRESTServer(
 	listenAddrs []string,
 ) *FCRRESTServer {
 	s := &FCRRESTServer{
-		start:       false,
-		listenAddrs: listenAddrs,
-		handlers:    make(map[string]map[int32]func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage)),
+		start:        false,
+		listenAddrs:  listenAddrs,
+		messagePath:  defaultMessagePath,
+		routes:       make(map[string][]*rest.Route),
+		readTimeout:  defaultReadTimeout,
+		writeTimeout: defaultWriteTimeout,
+		maxBodySize:  defaultMaxBodySize,
+		handlers:     make(map[string]map[int32]func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage)),
 	}
 	for _, listenAddr := range listenAddrs {
 		s.handlers[listenAddr] = make(map[int32]func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage))
@@ -66,44 +107,214 @@ func (s *FCRRESTServer) AddHandler(listenAddr string, msgType int32, handler fun
 	return s
 }
 
+// AddRoute is used to add a route served on a given address next to the message route, for example a health check.
+func (s *FCRRESTServer) AddRoute(listenAddr string, route *rest.Route) *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	if _, ok := s.handlers[listenAddr]; !ok {
+		return s
+	}
+	s.routes[listenAddr] = append(s.routes[listenAddr], route)
+	return s
+}
+
+// SetMessagePath is used to set the path messages are posted to, it is /v1 by default.
+func (s *FCRRESTServer) SetMessagePath(path string) *FCRRESTServer {
+	if s.start || path == "" {
+		return s
+	}
+	s.messagePath = path
+	return s
+}
+
+// SetTimeouts is used to set the maximum duration for reading a request and writing a response.
+func (s *FCRRESTServer) SetTimeouts(readTimeout time.Duration, writeTimeout time.Duration) *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	s.readTimeout = readTimeout
+	s.writeTimeout = writeTimeout
+	return s
+}
+
+// SetMaxBodySize is used to set the maximum size in bytes of a request body.
+func (s *FCRRESTServer) SetMaxBodySize(maxBodySize int64) *FCRRESTServer {
+	if s.start || maxBodySize <= 0 {
+		return s
+	}
+	s.maxBodySize = maxBodySize
+	return s
+}
+
+// SetTLS is used to serve https using given certificate and key files.
+func (s *FCRRESTServer) SetTLS(certFile string, keyFile string) *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	s.certFile = certFile
+	s.keyFile = keyFile
+	return s
+}
+
+// SetTLSConfig is used to serve https using a given tls configuration.
+func (s *FCRRESTServer) SetTLSConfig(tlsConfig *tls.Config) *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	s.tlsConfig = tlsConfig
+	return s
+}
+
+// UseDevStack is used to replace the production middleware stack with the development stack,
+// which indents responses and logs every request.
+func (s *FCRRESTServer) UseDevStack() *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	s.devStack = true
+	return s
+}
+
+// Use is used to add a middleware wrapping every handler of the server.
+// Middlewares are called in the order they are added, unknown message types also go through them.
+func (s *FCRRESTServer) Use(middleware fcrmiddleware.Middleware) *FCRRESTServer {
+	if s.start {
+		return s
+	}
+	s.middlewares = append(s.middlewares, middleware)
+	return s
+}
+
 // Start is used to start the server.
 func (s *FCRRESTServer) Start() error {
 	// Start server
 	if s.start {
 		return errors.New("server already started")
 	}
+	useTLS := s.tlsConfig != nil || (s.certFile != "" && s.keyFile != "")
+	servers := make([]*http.Server, 0, len(s.listenAddrs))
+	listeners := make([]net.Listener, 0, len(s.listenAddrs))
+	closeListeners := func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
 	for _, listenAddr := range s.listenAddrs {
-		errChan := make(chan bool)
-		go func(addr string, errChan chan bool) {
-			api := rest.NewApi()
-			api.Use(rest.DefaultDevStack...)
-			router, err := rest.MakeRouter(
-				rest.Post("/v1", func(w rest.ResponseWriter, r *rest.Request) {
-					s.msgRouter(w, r, addr)
-				}),
-			)
-			if err != nil {
-				logging.Error(err.Error())
-				errChan <- true
-				return
-			}
-			api.SetApp(router)
-			errChan <- false
-			logging.Error(http.ListenAndServe(":"+addr, api.MakeHandler()).Error())
-		}(listenAddr, errChan)
-		if <-errChan {
+		handler, err := s.makeHandler(listenAddr)
+		if err != nil {
+			logging.Error(err.Error())
+			closeListeners()
 			return errors.New("fail to start REST Server")
 		}
-		logging.Info("REST server starts listening on %s for connections.", listenAddr)
+		ln, err := net.Listen("tcp", ":"+listenAddr)
+		if err != nil {
+			closeListeners()
+			return err
+		}
+		listeners = append(listeners, ln)
+		servers = append(servers, &http.Server{
+			Handler:           handler,
+			ReadTimeout:       s.readTimeout,
+			ReadHeaderTimeout: s.readTimeout,
+			WriteTimeout:      s.writeTimeout,
+			TLSConfig:         s.tlsConfig,
+		})
+	}
+	for i, srv := range servers {
+		go func(srv *http.Server, ln net.Listener) {
+			var err error
+			if useTLS {
+				err = srv.ServeTLS(ln, s.certFile, s.keyFile)
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != http.ErrServerClosed {
+				logging.Error(err.Error())
+			}
+		}(srv, listeners[i])
+		logging.Info("REST server starts listening on %s for connections.", s.listenAddrs[i])
 	}
+	s.servers = servers
 	s.start = true
 	return nil
 }
 
+// Shutdown is used to shutdown the server gracefully.
+// It stops accepting connections and waits for in-flight requests to complete or for the given context to expire.
+func (s *FCRRESTServer) Shutdown(ctx context.Context) error {
+	if !s.start {
+		return errors.New("server not started")
+	}
+	var res error
+	for _, srv := range s.servers {
+		if err := srv.Shutdown(ctx); err != nil && res == nil {
+			res = err
+		}
+	}
+	s.servers = nil
+	s.start = false
+	return res
+}
+
+// makeHandler makes the http handler serving a given address.
+func (s *FCRRESTServer) makeHandler(listenAddr string) (http.Handler, error) {
+	api := rest.NewApi()
+	if s.devStack {
+		api.Use(middlewareStack(rest.DefaultDevStack)...)
+	} else {
+		api.Use(middlewareStack(rest.DefaultProdStack)...)
+	}
+	routes := []*rest.Route{
+		rest.Post(s.messagePath, func(w rest.ResponseWriter, r *rest.Request) {
+			s.msgRouter(w, r, listenAddr)
+		}),
+	}
+	router, err := rest.MakeRouter(append(routes, s.routes[listenAddr]...)...)
+	if err != nil {
+		return nil, err
+	}
+	api.SetApp(router)
+	return api.MakeHandler(), nil
+}
+
+// middlewareStack returns a given go-json-rest stack, with a content type check that also accepts binary messages.
+func middlewareStack(base []rest.Middleware) []rest.Middleware {
+	stack := make([]rest.Middleware, 0, len(base))
+	for _, mw := range base {
+		if _, ok := mw.(*rest.ContentTypeCheckerMiddleware); ok {
+			mw = &contentTypeCheckerMiddleware{}
+		}
+		stack = append(stack, mw)
+	}
+	return stack
+}
+
+// contentTypeCheckerMiddleware rejects requests that are neither json nor binary encoded messages.
+type contentTypeCheckerMiddleware struct{}
+
+// MiddlewareFunc makes contentTypeCheckerMiddleware implement the rest.Middleware interface.
+func (mw *contentTypeCheckerMiddleware) MiddlewareFunc(handler rest.HandlerFunc) rest.HandlerFunc {
+	return func(w rest.ResponseWriter, r *rest.Request) {
+		mediatype, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.ContentLength > 0 && mediatype != "application/json" && mediatype != "application/octet-stream" {
+			rest.Error(w, "Bad Content-Type, expected 'application/json' or 'application/octet-stream'", http.StatusUnsupportedMediaType)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 // msgRouter routes message
 func (s *FCRRESTServer) msgRouter(w rest.ResponseWriter, r *rest.Request, listenAddr string) {
-	logging.Trace("Received request via /v1 API")
-	content, err := ioutil.ReadAll(r.Body)
+	logging.Trace("Received request via %s API", s.messagePath)
+	if r.ContentLength > s.maxBodySize {
+		logging.Error("Error request too large: %d bytes.", r.ContentLength)
+		writeInvalidMessageWithStatus(w, http.StatusRequestEntityTooLarge, fcrmessages.InvalidMessageTooLarge, "Error request too large", nil)
+		return
+	}
+	// Read one byte more than allowed to detect bodies without content length that are too large.
+	content, err := ioutil.ReadAll(io.LimitReader(r.Body, s.maxBodySize+1))
 
 	if closeErr := r.Body.Close(); closeErr != nil {
 		logging.Error("msgRouter can't close request body")
@@ -111,25 +322,76 @@ func (s *FCRRESTServer) msgRouter(w rest.ResponseWriter, r *rest.Request, listen
 
 	if err != nil {
 		logging.Error("Error reading request: %s.", err.Error())
-		rest.Error(w, "Error reading request", http.StatusBadRequest)
+		writeInvalidMessage(w, fcrmessages.InvalidMessageMalformed, "Error reading request", nil)
+		return
+	}
+	if int64(len(content)) > s.maxBodySize {
+		logging.Error("Error request too large")
+		writeInvalidMessageWithStatus(w, http.StatusRequestEntityTooLarge, fcrmessages.InvalidMessageTooLarge, "Error request too large", nil)
 		return
 	}
 	if len(content) == 0 {
 		logging.Error("Error empty request")
-		rest.Error(w, "Error empty request", http.StatusBadRequest)
+		writeInvalidMessage(w, fcrmessages.InvalidMessageMalformed, "Error empty request", nil)
 		return
 	}
 	message, err := fcrmessages.FCRMsgFromBytes(content)
 	if err != nil {
 		logging.Error("Failed to decode payload: %s.", err.Error())
-		rest.Error(w, "Failed to decode payload: "+err.Error(), http.StatusBadRequest)
+		writeInvalidMessage(w, fcrmessages.InvalidMessageMalformed, "Failed to decode payload: "+err.Error(), nil)
 		return
 	}
 	handler := s.handlers[listenAddr][message.GetMessageType()]
-	if handler != nil {
-		handler(w, message)
-	} else {
-		logging.Warn("Client Request: Unknown message type: %d", message.GetMessageType())
-		rest.Error(w, "Unknown message type", http.StatusBadRequest)
+	// Call handler through the middlewares to handle the request
+	err = fcrmiddleware.Chain(func(request *fcrmiddleware.Request) error {
+		if handler == nil {
+			logging.Warn("Client Request: Unknown message type: %d", request.Message.GetMessageType())
+			return fcrmiddleware.Reject(fcrmessages.InvalidMessageUnknownType, "Unknown message type")
+		}
+		handler(w, request.Message)
+		return nil
+	}, s.middlewares...)(&fcrmiddleware.Request{
+		Context:    r.Context(),
+		Transport:  fcrmiddleware.TransportREST,
+		ListenAddr: listenAddr,
+		RemoteAddr: r.RemoteAddr,
+		Message:    message,
+	})
+	if rejected, ok := err.(*fcrmiddleware.RejectError); ok {
+		writeInvalidMessageWithStatus(w, rejectStatus(rejected.Code), rejected.Code, rejected.Reason, message)
+	} else if err != nil {
+		logging.Error("Error handling request: %s.", err.Error())
+		rest.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// rejectStatus returns the http status of a request rejected with a given code.
+func rejectStatus(code fcrmessages.InvalidMessageCode) int {
+	switch code {
+	case fcrmessages.InvalidMessageRateLimited:
+		return http.StatusTooManyRequests
+	case fcrmessages.InvalidMessageOverloaded:
+		return http.StatusServiceUnavailable
+	case fcrmessages.InvalidMessageTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// writeInvalidMessage responds with a bad request status and an invalid message explaining the failure.
+func writeInvalidMessage(w rest.ResponseWriter, code fcrmessages.InvalidMessageCode, reason string, request *fcrmessages.FCRMessage) {
+	writeInvalidMessageWithStatus(w, http.StatusBadRequest, code, reason, request)
+}
+
+// writeInvalidMessageWithStatus responds with a given status and an invalid message explaining the failure.
+func writeInvalidMessageWithStatus(w rest.ResponseWriter, status int, code fcrmessages.InvalidMessageCode, reason string, request *fcrmessages.FCRMessage) {
+	response, err := fcrmessages.EncodeInvalidMessageResponseWithReason(code, reason, request)
+	if err != nil {
+		rest.Error(w, reason, status)
+		return
+	}
+	w.WriteHeader(status)
+	if err = w.WriteJson(response); err != nil {
+		logging.Error("Error writing invalid message response: %s", err.Error())
 	}
 }
@@ -16,7 +16,27 @@ package fcrrestserver
  */
 
 import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ant0ine/go-json-rest/rest"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmessages"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/fcrmiddleware"
+	"github.com/ConsenSys/fc-retrieval-common/pkg/utest"
 )
 
 func ExampleNewFCRRESTServer() {
@@ -29,3 +49,209 @@ func ExampleNewFCRRESTServer() {
 	// <nil>
 }
 
+func TestUnknownMessageType(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port})
+	assert.Empty(t, s.Start())
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":7}`))
+	data, err := request.FCRMsgToBytesWithCodec(fcrmessages.CodecBinary)
+	assert.Empty(t, err)
+	resp, err := http.Post("http://localhost:"+port+"/v1", "application/octet-stream", bytes.NewReader(data))
+	assert.Empty(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	content, err := ioutil.ReadAll(resp.Body)
+	assert.Empty(t, err)
+	response, err := fcrmessages.FCRMsgFromBytes(content)
+	assert.Empty(t, err)
+	code, _, msgType, nonce, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageUnknownType, code)
+	assert.Equal(t, int32(fcrmessages.GatewayPingRequestType), msgType)
+	assert.Equal(t, int64(7), nonce)
+}
+
+func TestMiddleware(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port}).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage) {
+			rw.WriteJson(request)
+		}).
+		Use(func(next fcrmiddleware.Handler) fcrmiddleware.Handler {
+			return func(request *fcrmiddleware.Request) error {
+				assert.Equal(t, fcrmiddleware.TransportREST, request.Transport)
+				assert.Equal(t, port, request.ListenAddr)
+				if request.Message.GetMessageType() == fcrmessages.GatewayPingRequestType {
+					return fcrmiddleware.Reject(fcrmessages.InvalidMessageSignature, "ping not allowed")
+				}
+				return next(request)
+			}
+		})
+	assert.Empty(t, s.Start())
+
+	post := func(request *fcrmessages.FCRMessage) (int, *fcrmessages.FCRMessage) {
+		data, err := request.FCRMsgToBytes()
+		assert.Empty(t, err)
+		resp, err := http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader(data))
+		assert.Empty(t, err)
+		defer resp.Body.Close()
+		content, err := ioutil.ReadAll(resp.Body)
+		assert.Empty(t, err)
+		response, err := fcrmessages.FCRMsgFromBytes(content)
+		assert.Empty(t, err)
+		return resp.StatusCode, response
+	}
+
+	// Passed to the handler.
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`))
+	status, response := post(request)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, request.GetMessageType(), response.GetMessageType())
+
+	// Rejected by the middleware.
+	status, response = post(fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":1}`)))
+	assert.Equal(t, http.StatusBadRequest, status)
+	code, reason, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageSignature, code)
+	assert.Equal(t, "ping not allowed", reason)
+}
+
+func TestShutdown(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port})
+	assert.Empty(t, s.Start())
+	assert.NotEmpty(t, s.Start())
+
+	resp, err := http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader([]byte(`{}`)))
+	assert.Empty(t, err)
+	resp.Body.Close()
+	assert.Empty(t, s.Shutdown(context.Background()))
+	assert.NotEmpty(t, s.Shutdown(context.Background()))
+	_, err = http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader([]byte(`{}`)))
+	assert.NotEmpty(t, err)
+
+	// Address can be reused.
+	assert.Empty(t, s.Start())
+	assert.Empty(t, s.Shutdown(context.Background()))
+}
+
+func TestMaxBodySize(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port}).SetMaxBodySize(64)
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, bytes.Repeat([]byte("0"), 64))
+	data, err := request.FCRMsgToBytes()
+	assert.Empty(t, err)
+	resp, err := http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader(data))
+	assert.Empty(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	content, err := ioutil.ReadAll(resp.Body)
+	assert.Empty(t, err)
+	response, err := fcrmessages.FCRMsgFromBytes(content)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageTooLarge, code)
+}
+
+func TestRoutes(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port}).
+		SetMessagePath("/v2").
+		AddRoute(port, rest.Get("/health", func(w rest.ResponseWriter, r *rest.Request) {
+			w.WriteJson(map[string]string{"status": "ok"})
+		}))
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	resp, err := http.Get("http://localhost:" + port + "/health")
+	assert.Empty(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":7}`))
+	data, err := request.FCRMsgToBytes()
+	assert.Empty(t, err)
+	resp, err = http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader(data))
+	assert.Empty(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, err = http.Post("http://localhost:"+port+"/v2", "application/json", bytes.NewReader(data))
+	assert.Empty(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTLS(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port}).SetTLSConfig(&tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}})
+	assert.Empty(t, s.Start())
+	defer s.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	request := fcrmessages.CreateFCRMessage(fcrmessages.GatewayPingRequestType, []byte(`{"nonce":7}`))
+	data, err := request.FCRMsgToBytes()
+	assert.Empty(t, err)
+	resp, err := client.Post("https://localhost:"+port+"/v1", "application/json", bytes.NewReader(data))
+	assert.Empty(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.NotEmpty(t, resp.TLS)
+}
+
+// selfSignedCertificate generates a certificate for localhost.
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Empty(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Empty(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestLimiter(t *testing.T) {
+	port := utest.GetFreePort()
+	s := NewFCRRESTServer([]string{port}).
+		AddHandler(port, fcrmessages.GatewayListDHTOfferAckType, func(rw rest.ResponseWriter, request *fcrmessages.FCRMessage) {
+			rw.WriteJson(request)
+		}).
+		Use(fcrmiddleware.NewLimiter().SetRate(0.001, 1).Middleware())
+	assert.Empty(t, s.Start())
+
+	post := func() *http.Response {
+		data, err := fcrmessages.CreateFCRMessage(fcrmessages.GatewayListDHTOfferAckType, []byte(`{}`)).FCRMsgToBytes()
+		assert.Empty(t, err)
+		resp, err := http.Post("http://localhost:"+port+"/v1", "application/json", bytes.NewReader(data))
+		assert.Empty(t, err)
+		return resp
+	}
+	resp := post()
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Over quota.
+	resp = post()
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	content, err := ioutil.ReadAll(resp.Body)
+	assert.Empty(t, err)
+	response, err := fcrmessages.FCRMsgFromBytes(content)
+	assert.Empty(t, err)
+	code, _, _, _, err := fcrmessages.DecodeInvalidMessageResponseWithReason(response)
+	assert.Empty(t, err)
+	assert.Equal(t, fcrmessages.InvalidMessageRateLimited, code)
+}
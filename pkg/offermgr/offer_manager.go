@@ -31,14 +31,62 @@ func NewFCROfferMgr() *FCROfferMgr {
 			offerDBInitialized = true
 		}
 		db.Exec(`create table if not exists offer (digest blob, provider_id blob, expiry blob, price blob, qos blob, signature blob, primary key (digest))`)
-		db.Exec(`create table if not exists content (content_id blob, content_no int, digest blob, primary key (digest, content_no))`)
+		db.Exec(`create table if not exists content (content_id blob, content_no int, digest blob, dht_key blob, primary key (digest, content_no))`)
+		migrateContentDHTKey(db)
 		db.Exec(`create index if not exists content_cid_idx on content (content_id)`)
+		db.Exec(`create index if not exists content_dht_key_idx on content (dht_key)`)
 	}
 	return &FCROfferMgr{
 		db: db,
 	}
 }
 
+// migrateContentDHTKey adds the dht_key column to a content table created before CIDs were stored with their DHT key,
+// and fills it for the rows without one.
+func migrateContentDHTKey(db *database.Database) error {
+	rows, err := db.Query(`select count(*) from pragma_table_info('content') where name = 'dht_key'`)
+	if err != nil {
+		return err
+	}
+	var columns int
+	for rows.Next() {
+		rows.Scan(&columns)
+	}
+	rows.Close()
+	if columns == 0 {
+		if _, err = db.Exec(`alter table content add column dht_key blob`); err != nil {
+			return err
+		}
+	}
+	type contentRow struct {
+		digest    string
+		contentNo int
+		contentID string
+	}
+	rows, err = db.Query(`select digest, content_no, content_id from content where dht_key is null`)
+	if err != nil {
+		return err
+	}
+	missing := []contentRow{}
+	for rows.Next() {
+		var row contentRow
+		rows.Scan(&row.digest, &row.contentNo, &row.contentID)
+		missing = append(missing, row)
+	}
+	rows.Close()
+	for _, row := range missing {
+		contentID, err := cid.NewContentIDFromString(row.contentID)
+		if err != nil {
+			continue
+		}
+		_, err = db.Exec(`update content set dht_key = ? where digest = ? and content_no = ?`, contentID.ToDHTKey(), row.digest, row.contentNo)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddGroupOffer stores a group offer
 func (mgr *FCROfferMgr) AddGroupOffer(offer *cidoffer.CIDOffer) error {
 	return mgr.insertOffer(offer)
@@ -83,8 +131,8 @@ func (mgr *FCROfferMgr) insertOffer(offer *cidoffer.CIDOffer) (err error) {
 		from (values (1)) a 
 		where not exists (select digest from offer where digest = ?)`
 	sqlInsertContent :=
-		`insert into content (content_id, content_no, digest) 
-		select ? content_id, ? content_no, ? digest
+		`insert into content (content_id, content_no, digest, dht_key) 
+		select ? content_id, ? content_no, ? digest, ? dht_key
 		from (values (1)) a 
 		where not exists (select 1 from content where content_no = ? and digest = ?)`
 	digestArr := offer.GetMessageDigest()
@@ -100,33 +148,34 @@ func (mgr *FCROfferMgr) insertOffer(offer *cidoffer.CIDOffer) (err error) {
 	}
 	i := 0
 	for _, contentID := range offer.GetCIDs() {
-		_, err = mgr.db.Exec(sqlInsertContent, contentID.ToString(), i, digestHex, i, digestHex)
+		_, err = mgr.db.Exec(sqlInsertContent, contentID.ToString(), i, digestHex, contentID.ToDHTKey(), i, digestHex)
 		i++
 	}
 	return err
 }
 
-// selectOffersSingle retrieves offers by a CID
+// selectOffersSingle retrieves offers by the DHT key of a CID, whatever the CID version they were stored with
 func (mgr *FCROfferMgr) selectOffersSingle(c *cid.ContentID) (res []cidoffer.CIDOffer, find bool) {
 	sqlSelectOffer :=
 		`select o.digest, o.provider_id, o.expiry, o.price, o.qos, o.signature
-		from offer o join content c on o.digest=c.digest 
-		where c.content_id=?
+		from offer o, (select distinct digest from content 
+				where dht_key=?) c
+		where o.digest=c.digest
 		and datetime(o.expiry, 'unixepoch') > datetime('now', 'utc')`
 
-	return mgr.selectOffers(sqlSelectOffer, -1, c.ToString())
+	return mgr.selectOffers(sqlSelectOffer, -1, c.ToDHTKey())
 }
 
-// selectOffersRange retrieves offers by a range of CIDs
+// selectOffersRange retrieves offers by a range of CID DHT keys
 func (mgr *FCROfferMgr) selectOffersRange(maxOffers int, cidMin *cid.ContentID, cidMax *cid.ContentID) (res []cidoffer.CIDOffer, find bool) {
 	sqlSelectOffer :=
 		`select o.digest, o.provider_id, o.expiry, o.price, o.qos, o.signature
 		from offer o, (select distinct digest from content 
-				where content_id>=? and content_id<=?) c
+				where dht_key>=? and dht_key<=?) c
 		where o.digest=c.digest
 		and datetime(o.expiry, 'unixepoch') > datetime('now', 'utc')`
 
-	return mgr.selectOffers(sqlSelectOffer, maxOffers, cidMin.ToString(), cidMax.ToString())
+	return mgr.selectOffers(sqlSelectOffer, maxOffers, cidMin.ToDHTKey(), cidMax.ToDHTKey())
 }
 
 // selectOffersDigest retrieves a offer by a digest
@@ -165,7 +214,7 @@ func (mgr *FCROfferMgr) selectOffers(sqlSelectOffer string, maxOffers int, param
 		for cidRows.Next() {
 			var aCidStr string
 			cidRows.Scan(&aCidStr)
-			aCid, _ := cid.NewContentIDFromHexString(aCidStr)
+			aCid, _ := cid.NewContentIDFromString(aCidStr)
 			cids = append(cids, *aCid)
 			find = true
 		}
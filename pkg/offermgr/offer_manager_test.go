@@ -2,6 +2,7 @@
 package offermgr
 
 import (
+	"encoding/hex"
 	//	"errors"
 	// "fmt"
 	"math/big"
@@ -101,6 +102,58 @@ func TestGetDTHOffers02(t *testing.T) {
 	assert.Equal(t, false, find)
 }
 
+func TestMigrateContentDHTKey(t *testing.T) {
+	mgr := NewFCROfferMgr()
+
+	// Offer stored with the content schema without DHT keys.
+	_, err := mgr.db.Exec(`drop table content`)
+	assert.Empty(t, err)
+	_, err = mgr.db.Exec(`create table content (content_id blob, content_no int, digest blob, primary key (digest, content_no))`)
+	assert.Empty(t, err)
+	offerGroup, err := getOfferGroup()
+	assert.Empty(t, err)
+	digest := offerGroup.GetMessageDigest()
+	digestHex := hex.EncodeToString(digest[:])
+	_, err = mgr.db.Exec(`insert into offer (digest, provider_id, expiry, price, qos, signature) values (?, ?, ?, ?, ?, ?)`,
+		digestHex, offerGroup.GetProviderID().ToString(), offerGroup.GetExpiry(), offerGroup.GetPrice(), offerGroup.GetQoS(), offerGroup.GetSignature())
+	assert.Empty(t, err)
+	for i, contentID := range offerGroup.GetCIDs() {
+		_, err = mgr.db.Exec(`insert into content (content_id, content_no, digest) values (?, ?, ?)`, contentID.ToString(), i, digestHex)
+		assert.Empty(t, err)
+	}
+
+	assert.Empty(t, migrateContentDHTKey(mgr.db))
+	offers, _ := mgr.GetDHTOffersWithinRange(intToCid(6), intToCid(8), 3)
+	assert.Equal(t, 1, len(offers))
+
+	// Offers are stored in the migrated table, migrating again changes nothing.
+	offerSingle, err := getOfferSingle(7)
+	assert.Empty(t, err)
+	assert.Empty(t, mgr.AddDHTOffer(offerSingle))
+	assert.Empty(t, migrateContentDHTKey(mgr.db))
+	offers, _ = mgr.GetDHTOffersWithinRange(intToCid(6), intToCid(8), 3)
+	assert.Equal(t, 2, len(offers))
+}
+
+func TestGetOffersCIDVersion(t *testing.T) {
+	v0, err := cid.NewContentIDFromString("QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG")
+	assert.Empty(t, err)
+	v1, err := cid.NewContentIDFromString("bafybeie5nqv6kd3qnfjupgvz34woh3oksc3iau6abmyajn7qvtf6d2ho34")
+	assert.Empty(t, err)
+	aNodeID, err := nodeid.NewNodeID(big.NewInt(7))
+	assert.Empty(t, err)
+	offer, err := cidoffer.NewCIDOffer(aNodeID, []cid.ContentID{*v0}, 5, time.Now().Add(12*time.Hour).Unix(), 5)
+	assert.Empty(t, err)
+	mgr := NewFCROfferMgr()
+	assert.Empty(t, mgr.AddDHTOffer(offer))
+
+	offers, find := mgr.GetDHTOffers(v1)
+	assert.True(t, find)
+	assert.Equal(t, 1, len(offers))
+	offers, find = mgr.GetOffers(v0)
+	assert.True(t, find)
+	assert.Equal(t, 1, len(offers))
+}
 
 // Helper functions
 